@@ -0,0 +1,38 @@
+package main
+
+import (
+	"os"
+
+	"github.com/openshift/must-gather-clean/pkg/cli"
+	"github.com/spf13/cobra"
+	"k8s.io/klog/v2"
+)
+
+var (
+	DiffOldOutput string
+	DiffNewOutput string
+	DiffFormat    string
+)
+
+// diffCmd represents the diff command
+var diffCmd = &cobra.Command{
+	Use:   "diff <old-report> <new-report>",
+	Short: "Compare the reports of two runs",
+	Long:  "Compares two reports in the YAML or JSON format, for example of runs with a changed configuration, and prints the values that were added to or removed from each obfuscator along with the added and removed omissions. The diff contains original values and must not be shared like a report",
+	Args:  cobra.ExactArgs(2),
+	Run: func(_ *cobra.Command, args []string) {
+		defer klog.Flush()
+
+		err := cli.Diff(args[0], args[1], DiffOldOutput, DiffNewOutput, DiffFormat, os.Stdout)
+		if err != nil {
+			klog.Exitf("%v\n", err)
+		}
+	},
+}
+
+func init() {
+	diffCmd.Flags().StringVar(&DiffOldOutput, "old-output", "", "The output directory of the old run, the files of both outputs are compared if set along with --new-output")
+	diffCmd.Flags().StringVar(&DiffNewOutput, "new-output", "", "The output directory of the new run, the files of both outputs are compared if set along with --old-output")
+	diffCmd.Flags().StringVar(&DiffFormat, "format", "text", "The format of the diff, one of text, yaml or json")
+	rootCmd.AddCommand(diffCmd)
+}
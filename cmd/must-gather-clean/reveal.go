@@ -0,0 +1,33 @@
+package main
+
+import (
+	"os"
+
+	"github.com/openshift/must-gather-clean/pkg/cli"
+	"github.com/spf13/cobra"
+	"k8s.io/klog/v2"
+)
+
+var RevealKey string
+
+// revealCmd represents the reveal command
+var revealCmd = &cobra.Command{
+	Use:   "reveal <mapping.yaml.age>",
+	Short: "Decrypt an encrypted mapping",
+	Long:  "Decrypts a mapping that was encrypted with --report-recipient and prints it, the mapping relates the original values with their replacements",
+	Args:  cobra.ExactArgs(1),
+	Run: func(_ *cobra.Command, args []string) {
+		defer klog.Flush()
+
+		err := cli.Reveal(args[0], RevealKey, os.Stdout)
+		if err != nil {
+			klog.Exitf("%v\n", err)
+		}
+	},
+}
+
+func init() {
+	revealCmd.Flags().StringVar(&RevealKey, "report-key", "", "The path to the age identity file of a recipient the mapping was encrypted to")
+	_ = revealCmd.MarkFlagRequired("report-key")
+	rootCmd.AddCommand(revealCmd)
+}
@@ -10,6 +10,7 @@ import (
 	"k8s.io/klog/v2"
 
 	"github.com/openshift/must-gather-clean/pkg/cli"
+	"github.com/openshift/must-gather-clean/pkg/traversal"
 	"github.com/spf13/cobra"
 )
 
@@ -20,9 +21,35 @@ var (
 	InputFolder        string
 	OutputFolder       string
 	ReportingFolder    string
+	ReportFormat       string
 	WorkerCount        int
+	ReportLocations    int
+	SplitReport        bool
+	ReportRecipient    string
+	ReportKey          string
+	SeedReport         string
+	AllowReportOutput  bool
+	StatisticsFile     string
+	Progress           string
+	SigningKey         string
+	InputDigest        bool
 )
 
+// progressAuto renders a progress line if stderr is a terminal and no progress otherwise, JSON progress events have to
+// be requested explicitly.
+const progressAuto = "auto"
+
+func progressFormat() traversal.ProgressFormat {
+	if Progress != progressAuto {
+		return traversal.ProgressFormat(Progress)
+	}
+	stat, err := os.Stderr.Stat()
+	if err == nil && (stat.Mode()&os.ModeCharDevice) != 0 {
+		return traversal.ProgressLine
+	}
+	return traversal.ProgressNone
+}
+
 // rootCmd represents the base command when called without any subcommands
 var rootCmd = &cobra.Command{
 	Use:   "must-gather-clean",
@@ -37,7 +64,20 @@ var rootCmd = &cobra.Command{
 				klog.Exitf("%v\n", err)
 			}
 		} else {
-			err := cli.Run(ConfigFile, InputFolder, OutputFolder, DeleteOutputFolder, ReportingFolder, WorkerCount)
+			report := cli.ReportOptions{
+				Folder:        ReportingFolder,
+				Format:        ReportFormat,
+				MaxLocations:  ReportLocations,
+				Split:         SplitReport,
+				Recipient:     ReportRecipient,
+				Key:           ReportKey,
+				Seed:          SeedReport,
+				AllowInOutput: AllowReportOutput,
+				Prometheus:    StatisticsFile,
+				SigningKey:    SigningKey,
+				InputDigest:   InputDigest,
+			}
+			err := cli.Run(ConfigFile, InputFolder, OutputFolder, DeleteOutputFolder, report, WorkerCount, progressFormat())
 			if err != nil {
 				klog.Exitf("%v\n", err)
 			}
@@ -53,6 +93,17 @@ func initFlags() {
 	flags.BoolVarP(&DeleteOutputFolder, "overwrite", "d", false, "If the output directory exists, setting this flag will delete the folder and all its contents before cleaning.")
 	flags.IntVarP(&WorkerCount, "worker-count", "w", runtime.NumCPU(), "The number of workers for processing")
 	flags.StringVarP(&ReportingFolder, "report", "r", ".", "The directory of the reporting output folder, default is the current working directory")
+	flags.StringVar(&ReportFormat, "report-format", "yaml", "The format of the report, one of yaml, json, csv or html")
+	flags.IntVar(&ReportLocations, "report-locations", 0, "If set, records up to this many file and line locations of each replaced value in locations.yaml next to the report, or in the mapping of a split report")
+	flags.BoolVar(&SplitReport, "split-report", false, "If set, the report only contains a summary without original values and the replacements are written into mapping.yaml next to it")
+	flags.StringVar(&ReportRecipient, "report-recipient", "", "An age recipient (age1...) or the path to a file with one recipient per line, the mapping is encrypted to them and written into mapping.yaml.age. Implies --split-report")
+	flags.StringVar(&ReportKey, "report-key", "", "The path to the age identity file that decrypts an encrypted mapping passed as the config or with --seed-report")
+	flags.StringVar(&SeedReport, "seed-report", "", "The path to the report or mapping of a previous run, values it replaced are replaced the same by the obfuscators of the config")
+	flags.StringVar(&StatisticsFile, "statistics-prometheus", "", "If set, writes the statistics of the run into this file in the Prometheus text format, for example for the textfile collector of the node exporter")
+	flags.StringVar(&SigningKey, "signing-key", "", "The path to an unencrypted ed25519 private key in the OpenSSH or PKCS #8 format, the manifest of the output is signed with it into manifest.json.sig")
+	flags.BoolVar(&InputDigest, "manifest-input-digest", false, "If set, the digest of the input directory is recorded in the manifest of the output, which reads the whole input a second time")
+	flags.StringVar(&Progress, "progress", progressAuto, "How the progress is rendered to stderr, one of auto, line, json or none. auto renders a line on a terminal and nothing otherwise")
+	flags.BoolVar(&AllowReportOutput, "allow-report-in-output", false, "If set, the report may be written inside the output directory. Use with care, the report must not be shared along with the output")
 
 	if !PipeModeEnabled {
 		_ = rootCmd.MarkFlagRequired("config")
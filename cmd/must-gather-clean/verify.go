@@ -0,0 +1,30 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/openshift/must-gather-clean/pkg/cli"
+	"github.com/spf13/cobra"
+	"k8s.io/klog/v2"
+)
+
+// verifyCmd represents the verify command
+var verifyCmd = &cobra.Command{
+	Use:   "verify <output>",
+	Short: "Check an output directory for reports before sharing it",
+	Long:  "Searches the output directory for files that look like reports, mappings or locations, which relate the original values with their replacements and must not be shared",
+	Args:  cobra.ExactArgs(1),
+	Run: func(_ *cobra.Command, args []string) {
+		defer klog.Flush()
+
+		err := cli.Verify(args[0])
+		if err != nil {
+			klog.Exitf("%v\n", err)
+		}
+		fmt.Printf("no reports found in %s\n", args[0])
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(verifyCmd)
+}
@@ -0,0 +1,34 @@
+package main
+
+import (
+	"os"
+
+	"github.com/openshift/must-gather-clean/pkg/cli"
+	"github.com/spf13/cobra"
+	"k8s.io/klog/v2"
+)
+
+var WatermarkOptions cli.VerifyWatermarkOptions
+
+// verifyWatermarkCmd represents the verify-watermark command
+var verifyWatermarkCmd = &cobra.Command{
+	Use:   "verify-watermark <output>",
+	Short: "Check an output directory against its manifest",
+	Long:  "Verifies the signature of the manifest of an output directory and checks that every file of the output matches its hash in the manifest. Without --public-key, any key is accepted and the signer is not verified. With --config, the configuration file must match the one the output was cleaned with",
+	Args:  cobra.ExactArgs(1),
+	Run: func(_ *cobra.Command, args []string) {
+		defer klog.Flush()
+
+		err := cli.VerifyWatermark(args[0], WatermarkOptions, os.Stdout)
+		if err != nil {
+			klog.Exitf("%v\n", err)
+		}
+	},
+}
+
+func init() {
+	verifyWatermarkCmd.Flags().StringVarP(&WatermarkOptions.Config, "config", "c", "", "The path to the configuration file the output must have been cleaned with, it is compared byte by byte with the hash in the manifest")
+	verifyWatermarkCmd.Flags().StringVar(&WatermarkOptions.PublicKey, "public-key", "", "The path to the ed25519 public key the manifest must be signed by, either an OpenSSH public key like id_ed25519.pub or PEM encoded")
+	verifyWatermarkCmd.Flags().BoolVar(&WatermarkOptions.AllowUnsigned, "allow-unsigned", false, "If set, the files of an output with an unsigned manifest are checked instead of failing, anyone could have written such a manifest")
+	rootCmd.AddCommand(verifyWatermarkCmd)
+}
@@ -3,6 +3,7 @@ module github.com/openshift/must-gather-clean
 go 1.16
 
 require (
+	filippo.io/age v1.0.0
 	github.com/atombender/go-jsonschema v0.0.0-00010101000000-000000000000
 	github.com/openshift/build-machinery-go v0.0.0-20210712174854-1bb7fd1518d3
 	github.com/spf13/cobra v1.1.3
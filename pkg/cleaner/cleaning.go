@@ -8,12 +8,15 @@ import (
 	"io"
 	"os"
 	"path/filepath"
+	"strings"
 	"sync"
 
 	"github.com/openshift/must-gather-clean/pkg/fsutil"
 	"github.com/openshift/must-gather-clean/pkg/kube"
 	"github.com/openshift/must-gather-clean/pkg/obfuscator"
 	"github.com/openshift/must-gather-clean/pkg/omitter"
+	"github.com/openshift/must-gather-clean/pkg/reporting"
+	"github.com/openshift/must-gather-clean/pkg/schema"
 )
 
 // Processor is responsible for processing a single input file.
@@ -38,6 +41,16 @@ type FileObfuscator interface {
 // ContentObfuscator wraps any obfuscator and implements ReadWriteObfuscator
 type ContentObfuscator struct {
 	Obfuscator obfuscator.Obfuscator
+	// IsMultiline is optional, it selects the obfuscators of a MultiObfuscator by their index that match across blocks of
+	// joined lines, all others match within each line of the blocks. Any other Obfuscator runs on the whole blocks.
+	IsMultiline func(i int) bool
+	// Multiline defines how the lines are joined into blocks if IsMultiline is set
+	Multiline schema.SchemaJsonConfigMultiline
+	// Locations is optional, it records where the obfuscators replaced values in the files of a FileProcessor
+	Locations reporting.LocationTracker
+
+	// path is the file that is obfuscated, it is only known by a FileProcessor
+	path string
 }
 
 // FileContentObfuscator obfuscates a file by implementing FileObfuscator and ReadWriteObfuscator.
@@ -89,10 +102,16 @@ func (c *FileProcessor) Process(path string) error {
 	}
 
 	// obfuscate the text file with updated path name, which can also contain confidential information
-	return c.ObfuscateFile(path, c.FileContentObfuscator.Obfuscator.Path(path))
+	scoped := c.forFile(path, kubeResource)
+	return c.obfuscateFile(path, scoped.obfuscatePath(path), scoped)
 }
 
 func (c *FileContentObfuscator) ObfuscateFile(inputFile string, outputFile string) error {
+	return c.obfuscateFile(inputFile, outputFile, &c.ContentObfuscator)
+}
+
+// obfuscateFile obfuscates the input file with the given content obfuscator and writes the result into the outputFile.
+func (c *FileContentObfuscator) obfuscateFile(inputFile string, outputFile string, content *ContentObfuscator) error {
 	readPath := filepath.Join(c.inputFolder, inputFile)
 	readPathParentDir := filepath.Dir(readPath)
 	writePath := filepath.Join(c.outputFolder, outputFile)
@@ -123,7 +142,7 @@ func (c *FileContentObfuscator) ObfuscateFile(inputFile string, outputFile strin
 		return fmt.Errorf("failed to create and open '%s': %w", writePath, err)
 	}
 
-	err = c.ObfuscateReader(inputOsFile, outputOsFile)
+	err = content.ObfuscateReader(inputOsFile, outputOsFile)
 	if err != nil {
 		return fmt.Errorf("failed to obfuscate input file '%s': %w", readPath, err)
 	}
@@ -152,12 +171,80 @@ func (c *FileContentObfuscator) createNonConflictingFileUnderLock(outputFilePath
 	return fsutil.CreateNonConflictingFile(outputFilePath, inputFileInfo)
 }
 
+// forFile returns a ContentObfuscator with only the obfuscators that apply to the file at the given path, resources are
+// nil if the file does not contain Kubernetes resources.
+func (c *ContentObfuscator) forFile(path string, resources *kube.ResourceListWithPath) *ContentObfuscator {
+	scoped := *c
+	scoped.path = path
+	if mo, ok := c.Obfuscator.(*obfuscator.MultiObfuscator); ok {
+		scoped.Obfuscator = mo.ForFile(path, resources)
+	}
+	return &scoped
+}
+
+// obfuscatePath obfuscates a path with all obfuscators, since a path is always a single line.
+func (c *ContentObfuscator) obfuscatePath(path string) string {
+	return c.obfuscate(c.Obfuscator, path, schema.ObfuscateTargetFilePath, 0)
+}
+
+// obfuscate runs the obfuscator on the path or contents of the file, starting at the given line of its contents. The
+// locations of the replaced values are recorded, as long as the file and the tracker are known.
+func (c *ContentObfuscator) obfuscate(o obfuscator.Obfuscator, input string, target schema.ObfuscateTarget, line int) string {
+	mo, ok := o.(*obfuscator.MultiObfuscator)
+	if !ok || c.Locations == nil || c.path == "" {
+		if target == schema.ObfuscateTargetFilePath {
+			return o.Path(input)
+		}
+		return o.Contents(input)
+	}
+
+	track := c.track(input, target, line)
+	if target == schema.ObfuscateTargetFilePath {
+		return mo.PathMatches(input, track)
+	}
+	return mo.ContentsMatches(input, track)
+}
+
+// obfuscateBlock runs the obfuscators on a block of joined lines that starts at the given line of the contents. Only the
+// obfuscators selected by IsMultiline match across its lines.
+func (c *ContentObfuscator) obfuscateBlock(block string, line int) string {
+	mo, ok := c.Obfuscator.(*obfuscator.MultiObfuscator)
+	if !ok {
+		return c.obfuscate(c.Obfuscator, block, schema.ObfuscateTargetFileContents, line)
+	}
+	var track obfuscator.MatchFunc
+	if c.Locations != nil && c.path != "" {
+		track = c.track(block, schema.ObfuscateTargetFileContents, line)
+	}
+	return mo.ContentsBlockMatches(block, c.IsMultiline, track)
+}
+
+// track returns a MatchFunc that records the locations of the values replaced within the input.
+func (c *ContentObfuscator) track(input string, target schema.ObfuscateTarget, line int) obfuscator.MatchFunc {
+	return func(m obfuscator.Match) {
+		location := reporting.Location{Path: c.path, Obfuscator: m.Obfuscator, Target: target}
+		if target == schema.ObfuscateTargetFileContents {
+			// matches of obfuscators in chain mode are offset within the output of the preceding ones
+			offset := m.Offset
+			if offset > len(input) {
+				offset = len(input)
+			}
+			location.Line = line + strings.Count(input[:offset], "\n")
+		}
+		c.Locations.TrackLocation(m.Original, m.ReplacedWith, location)
+	}
+}
+
 func (c *ContentObfuscator) ObfuscateReader(inputReader io.Reader, outputWriter io.Writer) error {
+	if c.IsMultiline != nil {
+		return c.obfuscateBlocks(inputReader, outputWriter)
+	}
+
 	// we don't use bufio.Scanner anymore, since that can not read larger than 4096 byte lines (found in prometheus rules.json)
 	reader := bufio.NewReader(inputReader)
 	writer := bufio.NewWriter(outputWriter)
 
-	for {
+	for lineNumber := 1; ; lineNumber++ {
 		isEOF := false
 		line, err := reader.ReadString('\n')
 		if err != nil {
@@ -172,7 +259,7 @@ func (c *ContentObfuscator) ObfuscateReader(inputReader io.Reader, outputWriter
 			}
 		}
 
-		contents := c.Obfuscator.Contents(line)
+		contents := c.obfuscate(c.Obfuscator, line, schema.ObfuscateTargetFileContents, lineNumber)
 
 		_, err = fmt.Fprint(writer, contents)
 		if err != nil {
@@ -187,10 +274,29 @@ func (c *ContentObfuscator) ObfuscateReader(inputReader io.Reader, outputWriter
 	return writer.Flush()
 }
 
-func NewFileCleaner(inputPath string, outputPath string, obfuscator obfuscator.Obfuscator, omitter omitter.Omitter) Processor {
+// obfuscateBlocks runs the obfuscators on each block of joined lines, the multiline obfuscators match across the lines of
+// a block and all others within each of its lines.
+func (c *ContentObfuscator) obfuscateBlocks(inputReader io.Reader, outputWriter io.Writer) error {
+	writer := bufio.NewWriter(outputWriter)
+	lineNumber := 1
+	err := readBlocks(inputReader, c.Multiline, func(block string) error {
+		if _, err := fmt.Fprint(writer, c.obfuscateBlock(block, lineNumber)); err != nil {
+			return err
+		}
+		lineNumber += strings.Count(block, "\n")
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	return writer.Flush()
+}
+
+func NewFileCleaner(inputPath string, outputPath string, contentObfuscator ContentObfuscator, omitter omitter.Omitter) Processor {
 	return &FileProcessor{
 		FileContentObfuscator: FileContentObfuscator{
-			ContentObfuscator: ContentObfuscator{Obfuscator: obfuscator},
+			ContentObfuscator: contentObfuscator,
 			inputFolder:       inputPath,
 			outputFolder:      outputPath,
 		},
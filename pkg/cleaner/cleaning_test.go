@@ -12,6 +12,7 @@ import (
 	"github.com/openshift/must-gather-clean/pkg/kube"
 	"github.com/openshift/must-gather-clean/pkg/obfuscator"
 	"github.com/openshift/must-gather-clean/pkg/omitter"
+	"github.com/openshift/must-gather-clean/pkg/reporting"
 	"github.com/openshift/must-gather-clean/pkg/schema"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -46,7 +47,7 @@ func pString(s string) *string {
 }
 
 func noErrorIpObfuscator(t *testing.T) obfuscator.ReportingObfuscator {
-	ipObfuscator, err := obfuscator.NewIPObfuscator(schema.ObfuscateReplacementTypeStatic, obfuscator.NewSimpleTracker())
+	ipObfuscator, err := obfuscator.NewIPObfuscator(nil, nil, schema.ObfuscateReplacementTypeStatic, obfuscator.ReplacementFormat{}, obfuscator.NewSimpleTracker())
 	require.NoError(t, err)
 	return ipObfuscator
 }
@@ -58,13 +59,13 @@ func noErrorK8sSecretOmitter(t *testing.T) omitter.KubernetesResourceOmitter {
 }
 
 func TestProcessNotExistingFile(t *testing.T) {
-	fileCleaner := NewFileCleaner("tmpInputDir", "tmpOutputDir", obfuscator.NoopObfuscator{}, &omitter.NoopOmitter{})
+	fileCleaner := NewFileCleaner("tmpInputDir", "tmpOutputDir", ContentObfuscator{Obfuscator: obfuscator.NoopObfuscator{}}, &omitter.NoopOmitter{})
 	err := fileCleaner.Process("not-existing.yaml")
 	assert.ErrorIs(t, err, os.ErrNotExist)
 }
 
 func TestProcessNoK8sResource(t *testing.T) {
-	fileCleaner := NewFileCleaner("tmpInputDir", "tmpOutputDir", obfuscator.NoopObfuscator{}, &omitter.NoopOmitter{})
+	fileCleaner := NewFileCleaner("tmpInputDir", "tmpOutputDir", ContentObfuscator{Obfuscator: obfuscator.NoopObfuscator{}}, &omitter.NoopOmitter{})
 	err := fileCleaner.Process("not-existing.zzzz")
 	assert.ErrorIs(t, err, os.ErrNotExist)
 }
@@ -279,7 +280,7 @@ metadata:
 
 			reportingObfuscator := obfuscator.NewMultiObfuscator(tc.obfuscators)
 			multiOmitter := omitter.NewMultiReportingOmitter(tc.fileOmitters, tc.k8sOmitters)
-			fileCleaner := NewFileCleaner(tmpInputDir, tmpOutputDir, reportingObfuscator, multiOmitter)
+			fileCleaner := NewFileCleaner(tmpInputDir, tmpOutputDir, ContentObfuscator{Obfuscator: reportingObfuscator}, multiOmitter)
 
 			err = fileCleaner.Process(testFileName)
 			if tc.err != nil {
@@ -303,6 +304,77 @@ metadata:
 
 }
 
+func TestCleanerProcessorScopes(t *testing.T) {
+	tmpInputDir := t.TempDir()
+	tmpOutputDir := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(tmpInputDir, "pods"), 0700))
+	input := "node 192.178.1.2\n"
+	route := "apiVersion: route.openshift.io/v1\nkind: Route\nmetadata:\n    namespace: console\n# node 192.178.1.2\n"
+	for file, content := range map[string]string{"pods/api.log": input, "pods/api.yaml": input, "pods/route.yaml": route} {
+		require.NoError(t, os.WriteFile(filepath.Join(tmpInputDir, file), []byte(content), 0600))
+	}
+
+	pathScoped, err := obfuscator.NewScopedObfuscator(&schema.ObfuscatePaths{Include: []string{"pods/*.log"}}, nil, noErrorIpObfuscator(t))
+	require.NoError(t, err)
+	keywords, err := obfuscator.NewKeywordsObfuscator(map[string]string{"node": "host"}, nil, false, false, schema.ObfuscateReplacementTypeStatic, obfuscator.ReplacementFormat{})
+	require.NoError(t, err)
+	resourceScoped, err := obfuscator.NewScopedObfuscator(nil, &schema.ObfuscateKubernetesResources{Include: []schema.KubernetesResourceSelector{{Kind: "Route"}}}, keywords)
+	require.NoError(t, err)
+	reportingObfuscator := obfuscator.NewMultiObfuscator([]obfuscator.ReportingObfuscator{pathScoped, resourceScoped})
+	fileCleaner := NewFileCleaner(tmpInputDir, tmpOutputDir, ContentObfuscator{Obfuscator: reportingObfuscator}, &omitter.NoopOmitter{})
+
+	for file, output := range map[string]string{
+		"pods/api.log":    "node xxx.xxx.xxx.xxx\n",
+		"pods/api.yaml":   input,
+		"pods/route.yaml": strings.Replace(route, "# node", "# host", 1),
+	} {
+		require.NoError(t, fileCleaner.Process(file))
+		bytes, err := ioutil.ReadFile(filepath.Join(tmpOutputDir, file))
+		require.NoError(t, err)
+		assert.Equal(t, output, string(bytes))
+	}
+}
+
+func TestCleanerProcessorLocations(t *testing.T) {
+	tmpInputDir := t.TempDir()
+	tmpOutputDir := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(tmpInputDir, "10.0.0.1"), 0700))
+	input := "host: 10.0.0.1\nkey: |\n  BEGIN\n  secret\n  END\nnext: 10.0.0.2\n"
+	require.NoError(t, os.WriteFile(filepath.Join(tmpInputDir, "10.0.0.1", "config"), []byte(input), 0600))
+
+	ip := noErrorIpObfuscator(t)
+	key, err := obfuscator.NewRegexObfuscator(`(?s)BEGIN.*?END`, nil, schema.ObfuscateReplacementTypeStatic, obfuscator.ReplacementFormat{Static: "<key>"}, obfuscator.NewSimpleTracker())
+	require.NoError(t, err)
+	mo := obfuscator.NewMultiObfuscator([]obfuscator.ReportingObfuscator{key, ip})
+	locations := reporting.NewSimpleLocationReporter(10)
+	contentObfuscator := ContentObfuscator{
+		Obfuscator:  mo,
+		IsMultiline: func(i int) bool { return i == 0 },
+		Multiline:   schema.SchemaJsonConfigMultiline{Mode: schema.SchemaJsonConfigMultilineModeYamlBlock},
+		Locations:   locations,
+	}
+	fileCleaner := NewFileCleaner(tmpInputDir, tmpOutputDir, contentObfuscator, &omitter.NoopOmitter{})
+	require.NoError(t, fileCleaner.Process(filepath.Join("10.0.0.1", "config")))
+
+	path := filepath.Join("10.0.0.1", "config")
+	assert.Equal(t, []reporting.ValueLocations{
+		{Original: "10.0.0.1", ReplacedWith: "xxx.xxx.xxx.xxx", Locations: []reporting.Location{
+			{Path: path, Obfuscator: 1, Target: schema.ObfuscateTargetFilePath},
+			{Path: path, Line: 1, Obfuscator: 1, Target: schema.ObfuscateTargetFileContents},
+		}},
+		{Original: "10.0.0.2", ReplacedWith: "xxx.xxx.xxx.xxx", Locations: []reporting.Location{
+			{Path: path, Line: 6, Obfuscator: 1, Target: schema.ObfuscateTargetFileContents},
+		}},
+		{Original: "BEGIN\n  secret\n  END", ReplacedWith: "<key>", Locations: []reporting.Location{
+			{Path: path, Line: 3, Obfuscator: 0, Target: schema.ObfuscateTargetFileContents},
+		}},
+	}, locations.Report().Values)
+
+	// without a file, nothing is recorded
+	require.NoError(t, contentObfuscator.ObfuscateReader(strings.NewReader("10.0.0.3"), &strings.Builder{}))
+	assert.Len(t, locations.Report().Values, 3)
+}
+
 func newFilePatternOmitter(t *testing.T, pattern string) omitter.FileOmitter {
 	o, err := omitter.NewFilenamePatternOmitter(pattern)
 	require.NoError(t, err)
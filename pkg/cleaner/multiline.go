@@ -0,0 +1,156 @@
+package cleaner
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+
+	"github.com/openshift/must-gather-clean/pkg/schema"
+)
+
+const (
+	defaultMultilineWindowLines = 10
+	defaultMultilineMaxFileSize = 1024 * 1024
+)
+
+// yamlBlockScalarHeader matches lines that start a YAML block scalar, for example "key: |", "- key: >-" or "key: |2".
+var yamlBlockScalarHeader = regexp.MustCompile(`^(\s*)(- )?[^#\s][^#]*:\s+[|>][-+0-9]*\s*(#.*)?$`)
+
+// blockFunc is called with the joined lines of a block, every line keeps its line break.
+type blockFunc func(block string) error
+
+// readLine returns the next line including its line break, the last line of the input might not have one. io.EOF is
+// only returned when there are no more lines.
+func readLine(reader *bufio.Reader) (string, error) {
+	line, err := reader.ReadString('\n')
+	if err != nil && errors.Is(err, io.EOF) && line != "" {
+		return line, nil
+	}
+	return line, err
+}
+
+// readBlocks reads the input and calls fn for each block as defined by the multiline options, unset options use the
+// defaults of the schema.
+func readBlocks(inputReader io.Reader, options schema.SchemaJsonConfigMultiline, fn blockFunc) error {
+	if options.WindowLines == 0 {
+		options.WindowLines = defaultMultilineWindowLines
+	}
+	if options.MaxFileSize == 0 {
+		options.MaxFileSize = defaultMultilineMaxFileSize
+	}
+
+	switch options.Mode {
+	case schema.SchemaJsonConfigMultilineModeFile:
+		return readFileBlock(inputReader, options, fn)
+	case schema.SchemaJsonConfigMultilineModeYamlBlock:
+		return readYamlBlocks(bufio.NewReader(inputReader), fn)
+	case schema.SchemaJsonConfigMultilineModeWindow, "":
+		return readWindowBlocks(bufio.NewReader(inputReader), options.WindowLines, fn)
+	default:
+		return fmt.Errorf("unsupported multiline mode: %s", options.Mode)
+	}
+}
+
+// readWindowBlocks joins every windowLines consecutive lines into a block.
+func readWindowBlocks(reader *bufio.Reader, windowLines int, fn blockFunc) error {
+	if windowLines < 1 {
+		return fmt.Errorf("invalid number of multiline window lines %d", windowLines)
+	}
+
+	var sb strings.Builder
+	lines := 0
+	for {
+		line, err := readLine(reader)
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			return err
+		}
+		sb.WriteString(line)
+		lines++
+		if lines == windowLines {
+			if err := fn(sb.String()); err != nil {
+				return err
+			}
+			sb.Reset()
+			lines = 0
+		}
+	}
+
+	if lines > 0 {
+		return fn(sb.String())
+	}
+	return nil
+}
+
+// readYamlBlocks joins each YAML block scalar header with its content, which are all following lines that are blank or
+// more indented than the header. All other lines are blocks on their own.
+func readYamlBlocks(reader *bufio.Reader, fn blockFunc) error {
+	var sb strings.Builder
+	blockIndent := -1
+	for {
+		line, err := readLine(reader)
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			return err
+		}
+
+		if blockIndent >= 0 {
+			if strings.TrimSpace(line) == "" || indentation(line) > blockIndent {
+				sb.WriteString(line)
+				continue
+			}
+			if err := fn(sb.String()); err != nil {
+				return err
+			}
+			sb.Reset()
+			blockIndent = -1
+		}
+
+		if yamlBlockScalarHeader.MatchString(strings.TrimRight(line, "\r\n")) {
+			sb.WriteString(line)
+			blockIndent = indentation(line)
+			continue
+		}
+		if err := fn(line); err != nil {
+			return err
+		}
+	}
+
+	if blockIndent >= 0 {
+		return fn(sb.String())
+	}
+	return nil
+}
+
+// readFileBlock joins the whole input into a single block, if it is not larger than the maximum file size. Larger inputs
+// fall back to the window mode, so they are still streamed.
+func readFileBlock(inputReader io.Reader, options schema.SchemaJsonConfigMultiline, fn blockFunc) error {
+	if options.MaxFileSize < 1 {
+		return fmt.Errorf("invalid multiline maximum file size %d", options.MaxFileSize)
+	}
+
+	buffer, err := io.ReadAll(io.LimitReader(inputReader, int64(options.MaxFileSize)+1))
+	if err != nil {
+		return err
+	}
+	if len(buffer) > options.MaxFileSize {
+		reader := bufio.NewReader(io.MultiReader(bytes.NewReader(buffer), inputReader))
+		return readWindowBlocks(reader, options.WindowLines, fn)
+	}
+	if len(buffer) == 0 {
+		return nil
+	}
+	return fn(string(buffer))
+}
+
+func indentation(line string) int {
+	return len(line) - len(strings.TrimLeft(line, " \t"))
+}
@@ -1,11 +1,18 @@
 package cli
 
 import (
+	"crypto/ed25519"
+	"encoding/json"
 	"fmt"
 	"io"
+	"io/ioutil"
+	"os"
 	"path/filepath"
+	"strings"
 
+	"filippo.io/age"
 	"github.com/openshift/must-gather-clean/pkg/cleaner"
+	"github.com/openshift/must-gather-clean/pkg/discovery"
 	"github.com/openshift/must-gather-clean/pkg/fsutil"
 	"github.com/openshift/must-gather-clean/pkg/reporting"
 	"k8s.io/klog/v2"
@@ -15,30 +22,65 @@ import (
 	"github.com/openshift/must-gather-clean/pkg/schema"
 	"github.com/openshift/must-gather-clean/pkg/traversal"
 	watermarking "github.com/openshift/must-gather-clean/pkg/watermarker"
+	"gopkg.in/yaml.v3"
 )
 
 const (
-	reportFileName = "report.yaml"
+	reportFileBaseName   = "report"
+	locationsFileName    = "locations.yaml"
+	mappingFileName      = "mapping.yaml"
+	encryptedMappingFile = mappingFileName + ".age"
+	// slowestFiles is the number of files listed in the statistics of the report
+	slowestFiles = 10
 )
 
+// ReportOptions configure which reporting files are written and how.
+type ReportOptions struct {
+	// Folder is where the report and all other reporting files are written to
+	Folder string
+	// Format of the report, see reporting.ReportFormats
+	Format string
+	// MaxLocations is the number of locations recorded per replaced value, none are recorded if it is 0
+	MaxLocations int
+	// Split writes a shareable summary as the report and the replacements into a separate mapping file
+	Split bool
+	// Recipient is an age recipient or the path to a file of age recipients the mapping is encrypted to, implies Split
+	Recipient string
+	// Key is the path to the age identity file that decrypts an encrypted mapping that is passed as the config or seed
+	Key string
+	// Seed is the path to the report or mapping of a previous run, its replacements are reused by the obfuscators of the
+	// config at the same index, if set
+	Seed string
+	// AllowInOutput permits writing the reporting files inside the output folder
+	AllowInOutput bool
+	// Prometheus is the path to write the statistics of the run to in the Prometheus text format, if set
+	Prometheus string
+	// SigningKey is the path to an ed25519 private key the manifest of the output is signed with, if set
+	SigningKey string
+	// InputDigest records the digest of the input folder in the manifest, which reads the whole input a second time
+	InputDigest bool
+}
+
 func RunPipe(configPath string, stdin io.Reader, stdout io.Writer) error {
 	var multiObfuscator *obfuscator.MultiObfuscator
+	var config *schema.SchemaJson
 	if configPath != "" {
-		config, err := schema.ReadConfigFromPath(configPath)
+		var err error
+		config, err = schema.ReadConfigFromPath(configPath)
 		if err != nil {
 			return fmt.Errorf("failed to read config at %s: %w", configPath, err)
 		}
-		multiObfuscator, err = createObfuscatorsFromConfig(config)
+		multiObfuscator, err = createObfuscatorsFromConfig(config, discovery.Report{})
 		if err != nil {
 			return fmt.Errorf("failed to create obfuscators via config at %s: %w", configPath, err)
 		}
 	} else {
-		ipObfuscator, err := obfuscator.NewIPObfuscator(schema.ObfuscateReplacementTypeConsistent, obfuscator.NewSimpleTracker())
+		ipObfuscator, err := obfuscator.NewIPObfuscator(nil, nil, schema.ObfuscateReplacementTypeConsistent, obfuscator.ReplacementFormat{}, obfuscator.NewSimpleTracker())
 		if err != nil {
 			return fmt.Errorf("failed to create IP obfuscator: %w", err)
 		}
 
-		macObfuscator, err := obfuscator.NewMacAddressObfuscator(schema.ObfuscateReplacementTypeConsistent, obfuscator.NewSimpleTracker())
+		macObfuscator, err := obfuscator.NewMacAddressObfuscator(schema.ObfuscateReplacementTypeConsistent, obfuscator.ReplacementFormat{}, obfuscator.NewSimpleTracker())
 		if err != nil {
 			return fmt.Errorf("failed to create MAC obfuscator: %w", err)
 		}
@@ -50,6 +92,13 @@ func RunPipe(configPath string, stdin io.Reader, stdout io.Writer) error {
 	}
 
 	contentObfuscator := cleaner.ContentObfuscator{Obfuscator: multiObfuscator}
+	if config != nil {
+		var err error
+		contentObfuscator, err = newContentObfuscator(config, multiObfuscator)
+		if err != nil {
+			return fmt.Errorf("failed to create obfuscators via config at %s: %w", configPath, err)
+		}
+	}
 	err := contentObfuscator.ObfuscateReader(stdin, stdout)
 	if err != nil {
 		return fmt.Errorf("failed to obfuscate via pipe: %w", err)
@@ -58,22 +107,125 @@ func RunPipe(configPath string, stdin io.Reader, stdout io.Writer) error {
 	return nil
 }
 
-func Run(configPath string, inputPath string, outputPath string, deleteOutputFolder bool, reportingFolder string, workerCount int) error {
+// Run cleans the input into the output folder. The progress is rendered to stderr in the given format, an empty format
+// renders none.
+func Run(configPath string, inputPath string, outputPath string, deleteOutputFolder bool, report ReportOptions, workerCount int, progressFormat traversal.ProgressFormat) error {
 	if workerCount < 1 {
 		return fmt.Errorf("invalid number of workers specified %d", workerCount)
 	}
 
-	err := fsutil.EnsureInputOutputPath(inputPath, outputPath, deleteOutputFolder)
+	switch progressFormat {
+	case "", traversal.ProgressNone, traversal.ProgressLine, traversal.ProgressJSON:
+	default:
+		return fmt.Errorf("invalid progress format %s, must be one of %s, %s or %s", progressFormat, traversal.ProgressNone, traversal.ProgressLine, traversal.ProgressJSON)
+	}
+
+	if report.MaxLocations < 0 {
+		return fmt.Errorf("invalid number of locations per value specified %d", report.MaxLocations)
+	}
+
+	statistics := reporting.NewStatisticsCollector(slowestFiles)
+
+	reportWriter, err := reporting.NewReportWriter(reporting.ReportFormat(report.Format))
+	if err != nil {
+		return err
+	}
+
+	var recipients []age.Recipient
+	if report.Recipient != "" {
+		recipients, err = reporting.ReadRecipients(report.Recipient)
+		if err != nil {
+			return err
+		}
+	}
+
+	var signingKey ed25519.PrivateKey
+	if report.SigningKey != "" {
+		signingKey, err = watermarking.ReadSigningKey(report.SigningKey)
+		if err != nil {
+			return err
+		}
+	}
+
+	err = fsutil.EnsureInputPath(inputPath)
+	if err != nil {
+		return err
+	}
+
+	split := report.Split || len(recipients) > 0
+	reportPath := filepath.Join(report.Folder, reportFileBaseName+"."+string(reportWriter.Format()))
+	mappingPath := filepath.Join(report.Folder, mappingFileName)
+	if len(recipients) > 0 {
+		mappingPath = filepath.Join(report.Folder, encryptedMappingFile)
+	}
+	locationsPath := filepath.Join(report.Folder, locationsFileName)
+
+	if !report.AllowInOutput {
+		// the files are checked on their own as well, an existing file might be a symbolic link into the output and the
+		// statistics can be written anywhere
+		reportingPaths := []struct {
+			name string
+			path string
+		}{
+			{name: "report folder", path: report.Folder},
+			{name: "report", path: reportPath},
+			{name: "mapping", path: mappingPath},
+			{name: "locations", path: locationsPath},
+			{name: "statistics file", path: report.Prometheus},
+		}
+		for _, p := range reportingPaths {
+			if p.path == "" {
+				continue
+			}
+			inOutput, err := fsutil.IsWithin(p.path, outputPath)
+			if err != nil {
+				return err
+			}
+			if inOutput {
+				return fmt.Errorf("%s '%s' is inside the output folder '%s', the report would be shared along with the output", p.name, p.path, outputPath)
+			}
+		}
+	}
+
+	// the output folder is only touched once the reporting paths are known to be outside of it, -d would delete them
+	err = fsutil.EnsureInputOutputPath(inputPath, outputPath, deleteOutputFolder)
 	if err != nil {
 		return err
 	}
 
-	config, err := schema.ReadConfigFromPath(configPath)
+	// the files are hashed for the manifest as they were passed, so that the receiving side can compare them
+	manifestOptions := watermarking.ManifestOptions{Key: signingKey}
+	if report.InputDigest {
+		manifestOptions.InputPath = inputPath
+	}
+	manifestOptions.Config, err = ioutil.ReadFile(configPath)
 	if err != nil {
 		return fmt.Errorf("failed to read config at %s: %w", configPath, err)
 	}
+	config, err := readConfig(manifestOptions.Config, configPath, report.Key)
+	if err != nil {
+		return fmt.Errorf("failed to read config at %s: %w", configPath, err)
+	}
+	if report.Seed != "" {
+		manifestOptions.Seed, err = ioutil.ReadFile(report.Seed)
+		if err != nil {
+			return fmt.Errorf("failed to seed config from %s: %w", report.Seed, err)
+		}
+		if err := seedConfig(config, manifestOptions.Seed, report.Seed, report.Key); err != nil {
+			return fmt.Errorf("failed to seed config from %s: %w", report.Seed, err)
+		}
+	}
 
-	mo, err := createObfuscatorsFromConfig(config)
+	discovered, err := discoverFromConfig(config, inputPath)
+	if err != nil {
+		return fmt.Errorf("failed to discover via config at %s: %w", configPath, err)
+	}
+
+	timings := make([]*obfuscator.Timing, len(config.Config.Obfuscate))
+	for i := range timings {
+		timings[i] = &obfuscator.Timing{}
+	}
+	mo, err := createTimedObfuscatorsFromConfig(config, discovered, timings)
 	if err != nil {
 		return fmt.Errorf("failed to create obfuscators via config at %s: %w", configPath, err)
 	}
@@ -82,39 +234,369 @@ func Run(configPath string, inputPath string, outputPath string, deleteOutputFol
 	if err != nil {
 		return fmt.Errorf("failed to create omitters via config at %s: %w", configPath, err)
 	}
-	fileCleaner := cleaner.NewFileCleaner(inputPath, outputPath, mo, mro)
+	contentObfuscator, err := newContentObfuscator(config, mo)
+	if err != nil {
+		return fmt.Errorf("failed to create obfuscators via config at %s: %w", configPath, err)
+	}
+	var locations reporting.LocationReporter
+	if report.MaxLocations > 0 {
+		locations = reporting.NewSimpleLocationReporter(report.MaxLocations)
+		contentObfuscator.Locations = locations
+	}
+	fileCleaner := cleaner.NewFileCleaner(inputPath, outputPath, contentObfuscator, mro)
+
+	var tracker traversal.Tracker = statistics
+	var progress *traversal.Progress
+	if progressFormat != "" && progressFormat != traversal.ProgressNone {
+		files, bytes, err := traversal.CountFiles(inputPath)
+		if err != nil {
+			return err
+		}
+		progress = traversal.NewProgress(os.Stderr, progressFormat, files, bytes)
+		tracker = traversal.NewMultiTracker(statistics, progress)
+	}
 
 	workerFactory := func(id int) traversal.QueueProcessor {
-		return traversal.NewWorker(id, fileCleaner)
+		return traversal.NewWorkerWithTracker(id, fileCleaner, tracker)
+	}
+
+	if progress != nil {
+		progress.Start()
+	}
+	traversal.NewParallelFileWalkerWithTracker(inputPath, workerCount, workerFactory, tracker).Traverse()
+	if progress != nil {
+		progress.Stop()
 	}
 
-	traversal.NewParallelFileWalker(inputPath, workerCount, workerFactory).Traverse()
+	omissions := mro.Omissions()
+	var omittedPaths []string
+	for _, o := range omissions {
+		omittedPaths = append(omittedPaths, relativeToInput(o.Path, inputPath))
+	}
+	var obfuscatorStatistics []reporting.ObfuscatorStatistics
+	for i, t := range timings {
+		obfuscatorStatistics = append(obfuscatorStatistics, reporting.ObfuscatorStatistics{
+			Obfuscator: i,
+			Type:       config.Config.Obfuscate[i].Type,
+			Calls:      t.Calls(),
+			Seconds:    t.Duration().Seconds(),
+		})
+	}
+	runStatistics := statistics.Statistics(omittedPaths, obfuscatorStatistics)
 
-	reporter := reporting.NewSimpleReporter(config)
-	reporter.CollectOmitterReport(mro.Report())
+	reporter := reporting.NewSimpleReporterWithWriter(config, reportWriter)
+	reporter.CollectDiscoveryReport(discovered)
+	reporter.CollectOmitterReport(omissions)
+	reporter.CollectStatistics(runStatistics)
+	if split {
+		// the paths are obfuscated before the obfuscator report is collected, so their replacements end up in the mapping
+		reporter.CollectSummaryPaths(func(path string) string {
+			return mo.Path(relativeToInput(path, inputPath))
+		})
+	}
 	reporter.CollectObfuscatorReport(mo.ReportPerObfuscator())
-	reporterErr := reporter.WriteReport(filepath.Join(reportingFolder, reportFileName))
+	if split && locations != nil {
+		// the locations relate the originals with their replacements just like the mapping, so they are kept with it
+		reporter.CollectLocations(locations.Report())
+	}
+	var reporterErr error
+	if split {
+		reporterErr = reporter.WriteSplitReport(reportPath, mappingPath, recipients)
+	} else {
+		reporterErr = reporter.WriteReport(reportPath)
+	}
 	if reporterErr != nil {
 		return reporterErr
 	}
 
-	watermarker := watermarking.NewSimpleWaterMarker()
+	if locations != nil && !split {
+		err = locations.WriteLocations(locationsPath)
+		if err != nil {
+			return err
+		}
+	}
+
+	if report.Prometheus != "" {
+		err = reporting.WritePrometheusFile(report.Prometheus, runStatistics)
+		if err != nil {
+			return err
+		}
+	}
+
+	watermarker := watermarking.NewManifestWaterMarker(manifestOptions)
 	return watermarker.WriteWaterMarkFile(outputPath)
 }
 
+// relativeToInput returns the path relative to the input folder, the omitted Kubernetes resources are reported along with
+// the input folder.
+func relativeToInput(path string, inputPath string) string {
+	return strings.TrimPrefix(path, filepath.Clean(inputPath)+string(filepath.Separator))
+}
+
+// Verify searches the folder for files that look like reports, which must not be shared along with the output.
+func Verify(folder string) error {
+	reports, err := reporting.FindReports(folder)
+	if err != nil {
+		return err
+	}
+	if len(reports) > 0 {
+		return fmt.Errorf("found %d files that look like reports in %s: %s", len(reports), folder, strings.Join(reports, ", "))
+	}
+	return nil
+}
+
+// VerifyWatermarkOptions configure what VerifyWatermark checks besides the files of the output.
+type VerifyWatermarkOptions struct {
+	// PublicKey is the path to the ed25519 public key the manifest must be signed by, if set
+	PublicKey string
+	// Config is the path to the configuration file the output must have been cleaned with, if set
+	Config string
+	// AllowUnsigned only checks the files of an output whose manifest is not signed instead of failing
+	AllowUnsigned bool
+}
+
+// VerifyWatermark checks the manifest of the output folder and writes the result to out. The manifest must be signed
+// unless unsigned manifests are allowed. If a public key is set, the manifest must be signed by that key, otherwise the
+// signature only proves that the manifest wasn't changed since it was signed by whoever holds the key. If a config is
+// set, its hash must match the one in the manifest. Every file of the output must match its hash in the manifest.
+func VerifyWatermark(folder string, options VerifyWatermarkOptions, out io.Writer) error {
+	var expectedKey ed25519.PublicKey
+	if options.PublicKey != "" {
+		var err error
+		expectedKey, err = watermarking.ReadVerifyingKey(options.PublicKey)
+		if err != nil {
+			return err
+		}
+	}
+	manifest, data, err := watermarking.ReadManifest(folder)
+	if err != nil {
+		return err
+	}
+
+	signature, err := ioutil.ReadFile(filepath.Join(folder, watermarking.SignatureFileName))
+	switch {
+	case os.IsNotExist(err) && (expectedKey != nil || !options.AllowUnsigned):
+		return fmt.Errorf("manifest in %s is not signed", folder)
+	case os.IsNotExist(err):
+		_, _ = fmt.Fprintf(out, "manifest is not signed, anyone could have written it\n")
+	case err != nil:
+		return fmt.Errorf("failed to read manifest signature: %w", err)
+	default:
+		signer, err := watermarking.VerifySignature(data, signature)
+		if err != nil {
+			return fmt.Errorf("invalid manifest signature in %s: %w", folder, err)
+		}
+		if expectedKey != nil && !expectedKey.Equal(signer) {
+			return fmt.Errorf("manifest in %s is signed by %s, expected %s", folder, watermarking.Fingerprint(signer), watermarking.Fingerprint(expectedKey))
+		}
+		if expectedKey == nil {
+			_, _ = fmt.Fprintf(out, "valid signature by the untrusted key %s, pass its public key to verify the signer\n", watermarking.Fingerprint(signer))
+		} else {
+			_, _ = fmt.Fprintf(out, "valid signature by %s\n", watermarking.Fingerprint(signer))
+		}
+	}
+
+	if options.Config != "" {
+		config, err := ioutil.ReadFile(options.Config)
+		if err != nil {
+			return fmt.Errorf("failed to read config at %s: %w", options.Config, err)
+		}
+		if hash := watermarking.Sha256(config); hash != manifest.ConfigSha256 {
+			return fmt.Errorf("config %s does not match the manifest in %s, its sha256 is %s but the output was cleaned with %s", options.Config, folder, hash, manifest.ConfigSha256)
+		}
+		_, _ = fmt.Fprintf(out, "config %s matches the manifest\n", options.Config)
+		if manifest.SeedSha256 != "" {
+			_, _ = fmt.Fprintf(out, "the replacements were seeded from a report with sha256 %s\n", manifest.SeedSha256)
+		}
+	}
+
+	mismatches, err := watermarking.CheckManifest(folder, manifest)
+	if err != nil {
+		return err
+	}
+	for _, m := range mismatches {
+		_, _ = fmt.Fprintln(out, m)
+	}
+	if len(mismatches) > 0 {
+		return fmt.Errorf("%d files in %s do not match the manifest", len(mismatches), folder)
+	}
+	_, _ = fmt.Fprintf(out, "all %d files match the manifest\n", len(manifest.Files))
+	return nil
+}
+
+// diffResult is what Diff writes in the YAML and JSON formats.
+type diffResult struct {
+	reporting.ReportDiff `yaml:",inline"`
+	Output               *watermarking.TreeDiff `yaml:"output,omitempty" json:"output,omitempty"`
+}
+
+// Diff compares the old with the new report and writes the differences to out, in the text, yaml or json format. If
+// both output folders are given, the files of the outputs are compared as well.
+func Diff(oldReportPath string, newReportPath string, oldOutputPath string, newOutputPath string, format string, out io.Writer) error {
+	if format != "text" && format != "yaml" && format != "json" {
+		return fmt.Errorf("invalid diff format %s, must be one of text, yaml or json", format)
+	}
+	if (oldOutputPath == "") != (newOutputPath == "") {
+		return fmt.Errorf("both the old and the new output are needed to compare them")
+	}
+	oldReport, err := reporting.ReadReport(oldReportPath)
+	if err != nil {
+		return err
+	}
+	newReport, err := reporting.ReadReport(newReportPath)
+	if err != nil {
+		return err
+	}
+	result := diffResult{ReportDiff: reporting.DiffReports(oldReport, newReport)}
+	if oldOutputPath != "" {
+		result.Output, err = watermarking.DiffTrees(oldOutputPath, newOutputPath)
+		if err != nil {
+			return err
+		}
+	}
+
+	switch format {
+	case "yaml":
+		encoder := yaml.NewEncoder(out)
+		if err := encoder.Encode(result); err != nil {
+			return err
+		}
+		return encoder.Close()
+	case "json":
+		encoder := json.NewEncoder(out)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(result)
+	}
+	outputDiffers := result.Output != nil && len(result.Output.Added)+len(result.Output.Removed)+len(result.Output.Changed) > 0
+	if result.Empty() && !outputDiffers {
+		_, err = fmt.Fprintln(out, "no differences")
+		return err
+	}
+	err = reporting.WriteDiff(out, result.ReportDiff)
+	if err != nil || !outputDiffers {
+		return err
+	}
+	_, err = fmt.Fprintf(out, "output: %d added, %d removed, %d changed\n", len(result.Output.Added), len(result.Output.Removed), len(result.Output.Changed))
+	if err != nil {
+		return err
+	}
+	for _, lines := range []struct {
+		prefix string
+		paths  []string
+	}{{"+", result.Output.Added}, {"-", result.Output.Removed}, {"~", result.Output.Changed}} {
+		for _, path := range lines.paths {
+			if _, err := fmt.Fprintf(out, "  %s %s\n", lines.prefix, path); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// Reveal decrypts the encrypted mapping at mappingPath with the age identities at keyPath and writes it to out.
+func Reveal(mappingPath string, keyPath string, out io.Writer) error {
+	identities, err := reporting.ReadIdentities(keyPath)
+	if err != nil {
+		return err
+	}
+	data, err := ioutil.ReadFile(mappingPath)
+	if err != nil {
+		return fmt.Errorf("failed to read mapping at %s: %w", mappingPath, err)
+	}
+	mapping, err := reporting.DecryptMapping(data, identities)
+	if err != nil {
+		return fmt.Errorf("failed to decrypt mapping at %s: %w", mappingPath, err)
+	}
+	_, err = out.Write(mapping)
+	return err
+}
+
+// readConfig parses the data of the config at the given path, an encrypted mapping is decrypted with the age identities at
+// keyPath.
+func readConfig(data []byte, configPath string, keyPath string) (*schema.SchemaJson, error) {
+	if keyPath == "" || !reporting.IsEncryptedMapping(data) {
+		return schema.ReadConfig(data, filepath.Ext(configPath))
+	}
+
+	identities, err := reporting.ReadIdentities(keyPath)
+	if err != nil {
+		return nil, err
+	}
+	mapping, err := reporting.DecryptMapping(data, identities)
+	if err != nil {
+		return nil, err
+	}
+	// the mapping is always written as YAML
+	return schema.ReadConfig(mapping, ".yaml")
+}
+
+// seedConfig adds the replacements of the report or mapping data read from seedPath to the obfuscators of the config, so
+// that values are replaced the same as in the run that wrote the report. An encrypted mapping is decrypted with the age
+// identities at keyPath. Obfuscators are matched by their index, replacements of the config take precedence.
+func seedConfig(config *schema.SchemaJson, data []byte, seedPath string, keyPath string) error {
+	if reporting.IsEncryptedMapping(data) {
+		if keyPath == "" {
+			return fmt.Errorf("the mapping is encrypted, the age identity file to decrypt it is required")
+		}
+		identities, err := reporting.ReadIdentities(keyPath)
+		if err != nil {
+			return err
+		}
+		data, err = reporting.DecryptMapping(data, identities)
+		if err != nil {
+			return err
+		}
+	}
+	seed, err := reporting.ParseReport(data, seedPath)
+	if err != nil {
+		return err
+	}
+
+	if len(seed.Config.Obfuscate) == 0 || len(seed.Replacements) == 0 {
+		return fmt.Errorf("%s has no replacements, pass the full report or the mapping of a split report instead of its summary", seedPath)
+	}
+
+	for i, o := range seed.Config.Obfuscate {
+		if i >= len(config.Config.Obfuscate) || i >= len(seed.Replacements) {
+			break
+		}
+		target := &config.Config.Obfuscate[i]
+		if target.Type != o.Type {
+			return fmt.Errorf("obfuscator %d is of type %s in the seed, but of type %s in the config", i, o.Type, target.Type)
+		}
+		if len(seed.Replacements[i]) == 0 {
+			continue
+		}
+		if target.Replacement == nil {
+			target.Replacement = schema.ObfuscateReplacement{}
+		}
+		// the trackers look up values by their canonical form, the replacements of the config hold the originals
+		for _, r := range seed.Replacements[i] {
+			if _, ok := target.Replacement[r.Canonical]; !ok {
+				target.Replacement[r.Canonical] = r.ReplacedWith
+			}
+		}
+	}
+	return nil
+}
+
 func createOmittersFromConfig(config *schema.SchemaJson, inputPath string) (omitter.ReportingOmitter, error) {
 	var fileOmitters []omitter.FileOmitter
 	var k8sOmitters []omitter.KubernetesResourceOmitter
-	for _, o := range config.Config.Omit {
+	// the index of each omitter in the configuration, so that omissions can be traced back to their rule
+	var fileRules, k8sRules []int
+	for i, o := range config.Config.Omit {
 		switch o.Type {
 		case schema.OmitTypeSymbolicLink:
 			fileOmitters = append(fileOmitters, omitter.NewSymlinkOmitter(inputPath))
+			fileRules = append(fileRules, i)
 		case schema.OmitTypeFile:
 			om, err := omitter.NewFilenamePatternOmitter(*o.Pattern)
 			if err != nil {
 				return nil, err
 			}
 			fileOmitters = append(fileOmitters, om)
+			fileRules = append(fileRules, i)
 		case schema.OmitTypeKubernetes:
 			if o.KubernetesResource == nil {
 				klog.Exitf("type Kubernetes must also include a 'kubernetesResource'. Given: %v", o)
@@ -125,46 +607,157 @@ func createOmittersFromConfig(config *schema.SchemaJson, inputPath string) (omit
 				return nil, err
 			}
 			k8sOmitters = append(k8sOmitters, om)
+			k8sRules = append(k8sRules, i)
 		}
 	}
 
-	return omitter.NewMultiReportingOmitter(fileOmitters, k8sOmitters), nil
+	return omitter.NewMultiReportingOmitterWithRules(inputPath, fileOmitters, fileRules, k8sOmitters, k8sRules), nil
 }
 
-func createObfuscatorsFromConfig(config *schema.SchemaJson) (*obfuscator.MultiObfuscator, error) {
-	var obfuscators []obfuscator.ReportingObfuscator
+// discoverFromConfig runs the discovery on the input path for all obfuscators that require it.
+func discoverFromConfig(config *schema.SchemaJson, inputPath string) (discovery.Report, error) {
+	var report discovery.Report
 	for _, o := range config.Config.Obfuscate {
+		switch {
+		case o.Type == schema.ObfuscateTypeDomain && o.AutoDiscovery && report.Domains == nil:
+			domains, err := discovery.DiscoverDomains(inputPath)
+			if err != nil {
+				return report, err
+			}
+			klog.V(2).Infof("discovered domains: %v", domains)
+			report.Domains = domains
+		case o.Type == schema.ObfuscateTypeClusterIdentifiers && report.ClusterIdentifiers == nil:
+			identifiers, err := discovery.DiscoverClusterIdentifiers(inputPath)
+			if err != nil {
+				return report, err
+			}
+			klog.V(2).Infof("discovered cluster identifiers: %v", identifiers)
+			report.ClusterIdentifiers = identifiers
+		case o.Type == schema.ObfuscateTypeIdentity && report.Identities.Users == nil && report.Identities.Groups == nil:
+			identities, err := discovery.DiscoverIdentities(inputPath)
+			if err != nil {
+				return report, err
+			}
+			klog.V(2).Infof("discovered %d users and %d groups", len(identities.Users), len(identities.Groups))
+			report.Identities = identities
+		}
+	}
+	return report, nil
+}
+
+func createObfuscatorsFromConfig(config *schema.SchemaJson, discovered discovery.Report) (*obfuscator.MultiObfuscator, error) {
+	return createTimedObfuscatorsFromConfig(config, discovered, nil)
+}
+
+// createTimedObfuscatorsFromConfig measures the time spent by each obfuscator in the timing at the same index, if timings
+// are given.
+func createTimedObfuscatorsFromConfig(config *schema.SchemaJson, discovered discovery.Report, timings []*obfuscator.Timing) (*obfuscator.MultiObfuscator, error) {
+	var obfuscators []obfuscator.ReportingObfuscator
+	var priorities []int
+	for i, o := range config.Config.Obfuscate {
 		var (
 			k   obfuscator.ReportingObfuscator
 			err error
 		)
 		tracker := obfuscator.NewSimpleTrackerMap(o.Replacement)
+		format := obfuscator.ReplacementFormat{Template: stringOrEmpty(o.Template), Static: stringOrEmpty(o.StaticReplacement)}
 		switch o.Type {
 		case schema.ObfuscateTypeKeywords:
-			k = obfuscator.NewKeywordsObfuscator(o.Replacement)
+			var keywords []string
+			if o.KeywordsFile != nil {
+				keywords, err = obfuscator.ReadKeywordsFile(*o.KeywordsFile)
+				if err != nil {
+					return nil, err
+				}
+			}
+			k, err = obfuscator.NewKeywordsObfuscator(o.Replacement, keywords, o.CaseInsensitive, o.WholeWords, o.ReplacementType, format)
+			if err != nil {
+				return nil, err
+			}
 		case schema.ObfuscateTypeMAC:
-			k, err = obfuscator.NewMacAddressObfuscator(o.ReplacementType, tracker)
+			k, err = obfuscator.NewMacAddressObfuscator(o.ReplacementType, format, tracker)
 			if err != nil {
 				return nil, err
 			}
 		case schema.ObfuscateTypeRegex:
-			k, err = obfuscator.NewRegexObfuscator(*o.Regex, tracker)
+			k, err = obfuscator.NewRegexObfuscator(*o.Regex, o.CaptureGroups, o.ReplacementType, format, tracker)
 			if err != nil {
 				return nil, err
 			}
 		case schema.ObfuscateTypeDomain:
-			k, err = obfuscator.NewDomainObfuscator(o.DomainNames, o.ReplacementType, tracker)
+			if o.AutoDiscovery {
+				domainNames := append(append([]string{}, o.DomainNames...), discovered.Domains...)
+				if len(domainNames) == 0 {
+					klog.Warningf("no domains were discovered for obfuscator %d and it has no domainNames, it won't replace any domains", i)
+				}
+				k, err = obfuscator.NewDiscoveredDomainObfuscator(domainNames, o.ReplacementType, format, tracker)
+			} else {
+				k, err = obfuscator.NewDomainObfuscator(o.DomainNames, o.ReplacementType, format, tracker)
+			}
+			if err != nil {
+				return nil, err
+			}
+		case schema.ObfuscateTypeClusterIdentifiers:
+			k, err = obfuscator.NewClusterIdentifiersObfuscator(discovered.ClusterIdentifiers, o.ReplacementType, format, tracker)
+			if err != nil {
+				return nil, err
+			}
+		case schema.ObfuscateTypeIdentity:
+			k, err = obfuscator.NewIdentityObfuscator(discovered.Identities.Users, discovered.Identities.Groups, o.ReplacementType, format, tracker)
 			if err != nil {
 				return nil, err
 			}
 		case schema.ObfuscateTypeIP:
-			k, err = obfuscator.NewIPObfuscator(o.ReplacementType, tracker)
+			k, err = obfuscator.NewIPObfuscator(o.Exclude, o.OnlyRanges, o.ReplacementType, format, tracker)
 			if err != nil {
 				return nil, err
 			}
 		}
+		k, err = obfuscator.NewExceptionObfuscator(o.Exceptions, k)
+		if err != nil {
+			return nil, err
+		}
 		k = obfuscator.NewTargetObfuscator(o.Target, k)
+		if timings != nil {
+			k = obfuscator.NewTimingObfuscator(timings[i], k)
+		}
+		if o.Chain {
+			k = obfuscator.NewChainObfuscator(k)
+		}
+		k, err = obfuscator.NewScopedObfuscator(o.Paths, o.KubernetesResources, k)
+		if err != nil {
+			return nil, err
+		}
 		obfuscators = append(obfuscators, k)
+		priorities = append(priorities, o.Priority)
+	}
+	return obfuscator.NewPrioritizedMultiObfuscator(obfuscators, priorities), nil
+}
+
+// newContentObfuscator runs the obfuscators that enable multiline on blocks of lines and all others on single lines.
+func newContentObfuscator(config *schema.SchemaJson, mo *obfuscator.MultiObfuscator) (cleaner.ContentObfuscator, error) {
+	isMultiline := func(i int) bool {
+		return config.Config.Obfuscate[i].Multiline
+	}
+	contentObfuscator := cleaner.ContentObfuscator{Obfuscator: mo}
+	for i := range config.Config.Obfuscate {
+		if isMultiline(i) {
+			contentObfuscator.IsMultiline = isMultiline
+			break
+		}
+	}
+	if m := config.Config.Multiline; m != nil {
+		if m.WindowLines < 1 || m.MaxFileSize < 1 {
+			return contentObfuscator, fmt.Errorf("multiline windowLines and maxFileSize must be positive, given: %d and %d", m.WindowLines, m.MaxFileSize)
+		}
+		contentObfuscator.Multiline = *m
+	}
+	return contentObfuscator, nil
+}
+
+func stringOrEmpty(s *string) string {
+	if s == nil {
+		return ""
 	}
-	return obfuscator.NewMultiObfuscator(obfuscators), nil
+	return *s
 }
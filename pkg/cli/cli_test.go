@@ -1,27 +1,450 @@
 package cli
 
 import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
 	"fmt"
 	"io/ioutil"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 
+	"filippo.io/age"
+	"github.com/openshift/must-gather-clean/pkg/discovery"
 	"github.com/openshift/must-gather-clean/pkg/kube"
+	"github.com/openshift/must-gather-clean/pkg/obfuscator"
+	"github.com/openshift/must-gather-clean/pkg/reporting"
 	"github.com/openshift/must-gather-clean/pkg/schema"
+	watermarking "github.com/openshift/must-gather-clean/pkg/watermarker"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
 )
 
 func TestRunFailsOnNegativeAndZeroWorkers(t *testing.T) {
-	err := Run("", "", "", false, "", 0)
+	err := Run("", "", "", false, ReportOptions{Format: "yaml"}, 0, "")
 	assert.Equal(t, fmt.Errorf("invalid number of workers specified %d", 0), err)
-	err = Run("", "", "", false, "", -2)
+	err = Run("", "", "", false, ReportOptions{Format: "yaml"}, -2, "")
 	assert.Equal(t, fmt.Errorf("invalid number of workers specified %d", -2), err)
 }
 
+func TestRunFailsOnNegativeLocations(t *testing.T) {
+	err := Run("", "", "", false, ReportOptions{Format: "yaml", MaxLocations: -1}, 1, "")
+	assert.EqualError(t, err, "invalid number of locations per value specified -1")
+}
+
+func TestRunFailsOnUnsupportedProgressFormat(t *testing.T) {
+	err := Run("", "", "", false, ReportOptions{Format: "yaml"}, 1, "xml")
+	assert.EqualError(t, err, "invalid progress format xml, must be one of none, line or json")
+}
+
+func TestRunFailsOnUnsupportedReportFormat(t *testing.T) {
+	err := Run("", "", "", false, ReportOptions{Format: "xml"}, 1, "")
+	assert.EqualError(t, err, "unsupported report format 'xml', expected one of [yaml json csv html]")
+}
+
+func TestRunWithReportFormat(t *testing.T) {
+	inputDir := t.TempDir()
+	reportDir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(inputDir, "a.log"), []byte("10.0.0.1\n"), 0600))
+	configPath := filepath.Join(t.TempDir(), "config.yaml")
+	require.NoError(t, os.WriteFile(configPath, []byte("config:\n  obfuscate:\n  - type: IP\n"), 0600))
+
+	require.NoError(t, Run(configPath, inputDir, filepath.Join(t.TempDir(), "output"), false, ReportOptions{Folder: reportDir, Format: "json"}, 1, ""))
+
+	bytes, err := os.ReadFile(filepath.Join(reportDir, "report.json"))
+	require.NoError(t, err)
+	var report reporting.Report
+	require.NoError(t, json.Unmarshal(bytes, &report))
+	assert.Equal(t, "xxx.xxx.xxx.xxx", report.Replacements[0][0].ReplacedWith)
+	assert.NoFileExists(t, filepath.Join(reportDir, "report.yaml"))
+}
+
+func TestRunWritesManifest(t *testing.T) {
+	inputDir := t.TempDir()
+	outputDir := filepath.Join(t.TempDir(), "output")
+	require.NoError(t, os.WriteFile(filepath.Join(inputDir, "a.log"), []byte("10.0.0.1\n"), 0600))
+	configPath := filepath.Join(t.TempDir(), "config.yaml")
+	require.NoError(t, os.WriteFile(configPath, []byte("config:\n  obfuscate:\n  - type: IP\n"), 0600))
+
+	require.NoError(t, Run(configPath, inputDir, outputDir, false, ReportOptions{Folder: t.TempDir(), Format: "yaml"}, 1, ""))
+
+	bytes, err := os.ReadFile(filepath.Join(outputDir, watermarking.ManifestFileName))
+	require.NoError(t, err)
+	var manifest watermarking.Manifest
+	require.NoError(t, json.Unmarshal(bytes, &manifest))
+	assert.Empty(t, manifest.InputSha256, "the input is only digested on request")
+	var paths []string
+	for _, f := range manifest.Files {
+		paths = append(paths, f.Path)
+	}
+	assert.Equal(t, []string{"a.log", "watermark.txt"}, paths)
+
+	// the hash is taken from the config file as it was passed, so the receiving side can compare it
+	configHash := sha256.Sum256([]byte("config:\n  obfuscate:\n  - type: IP\n"))
+	assert.Equal(t, hex.EncodeToString(configHash[:]), manifest.ConfigSha256)
+	assert.Empty(t, manifest.SeedSha256)
+
+	require.NoError(t, Run(configPath, inputDir, outputDir, true, ReportOptions{Folder: t.TempDir(), Format: "yaml", InputDigest: true}, 1, ""))
+	bytes, err = os.ReadFile(filepath.Join(outputDir, watermarking.ManifestFileName))
+	require.NoError(t, err)
+	require.NoError(t, json.Unmarshal(bytes, &manifest))
+	inputDigest, err := watermarking.TreeDigest(inputDir)
+	require.NoError(t, err)
+	assert.Equal(t, inputDigest, manifest.InputSha256)
+}
+
+func TestRunWithSeedAndVerifyWatermarkConfig(t *testing.T) {
+	inputDir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(inputDir, "a.log"), []byte("10.0.0.1\n"), 0600))
+	configPath := filepath.Join(t.TempDir(), "config.yaml")
+	require.NoError(t, os.WriteFile(configPath, []byte("config:\n  obfuscate:\n  - type: IP\n    replacementType: Consistent\n"), 0600))
+	reportDir := t.TempDir()
+	require.NoError(t, Run(configPath, inputDir, filepath.Join(t.TempDir(), "output"), false, ReportOptions{Folder: reportDir, Format: "yaml"}, 1, ""))
+	seedPath := filepath.Join(reportDir, "report.yaml")
+	seed, err := os.ReadFile(seedPath)
+	require.NoError(t, err)
+
+	outputDir := filepath.Join(t.TempDir(), "output")
+	require.NoError(t, Run(configPath, inputDir, outputDir, false, ReportOptions{Folder: t.TempDir(), Format: "yaml", Seed: seedPath}, 1, ""))
+	manifest, _, err := watermarking.ReadManifest(outputDir)
+	require.NoError(t, err)
+	assert.Equal(t, watermarking.Sha256(seed), manifest.SeedSha256)
+
+	out := &bytes.Buffer{}
+	require.NoError(t, VerifyWatermark(outputDir, VerifyWatermarkOptions{Config: configPath, AllowUnsigned: true}, out))
+	assert.Equal(t, fmt.Sprintf("manifest is not signed, anyone could have written it\nconfig %s matches the manifest\nthe replacements were seeded from a report with sha256 %s\nall 2 files match the manifest\n", configPath, manifest.SeedSha256), out.String())
+
+	// the same config in JSON parses the same, but it isn't the file the output was cleaned with
+	otherConfig := filepath.Join(t.TempDir(), "config.json")
+	require.NoError(t, os.WriteFile(otherConfig, []byte(`{"config":{"obfuscate":[{"type":"IP","replacementType":"Consistent"}]}}`), 0600))
+	otherConfigData, err := os.ReadFile(otherConfig)
+	require.NoError(t, err)
+	err = VerifyWatermark(outputDir, VerifyWatermarkOptions{Config: otherConfig, AllowUnsigned: true}, &bytes.Buffer{})
+	assert.EqualError(t, err, fmt.Sprintf("config %s does not match the manifest in %s, its sha256 is %s but the output was cleaned with %s", otherConfig, outputDir, watermarking.Sha256(otherConfigData), manifest.ConfigSha256))
+}
+
+func TestRunWithSigningKeyAndVerifyWatermark(t *testing.T) {
+	inputDir := t.TempDir()
+	outputDir := filepath.Join(t.TempDir(), "output")
+	require.NoError(t, os.WriteFile(filepath.Join(inputDir, "a.log"), []byte("10.0.0.1\n"), 0600))
+	configPath := filepath.Join(t.TempDir(), "config.yaml")
+	require.NoError(t, os.WriteFile(configPath, []byte("config:\n  obfuscate:\n  - type: IP\n"), 0600))
+	signingKey := "../watermarker/testfiles/id_ed25519"
+	publicKey := "../watermarker/testfiles/id_ed25519.pub"
+
+	require.NoError(t, Run(configPath, inputDir, outputDir, false, ReportOptions{Folder: t.TempDir(), Format: "yaml", SigningKey: signingKey}, 1, ""))
+	require.FileExists(t, filepath.Join(outputDir, watermarking.SignatureFileName))
+
+	out := &bytes.Buffer{}
+	require.NoError(t, VerifyWatermark(outputDir, VerifyWatermarkOptions{PublicKey: publicKey}, out))
+	assert.Equal(t, "valid signature by SHA256:R1tDjmUZUN2iTO9dpAJAQzxRWl14SpZQGRlm2zWFEG4\nall 2 files match the manifest\n", out.String())
+
+	// without a public key any signer is accepted, which the output points out
+	out.Reset()
+	require.NoError(t, VerifyWatermark(outputDir, VerifyWatermarkOptions{}, out))
+	assert.Equal(t, "valid signature by the untrusted key SHA256:R1tDjmUZUN2iTO9dpAJAQzxRWl14SpZQGRlm2zWFEG4, pass its public key to verify the signer\nall 2 files match the manifest\n", out.String())
+
+	otherKey := filepath.Join(t.TempDir(), "other.pub")
+	require.NoError(t, os.WriteFile(otherKey, []byte("ssh-ed25519 AAAAC3NzaC1lZDI1NTE5AAAAIAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAA other\n"), 0600))
+	err := VerifyWatermark(outputDir, VerifyWatermarkOptions{PublicKey: otherKey}, &bytes.Buffer{})
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "is signed by SHA256:R1tDjmUZUN2iTO9dpAJAQzxRWl14SpZQGRlm2zWFEG4, expected")
+
+	require.NoError(t, os.WriteFile(filepath.Join(outputDir, "a.log"), []byte("10.0.0.1\n"), 0600))
+	out.Reset()
+	err = VerifyWatermark(outputDir, VerifyWatermarkOptions{PublicKey: publicKey}, out)
+	assert.EqualError(t, err, fmt.Sprintf("1 files in %s do not match the manifest", outputDir))
+	assert.Contains(t, out.String(), "changed: a.log\n")
+
+	require.NoError(t, os.Remove(filepath.Join(outputDir, watermarking.SignatureFileName)))
+	err = VerifyWatermark(outputDir, VerifyWatermarkOptions{PublicKey: publicKey}, &bytes.Buffer{})
+	assert.EqualError(t, err, fmt.Sprintf("manifest in %s is not signed", outputDir))
+	err = VerifyWatermark(outputDir, VerifyWatermarkOptions{}, &bytes.Buffer{})
+	assert.EqualError(t, err, fmt.Sprintf("manifest in %s is not signed", outputDir))
+	err = VerifyWatermark(outputDir, VerifyWatermarkOptions{PublicKey: publicKey, AllowUnsigned: true}, &bytes.Buffer{})
+	assert.EqualError(t, err, fmt.Sprintf("manifest in %s is not signed", outputDir), "the public key requires a signature")
+	out.Reset()
+	err = VerifyWatermark(outputDir, VerifyWatermarkOptions{AllowUnsigned: true}, out)
+	assert.EqualError(t, err, fmt.Sprintf("1 files in %s do not match the manifest", outputDir))
+	assert.Equal(t, "manifest is not signed, anyone could have written it\nchanged: a.log\n", out.String())
+}
+
+func TestDiff(t *testing.T) {
+	inputDir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(inputDir, "a.log"), []byte("10.0.0.1 and 10.0.0.2\n"), 0600))
+	require.NoError(t, os.WriteFile(filepath.Join(inputDir, "secret.log"), []byte("secret\n"), 0600))
+	run := func(config string) (string, string) {
+		configPath := filepath.Join(t.TempDir(), "config.yaml")
+		require.NoError(t, os.WriteFile(configPath, []byte(config), 0600))
+		outputDir := filepath.Join(t.TempDir(), "output")
+		reportDir := t.TempDir()
+		require.NoError(t, Run(configPath, inputDir, outputDir, false, ReportOptions{Folder: reportDir, Format: "json"}, 1, ""))
+		return filepath.Join(reportDir, "report.json"), outputDir
+	}
+	oldReport, oldOutput := run("config:\n  obfuscate:\n  - type: Keywords\n    replacement:\n      10.0.0.1: first\n")
+	newReport, newOutput := run("config:\n  obfuscate:\n  - type: Keywords\n    replacement:\n      10.0.0.2: second\n  omit:\n  - type: File\n    pattern: secret.log\n")
+
+	out := &bytes.Buffer{}
+	require.NoError(t, Diff(oldReport, newReport, oldOutput, newOutput, "text", out))
+	assert.Equal(t, `obfuscator 0 (Keywords): 1 added, 1 removed
+  + 10.0.0.2 -> second
+  - 10.0.0.1 -> first
+omissions: 1 added, 0 removed
+  + secret.log (rule 0)
+output: 0 added, 1 removed, 2 changed
+  - secret.log
+  ~ a.log
+  ~ watermark.txt
+`, out.String())
+
+	out.Reset()
+	require.NoError(t, Diff(oldReport, oldReport, "", "", "text", out))
+	assert.Equal(t, "no differences\n", out.String())
+
+	out.Reset()
+	require.NoError(t, Diff(oldReport, newReport, "", "", "json", out))
+	var result map[string]interface{}
+	require.NoError(t, json.Unmarshal(out.Bytes(), &result))
+	assert.Contains(t, result, "obfuscators")
+	assert.Contains(t, result, "addedOmissions")
+
+	assert.EqualError(t, Diff(oldReport, newReport, oldOutput, "", "text", out), "both the old and the new output are needed to compare them")
+	assert.EqualError(t, Diff(oldReport, newReport, "", "", "xml", out), "invalid diff format xml, must be one of text, yaml or json")
+}
+
+func TestRunWithSplitReport(t *testing.T) {
+	inputDir := t.TempDir()
+	reportDir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(inputDir, "a.log"), []byte("10.0.0.1\n"), 0600))
+	require.NoError(t, os.WriteFile(filepath.Join(inputDir, "10.0.0.2.txt"), []byte("omitted\n"), 0600))
+	configPath := filepath.Join(t.TempDir(), "config.yaml")
+	require.NoError(t, os.WriteFile(configPath, []byte("config:\n  obfuscate:\n  - type: IP\n    replacementType: Consistent\n    target: All\n  omit:\n  - type: File\n    pattern: \"*.txt\"\n"), 0600))
+
+	require.NoError(t, Run(configPath, inputDir, filepath.Join(t.TempDir(), "output"), false, ReportOptions{Folder: reportDir, Format: "yaml", Split: true, MaxLocations: 1}, 1, ""))
+
+	bytes, err := os.ReadFile(filepath.Join(reportDir, "report.yaml"))
+	require.NoError(t, err)
+	var summary reporting.Report
+	require.NoError(t, yaml.Unmarshal(bytes, &summary))
+	require.Len(t, summary.Omissions, 1)
+	assert.Equal(t, "x-ipv4-0000000002-x.txt", summary.Omissions[0].Path)
+
+	// the IP only found in the path of the omitted file is part of the mapping as well
+	bytes, err = os.ReadFile(filepath.Join(reportDir, mappingFileName))
+	require.NoError(t, err)
+	var mapping reporting.Report
+	require.NoError(t, yaml.Unmarshal(bytes, &mapping))
+	assert.Equal(t, schema.ObfuscateReplacement{"10.0.0.1": "x-ipv4-0000000001-x", "10.0.0.2": "x-ipv4-0000000002-x"}, mapping.Config.Obfuscate[0].Replacement)
+
+	// the locations are kept in the mapping instead of a file of their own
+	assert.NoFileExists(t, filepath.Join(reportDir, locationsFileName))
+	assert.Empty(t, summary.Locations)
+	assert.Equal(t, []reporting.ValueLocations{{
+		Original:     "10.0.0.1",
+		ReplacedWith: "x-ipv4-0000000001-x",
+		Locations:    []reporting.Location{{Path: "a.log", Line: 1, Obfuscator: 0, Target: schema.ObfuscateTargetFileContents}},
+	}}, mapping.Locations)
+}
+
+func TestRunWithEncryptedMapping(t *testing.T) {
+	inputDir := t.TempDir()
+	reportDir := t.TempDir()
+	keyDir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(inputDir, "a.log"), []byte("10.0.0.1\n"), 0600))
+	configPath := filepath.Join(t.TempDir(), "config.yaml")
+	require.NoError(t, os.WriteFile(configPath, []byte("config:\n  obfuscate:\n  - type: IP\n    replacementType: Consistent\n"), 0600))
+
+	identity, err := age.GenerateX25519Identity()
+	require.NoError(t, err)
+	privateKeyPath := filepath.Join(keyDir, "key.txt")
+	require.NoError(t, os.WriteFile(privateKeyPath, []byte(identity.String()+"\n"), 0600))
+
+	require.NoError(t, Run(configPath, inputDir, filepath.Join(t.TempDir(), "output"), false, ReportOptions{Folder: reportDir, Format: "yaml", Recipient: identity.Recipient().String()}, 1, ""))
+
+	summary, err := os.ReadFile(filepath.Join(reportDir, "report.yaml"))
+	require.NoError(t, err)
+	assert.NotContains(t, string(summary), "10.0.0.1")
+	var report reporting.Report
+	require.NoError(t, yaml.Unmarshal(summary, &report))
+	assert.Equal(t, []reporting.ObfuscatorSummary{{Obfuscator: 0, Type: schema.ObfuscateTypeIP, Values: 1, Occurrences: 1}}, report.Summary)
+	assert.NoFileExists(t, filepath.Join(reportDir, mappingFileName))
+
+	mappingPath := filepath.Join(reportDir, encryptedMappingFile)
+	revealed := &bytes.Buffer{}
+	require.NoError(t, Reveal(mappingPath, privateKeyPath, revealed))
+	require.NoError(t, yaml.Unmarshal(revealed.Bytes(), &report))
+	assert.Equal(t, "10.0.0.1", report.Replacements[0][0].Canonical)
+
+	// the encrypted mapping reproduces the run
+	outputDir := filepath.Join(t.TempDir(), "output")
+	require.NoError(t, Run(mappingPath, inputDir, outputDir, false, ReportOptions{Folder: t.TempDir(), Format: "yaml", Key: privateKeyPath}, 1, ""))
+	cleaned, err := os.ReadFile(filepath.Join(outputDir, "a.log"))
+	require.NoError(t, err)
+	assert.Equal(t, report.Replacements[0][0].ReplacedWith+"\n", string(cleaned))
+}
+
+func TestRunWithSeedReport(t *testing.T) {
+	inputDir := t.TempDir()
+	reportDir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(inputDir, "a.log"), []byte("10.0.0.1\n"), 0600))
+	configPath := filepath.Join(t.TempDir(), "config.yaml")
+	require.NoError(t, os.WriteFile(configPath, []byte("config:\n  obfuscate:\n  - type: IP\n    replacementType: Consistent\n"), 0600))
+
+	identity, err := age.GenerateX25519Identity()
+	require.NoError(t, err)
+	privateKeyPath := filepath.Join(t.TempDir(), "key.txt")
+	require.NoError(t, os.WriteFile(privateKeyPath, []byte(identity.String()+"\n"), 0600))
+	require.NoError(t, Run(configPath, inputDir, filepath.Join(t.TempDir(), "output"), false, ReportOptions{Folder: reportDir, Format: "yaml", Recipient: identity.Recipient().String()}, 1, ""))
+	mappingPath := filepath.Join(reportDir, encryptedMappingFile)
+
+	// a later must-gather of the same cluster is cleaned with the same replacements
+	otherInputDir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(otherInputDir, "b.log"), []byte("10.0.0.2 10.0.0.1\n"), 0600))
+	outputDir := filepath.Join(t.TempDir(), "output")
+	require.NoError(t, Run(configPath, otherInputDir, outputDir, false, ReportOptions{Folder: t.TempDir(), Format: "yaml", Seed: mappingPath, Key: privateKeyPath}, 1, ""))
+	cleaned, err := os.ReadFile(filepath.Join(outputDir, "b.log"))
+	require.NoError(t, err)
+	assert.Equal(t, "x-ipv4-0000000002-x x-ipv4-0000000001-x\n", string(cleaned))
+
+	err = Run(configPath, otherInputDir, filepath.Join(t.TempDir(), "output"), false, ReportOptions{Folder: t.TempDir(), Format: "yaml", Seed: mappingPath}, 1, "")
+	assert.EqualError(t, err, fmt.Sprintf("failed to seed config from %s: the mapping is encrypted, the age identity file to decrypt it is required", mappingPath))
+
+	err = Run(configPath, otherInputDir, filepath.Join(t.TempDir(), "output"), false, ReportOptions{Folder: t.TempDir(), Format: "yaml", Seed: filepath.Join(reportDir, "report.yaml")}, 1, "")
+	assert.EqualError(t, err, fmt.Sprintf("failed to seed config from %[1]s: report %[1]s is a summary without the replaced values, use the mapping of the split report instead", filepath.Join(reportDir, "report.yaml")))
+
+	// a summary without any replaced values has nothing to seed either
+	emptySummaryPath := filepath.Join(t.TempDir(), "report.yaml")
+	require.NoError(t, os.WriteFile(emptySummaryPath, []byte("summary:\n- obfuscator: 0\n  type: IP\n"), 0600))
+	err = Run(configPath, otherInputDir, filepath.Join(t.TempDir(), "output"), false, ReportOptions{Folder: t.TempDir(), Format: "yaml", Seed: emptySummaryPath}, 1, "")
+	assert.EqualError(t, err, fmt.Sprintf("failed to seed config from %[1]s: %[1]s has no replacements, pass the full report or the mapping of a split report instead of its summary", emptySummaryPath))
+
+	macConfigPath := filepath.Join(t.TempDir(), "config.yaml")
+	require.NoError(t, os.WriteFile(macConfigPath, []byte("config:\n  obfuscate:\n  - type: MAC\n"), 0600))
+	err = Run(macConfigPath, otherInputDir, filepath.Join(t.TempDir(), "output"), false, ReportOptions{Folder: t.TempDir(), Format: "yaml", Seed: mappingPath, Key: privateKeyPath}, 1, "")
+	assert.EqualError(t, err, fmt.Sprintf("failed to seed config from %s: obfuscator 0 is of type IP in the seed, but of type MAC in the config", mappingPath))
+}
+
+func TestRunWithSeedReportOfOtherNotations(t *testing.T) {
+	inputDir := t.TempDir()
+	reportDir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(inputDir, "a.log"), []byte("mac 69:80:6f:e6:7c:05 ip 10-0-0-1\n"), 0600))
+	configPath := filepath.Join(t.TempDir(), "config.yaml")
+	require.NoError(t, os.WriteFile(configPath, []byte("config:\n  obfuscate:\n  - type: MAC\n    replacementType: Consistent\n  - type: IP\n    replacementType: Consistent\n"), 0600))
+	require.NoError(t, Run(configPath, inputDir, filepath.Join(t.TempDir(), "output"), false, ReportOptions{Folder: reportDir, Format: "yaml"}, 1, ""))
+
+	// the seeded values are found by their canonical form, whatever notation they were written in before
+	otherInputDir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(otherInputDir, "b.log"), []byte("mac 00:11:22:33:44:55 6980.6fe6.7c05 ip 10.0.0.1\n"), 0600))
+	outputDir := filepath.Join(t.TempDir(), "output")
+	require.NoError(t, Run(configPath, otherInputDir, outputDir, false, ReportOptions{Folder: t.TempDir(), Format: "yaml", Seed: filepath.Join(reportDir, "report.yaml")}, 1, ""))
+	cleaned, err := os.ReadFile(filepath.Join(outputDir, "b.log"))
+	require.NoError(t, err)
+	assert.Equal(t, "mac x-mac-0000000002-x x-mac-0000000001-x ip x-ipv4-0000000001-x\n", string(cleaned))
+}
+
+func TestRunFailsOnReportInOutput(t *testing.T) {
+	inputDir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(inputDir, "a.log"), []byte("10.0.0.1\n"), 0600))
+	configPath := filepath.Join(t.TempDir(), "config.yaml")
+	require.NoError(t, os.WriteFile(configPath, []byte("config:\n  obfuscate:\n  - type: IP\n"), 0600))
+	testDir := t.TempDir()
+	outputDir := filepath.Join(testDir, "output")
+	linkDir := filepath.Join(testDir, "link")
+	require.NoError(t, os.Mkdir(outputDir, 0700))
+	require.NoError(t, os.Symlink(outputDir, linkDir))
+	// a previous report inside the output must survive the refused run, even though -d is passed
+	previousReport := filepath.Join(outputDir, "previous", "report.yaml")
+	require.NoError(t, os.Mkdir(filepath.Dir(previousReport), 0700))
+	require.NoError(t, os.WriteFile(previousReport, []byte("replacements: []\n"), 0600))
+
+	for _, reportDir := range []string{outputDir, filepath.Join(outputDir, "reports"), filepath.Join(linkDir, "reports")} {
+		err := Run(configPath, inputDir, outputDir, true, ReportOptions{Folder: reportDir, Format: "yaml"}, 1, "")
+		assert.EqualError(t, err, fmt.Sprintf("report folder '%s' is inside the output folder '%s', the report would be shared along with the output", reportDir, outputDir))
+	}
+	assert.NoFileExists(t, filepath.Join(outputDir, "reports", "report.yaml"))
+	assert.FileExists(t, previousReport)
+
+	// every reporting file is checked, not only the report folder
+	reportDir := t.TempDir()
+	statisticsPath := filepath.Join(outputDir, "statistics.prom")
+	err := Run(configPath, inputDir, outputDir, true, ReportOptions{Folder: reportDir, Format: "yaml", Prometheus: statisticsPath}, 1, "")
+	assert.EqualError(t, err, fmt.Sprintf("statistics file '%s' is inside the output folder '%s', the report would be shared along with the output", statisticsPath, outputDir))
+	mappingPath := filepath.Join(reportDir, mappingFileName)
+	require.NoError(t, os.Symlink(filepath.Join(outputDir, mappingFileName), mappingPath))
+	err = Run(configPath, inputDir, outputDir, true, ReportOptions{Folder: reportDir, Format: "yaml", Split: true}, 1, "")
+	assert.EqualError(t, err, fmt.Sprintf("mapping '%s' is inside the output folder '%s', the report would be shared along with the output", mappingPath, outputDir))
+	assert.NoFileExists(t, statisticsPath)
+	assert.NoFileExists(t, filepath.Join(outputDir, mappingFileName))
+
+	reportDir = filepath.Join(linkDir, "reports")
+	require.NoError(t, Run(configPath, inputDir, outputDir, true, ReportOptions{Folder: reportDir, Format: "yaml", AllowInOutput: true}, 1, ""))
+	assert.FileExists(t, filepath.Join(outputDir, "reports", "report.yaml"))
+	assert.EqualError(t, Verify(outputDir), fmt.Sprintf("found 1 files that look like reports in %s: %s", outputDir, filepath.Join("reports", "report.yaml")))
+}
+
+func TestVerify(t *testing.T) {
+	outputDir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(outputDir, "a.log"), []byte("x-ipv4-0000000001-x\n"), 0600))
+	assert.NoError(t, Verify(outputDir))
+}
+
+func TestRunWithStatistics(t *testing.T) {
+	inputDir := t.TempDir()
+	reportDir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(inputDir, "a.log"), []byte("10.0.0.1\n"), 0600))
+	require.NoError(t, os.WriteFile(filepath.Join(inputDir, "b.txt"), []byte("omitted\n"), 0600))
+	configPath := filepath.Join(t.TempDir(), "config.yaml")
+	require.NoError(t, os.WriteFile(configPath, []byte("config:\n  obfuscate:\n  - type: IP\n  omit:\n  - type: File\n    pattern: \"*.txt\"\n"), 0600))
+	prometheusPath := filepath.Join(t.TempDir(), "metrics", "must-gather-clean.prom")
+
+	require.NoError(t, Run(configPath, inputDir, filepath.Join(t.TempDir(), "output"), false, ReportOptions{Folder: reportDir, Format: "yaml", Prometheus: prometheusPath}, 1, ""))
+
+	bytes, err := os.ReadFile(filepath.Join(reportDir, "report.yaml"))
+	require.NoError(t, err)
+	var report reporting.Report
+	require.NoError(t, yaml.Unmarshal(bytes, &report))
+	require.NotNil(t, report.Statistics)
+	assert.Equal(t, reporting.FileStatistics{Processed: 2, ProcessedBytes: 17, Obfuscated: 1, ObfuscatedBytes: 9, Omitted: 1, OmittedBytes: 8}, report.Statistics.Files)
+	require.Len(t, report.Statistics.Obfuscators, 1)
+	assert.Equal(t, schema.ObfuscateTypeIP, report.Statistics.Obfuscators[0].Type)
+	// the path and the contents of a.log
+	assert.Equal(t, uint64(2), report.Statistics.Obfuscators[0].Calls)
+	assert.Len(t, report.Statistics.SlowestFiles, 2)
+
+	metrics, err := os.ReadFile(prometheusPath)
+	require.NoError(t, err)
+	assert.Contains(t, string(metrics), `must_gather_clean_files{outcome="omitted"} 1`)
+	assert.Contains(t, string(metrics), `must_gather_clean_obfuscator_calls{obfuscator="0",type="IP"} 2`)
+}
+
+func TestRunWithLocations(t *testing.T) {
+	inputDir := t.TempDir()
+	outputDir := filepath.Join(t.TempDir(), "output")
+	reportDir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(inputDir, "a.log"), []byte("10.0.0.1\n10.0.0.1\n"), 0600))
+	configPath := filepath.Join(t.TempDir(), "config.yaml")
+	require.NoError(t, os.WriteFile(configPath, []byte("config:\n  obfuscate:\n  - type: IP\n"), 0600))
+
+	require.NoError(t, Run(configPath, inputDir, outputDir, false, ReportOptions{Folder: reportDir, Format: "yaml", MaxLocations: 1}, 1, ""))
+
+	bytes, err := os.ReadFile(filepath.Join(reportDir, locationsFileName))
+	require.NoError(t, err)
+	var locations reporting.LocationReport
+	require.NoError(t, yaml.Unmarshal(bytes, &locations))
+	assert.Equal(t, []reporting.ValueLocations{{
+		Original:     "10.0.0.1",
+		ReplacedWith: "xxx.xxx.xxx.xxx",
+		Omitted:      1,
+		Locations:    []reporting.Location{{Path: "a.log", Line: 1, Obfuscator: 0, Target: schema.ObfuscateTargetFileContents}},
+	}}, locations.Values)
+}
+
 func TestRunFailsOnNotExistingInputPath(t *testing.T) {
-	err := Run("", "", "", false, "", 1)
+	err := Run("", "", "", false, ReportOptions{Format: "yaml"}, 1, "")
 	assert.Equal(t, "input folder does not exist: stat : no such file or directory", err.Error())
 }
 
@@ -32,7 +455,7 @@ func TestFailConfigReading(t *testing.T) {
 		_ = os.RemoveAll(testDir)
 	}()
 
-	err = Run("some.yaml", "", testDir, false, "", 1)
+	err = Run("some.yaml", "", testDir, false, ReportOptions{Format: "yaml"}, 1, "")
 	assert.ErrorIs(t, err, os.ErrNotExist)
 }
 
@@ -72,11 +495,154 @@ func TestCreateObfuscatorFromFullConfig(t *testing.T) {
 		Omit: nil,
 	}}
 
-	mfo, err := createObfuscatorsFromConfig(config)
+	mfo, err := createObfuscatorsFromConfig(config, discovery.Report{})
 	require.NoError(t, err)
 	assert.Equal(t, "something else", mfo.Contents("something"))
 }
 
+func TestCreateObfuscatorWithDiscoveredDomains(t *testing.T) {
+	testDir, err := os.MkdirTemp(os.TempDir(), "test-dir-*")
+	require.NoError(t, err)
+	defer func() {
+		_ = os.RemoveAll(testDir)
+	}()
+
+	dnsPath := filepath.Join(testDir, "cluster-scoped-resources", "config.openshift.io", "dnses.yaml")
+	require.NoError(t, os.MkdirAll(filepath.Dir(dnsPath), 0700))
+	require.NoError(t, os.WriteFile(dnsPath, []byte("apiVersion: config.openshift.io/v1\nkind: DNS\nspec:\n  baseDomain: mycluster.example.com\n"), 0600))
+
+	config := &schema.SchemaJson{Config: schema.SchemaJsonConfig{
+		Obfuscate: []schema.Obfuscate{
+			{
+				Type:            schema.ObfuscateTypeDomain,
+				DomainNames:     []string{"something.com"},
+				AutoDiscovery:   true,
+				Target:          schema.ObfuscateTargetFileContents,
+				ReplacementType: schema.ObfuscateReplacementTypeStatic,
+			},
+		},
+	}}
+
+	discovered, err := discoverFromConfig(config, testDir)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"mycluster.example.com"}, discovered.Domains)
+
+	mfo, err := createObfuscatorsFromConfig(config, discovered)
+	require.NoError(t, err)
+	assert.Equal(t, "console.obfuscated.com and obfuscated.com", mfo.Contents("console.mycluster.example.com and something.com"))
+}
+
+func TestCreateObfuscatorWithoutDiscoveredDomains(t *testing.T) {
+	config := &schema.SchemaJson{Config: schema.SchemaJsonConfig{
+		Obfuscate: []schema.Obfuscate{
+			{
+				Type:            schema.ObfuscateTypeDomain,
+				AutoDiscovery:   true,
+				Target:          schema.ObfuscateTargetFileContents,
+				ReplacementType: schema.ObfuscateReplacementTypeStatic,
+			},
+			{
+				Type:            schema.ObfuscateTypeIP,
+				Target:          schema.ObfuscateTargetFileContents,
+				ReplacementType: schema.ObfuscateReplacementTypeStatic,
+			},
+		},
+	}}
+
+	// nothing is discovered in pipe mode, the other obfuscators still run
+	mfo, err := createObfuscatorsFromConfig(config, discovery.Report{})
+	require.NoError(t, err)
+	assert.Equal(t, "console.example.com at xxx.xxx.xxx.xxx", mfo.Contents("console.example.com at 10.0.0.1"))
+
+	config.Config.Obfuscate[0].AutoDiscovery = false
+	_, err = createObfuscatorsFromConfig(config, discovery.Report{})
+	assert.EqualError(t, err, "no domainNames supplied for the obfuscation type: Domain")
+}
+
+func TestCreateObfuscatorWithExceptions(t *testing.T) {
+	literal := "8.8.8.8"
+	config := &schema.SchemaJson{Config: schema.SchemaJsonConfig{
+		Obfuscate: []schema.Obfuscate{
+			{
+				Type:            schema.ObfuscateTypeIP,
+				Exceptions:      []schema.ObfuscateExceptionsElem{{Literal: &literal}},
+				Target:          schema.ObfuscateTargetFileContents,
+				ReplacementType: schema.ObfuscateReplacementTypeStatic,
+			},
+		},
+	}}
+
+	mfo, err := createObfuscatorsFromConfig(config, discovery.Report{})
+	require.NoError(t, err)
+	assert.Equal(t, "resolving xxx.xxx.xxx.xxx with 8.8.8.8", mfo.Contents("resolving 10.0.0.1 with 8.8.8.8"))
+	assert.Equal(t, map[string]uint{"8.8.8.8": 1}, mfo.ReportPerObfuscator()[0].Skipped)
+}
+
+func TestCreateObfuscatorWithChainAndPriority(t *testing.T) {
+	nodes := "node-[0-9]+"
+	digits := "[0-9]{4}"
+	config := &schema.SchemaJson{Config: schema.SchemaJsonConfig{
+		Obfuscate: []schema.Obfuscate{
+			{
+				Type:            schema.ObfuscateTypeRegex,
+				Regex:           &nodes,
+				ReplacementType: schema.ObfuscateReplacementTypeStatic,
+				Target:          schema.ObfuscateTargetFileContents,
+			},
+			{
+				Type:        schema.ObfuscateTypeKeywords,
+				Replacement: map[string]string{"secret": "1234", "node-01": "master-node"},
+				Target:      schema.ObfuscateTargetFileContents,
+				Priority:    1,
+			},
+			{
+				Type:            schema.ObfuscateTypeRegex,
+				Regex:           &digits,
+				ReplacementType: schema.ObfuscateReplacementTypeStatic,
+				Target:          schema.ObfuscateTargetFileContents,
+				Chain:           true,
+			},
+		},
+	}}
+
+	mfo, err := createObfuscatorsFromConfig(config, discovery.Report{})
+	require.NoError(t, err)
+	assert.Equal(t, "xxxx on master-node and xxxxxxx", mfo.Contents("secret on node-01 and node-02"))
+}
+
+func TestCreateObfuscatorWithScopes(t *testing.T) {
+	config := &schema.SchemaJson{Config: schema.SchemaJsonConfig{
+		Obfuscate: []schema.Obfuscate{
+			{
+				Type:            schema.ObfuscateTypeIP,
+				ReplacementType: schema.ObfuscateReplacementTypeStatic,
+				Target:          schema.ObfuscateTargetAll,
+				Paths:           &schema.ObfuscatePaths{Include: []string{"*/pods/*"}, Exclude: []string{"*/pods/*.yaml"}},
+			},
+			{
+				Type:                schema.ObfuscateTypeKeywords,
+				Replacement:         map[string]string{"console": "app"},
+				Target:              schema.ObfuscateTargetAll,
+				KubernetesResources: &schema.ObfuscateKubernetesResources{Exclude: []schema.KubernetesResourceSelector{{Kind: "ClusterOperator"}}},
+			},
+		},
+	}}
+
+	mfo, err := createObfuscatorsFromConfig(config, discovery.Report{})
+	require.NoError(t, err)
+	assert.Equal(t, "xxx.xxx.xxx.xxx", mfo.ForFile("ns/pods/api.log", nil).Contents("10.0.0.1"))
+	assert.Equal(t, "10.0.0.1", mfo.ForFile("ns/pods/api.yaml", nil).Contents("10.0.0.1"))
+	assert.Equal(t, "10.0.0.1", mfo.ForFile("ns/nodes/api.log", nil).Contents("10.0.0.1"))
+
+	operator := &kube.ResourceListWithPath{ResourceList: kube.ResourceList{Items: []kube.Resource{{ApiVersion: "config.openshift.io/v1", Kind: "ClusterOperator"}}}}
+	assert.Equal(t, "app", mfo.ForFile("ns/pods/api.log", nil).Contents("console"))
+	assert.Equal(t, "console", mfo.ForFile("cluster-scoped-resources/console.yaml", operator).Contents("console"))
+
+	config.Config.Obfuscate[0].Paths.Include = []string{"[pods"}
+	_, err = createObfuscatorsFromConfig(config, discovery.Report{})
+	assert.EqualError(t, err, "invalid path glob '[pods': syntax error in pattern")
+}
+
 func TestCreateOmitter(t *testing.T) {
 	sampleApiVersion := "v1"
 	sampleKind := "Resource"
@@ -202,6 +768,42 @@ config:
 	assert.Equal(t, "some IP 192.167.122.2 that should not to be obfuscated\nand some mac x-mac-0000000001-x\n", string(bytes))
 }
 
+func TestRunPipeConfigMultiline(t *testing.T) {
+	cfgFile, err := os.CreateTemp("", "temp-file-*.yaml")
+	require.NoError(t, err)
+	defer func() {
+		_ = os.RemoveAll(cfgFile.Name())
+	}()
+
+	_, err = cfgFile.WriteString(`
+config:
+  multiline:
+    mode: Window
+    windowLines: 3
+  obfuscate:
+    - type: Regex
+      regex: "\"password\":\\s*\"[^\"]*\""
+      multiline: true
+    - type: MAC
+      replacementType: Consistent
+`)
+	require.NoError(t, err)
+	require.NoError(t, cfgFile.Close())
+
+	input := strings.NewReader("{\n  \"password\":\n    \"hunter2\",\n  \"mac\": \"eb:a1:2a:b2:09:bf\"\n}\n")
+	output := &strings.Builder{}
+	require.NoError(t, RunPipe(cfgFile.Name(), input, output))
+	assert.Equal(t, "{\n  xxxxxxxxxxx\nxxxxxxxxxxxxx,\n  \"mac\": \"x-mac-0000000001-x\"\n}\n", output.String())
+}
+
+func TestRunFailsOnInvalidMultilineConfig(t *testing.T) {
+	config := &schema.SchemaJson{Config: schema.SchemaJsonConfig{
+		Multiline: &schema.SchemaJsonConfigMultiline{Mode: schema.SchemaJsonConfigMultilineModeWindow},
+	}}
+	_, err := newContentObfuscator(config, obfuscator.NewMultiObfuscator(nil))
+	assert.EqualError(t, err, "multiline windowLines and maxFileSize must be positive, given: 0 and 0")
+}
+
 func TestWaterMarkerNotCreatedOnFail(t *testing.T) {
 	testDir, err := os.MkdirTemp(os.TempDir(), "test-dir-*")
 	require.NoError(t, err)
@@ -209,7 +811,7 @@ func TestWaterMarkerNotCreatedOnFail(t *testing.T) {
 		_ = os.RemoveAll(testDir)
 	}()
 
-	err = Run("some.yaml", "", testDir, false, "", 1)
+	err = Run("some.yaml", "", testDir, false, ReportOptions{Format: "yaml"}, 1, "")
 	assert.ErrorIs(t, err, os.ErrNotExist)
 	require.NoFileExists(t, filepath.Join(testDir, "watermark.txt"))
 }
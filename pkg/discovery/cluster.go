@@ -0,0 +1,91 @@
+package discovery
+
+import (
+	"encoding/json"
+	"regexp"
+	"strings"
+
+	"github.com/openshift/must-gather-clean/pkg/obfuscator"
+)
+
+// gcpProviderIDPattern finds the project in a GCP provider ID, for example gce://<project>/<zone>/<instance>
+var gcpProviderIDPattern = regexp.MustCompile(`^gce://([a-z][a-z0-9-]{4,28}[a-z0-9])/`)
+
+var clusterIdentifierSources = []source{
+	{pattern: "config.openshift.io/clusterversions.yaml", extract: clusterVersionID},
+	{pattern: "config.openshift.io/clusterversions/*.yaml", extract: clusterVersionID},
+	{pattern: "config.openshift.io/infrastructures.yaml", extract: infrastructureIdentifiers},
+	{pattern: "config.openshift.io/infrastructures/*.yaml", extract: infrastructureIdentifiers},
+	{pattern: "machine.openshift.io/machines.yaml", extract: providerIdentifiers},
+	{pattern: "machine.openshift.io/machines/*.yaml", extract: providerIdentifiers},
+	{pattern: "core/nodes.yaml", extract: providerIdentifiers},
+	{pattern: "core/nodes/*.yaml", extract: providerIdentifiers},
+	{pattern: "openshift-config/core/configmaps.yaml", extract: cloudProviderConfigIdentifiers},
+	{pattern: "openshift-config/core/configmaps/*.yaml", extract: cloudProviderConfigIdentifiers},
+	{pattern: "cloudcredential.openshift.io/credentialsrequests.yaml", extract: awsAccountIdentifiers},
+	{pattern: "cloudcredential.openshift.io/credentialsrequests/*.yaml", extract: awsAccountIdentifiers},
+}
+
+// DiscoverClusterIdentifiers returns the identifiers of the cluster and its cloud account that were found in the
+// must-gather under inputPath: the cluster ID, the infrastructure name, AWS account IDs, Azure subscription and tenant
+// IDs and GCP project IDs.
+func DiscoverClusterIdentifiers(inputPath string) ([]string, error) {
+	values, err := walk(inputPath, clusterIdentifierSources)
+	if err != nil {
+		return nil, err
+	}
+	return sortedKeys(values), nil
+}
+
+func clusterVersionID(resource map[string]interface{}) []string {
+	return []string{lookup(resource, "spec", "clusterID")}
+}
+
+func infrastructureIdentifiers(resource map[string]interface{}) []string {
+	return []string{
+		lookup(resource, "status", "infrastructureName"),
+		lookup(resource, "status", "platformStatus", "gcp", "projectID"),
+	}
+}
+
+func providerIdentifiers(resource map[string]interface{}) []string {
+	providerID := lookup(resource, "spec", "providerID")
+	var identifiers []string
+	if m := obfuscator.AzureSubscriptionIDPattern.FindStringSubmatch(providerID); m != nil {
+		identifiers = append(identifiers, strings.ToLower(m[1]))
+	}
+	if m := gcpProviderIDPattern.FindStringSubmatch(providerID); m != nil {
+		identifiers = append(identifiers, m[1])
+	}
+	return identifiers
+}
+
+func cloudProviderConfigIdentifiers(resource map[string]interface{}) []string {
+	if lookup(resource, "metadata", "name") != "cloud-provider-config" {
+		return nil
+	}
+
+	var config map[string]interface{}
+	err := json.Unmarshal([]byte(lookup(resource, "data", "config")), &config)
+	if err != nil {
+		return nil
+	}
+	return []string{
+		strings.ToLower(lookup(config, "subscriptionId")),
+		strings.ToLower(lookup(config, "tenantId")),
+		lookup(config, "projectID"),
+	}
+}
+
+func awsAccountIdentifiers(resource map[string]interface{}) []string {
+	bytes, err := json.Marshal(resource)
+	if err != nil {
+		return nil
+	}
+
+	var identifiers []string
+	for _, m := range obfuscator.AWSAccountIDPattern.FindAllStringSubmatch(string(bytes), -1) {
+		identifiers = append(identifiers, m[1])
+	}
+	return identifiers
+}
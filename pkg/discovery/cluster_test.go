@@ -0,0 +1,67 @@
+package discovery
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDiscoverClusterIdentifiers(t *testing.T) {
+	dir := writeTestFiles(t, map[string]string{
+		"image/cluster-scoped-resources/config.openshift.io/clusterversions.yaml": `apiVersion: config.openshift.io/v1
+kind: ClusterVersionList
+items:
+- apiVersion: config.openshift.io/v1
+  kind: ClusterVersion
+  spec:
+    clusterID: 96eb6c40-16e8-4718-ade6-5d7c6f05e120
+`,
+		"image/cluster-scoped-resources/config.openshift.io/infrastructures.yaml": `apiVersion: config.openshift.io/v1
+kind: Infrastructure
+status:
+  infrastructureName: mycluster-abcde
+  platformStatus:
+    gcp:
+      projectID: my-gcp-project
+`,
+		"image/namespaces/openshift-machine-api/machine.openshift.io/machines/mycluster-abcde-master-0.yaml": `apiVersion: machine.openshift.io/v1beta1
+kind: Machine
+spec:
+  providerID: azure:///subscriptions/D38F1E38-4BED-438E-B227-833F997ADF6A/resourceGroups/rg/providers/Microsoft.Compute/virtualMachines/vm
+`,
+		"image/cluster-scoped-resources/core/nodes/worker-0.yaml": `apiVersion: v1
+kind: Node
+spec:
+  providerID: gce://other-project-123/us-central1-a/worker-0
+`,
+		"image/namespaces/openshift-config/core/configmaps.yaml": `apiVersion: v1
+kind: ConfigMapList
+items:
+- apiVersion: v1
+  kind: ConfigMap
+  metadata:
+    name: cloud-provider-config
+  data:
+    config: '{"tenantId": "6047c7e9-b2ad-488d-a54e-dc3f6be6a7ee", "subscriptionId": "d38f1e38-4bed-438e-b227-833f997adf6a"}'
+`,
+		"image/cluster-scoped-resources/cloudcredential.openshift.io/credentialsrequests.yaml": `apiVersion: cloudcredential.openshift.io/v1
+kind: CredentialsRequest
+status:
+  providerStatus:
+    user: arn:aws:iam::460538899914:user/some-user
+`,
+	})
+
+	identifiers, err := DiscoverClusterIdentifiers(dir)
+	require.NoError(t, err)
+	assert.Equal(t, []string{
+		"460538899914",
+		"6047c7e9-b2ad-488d-a54e-dc3f6be6a7ee",
+		"96eb6c40-16e8-4718-ade6-5d7c6f05e120",
+		"d38f1e38-4bed-438e-b227-833f997adf6a",
+		"my-gcp-project",
+		"mycluster-abcde",
+		"other-project-123",
+	}, identifiers)
+}
@@ -0,0 +1,264 @@
+// Package discovery harvests confidential values (for example the cluster base domains) from well-known resources in a
+// must-gather, so they can be fed to the obfuscators without the user having to enumerate them up front.
+package discovery
+
+import (
+	"bufio"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/fs"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+	"k8s.io/klog/v2"
+
+	"github.com/openshift/must-gather-clean/pkg/kube"
+)
+
+// Report contains everything that was discovered in a must-gather.
+type Report struct {
+	Domains            []string   `yaml:"domains,omitempty" json:"domains,omitempty"`
+	ClusterIdentifiers []string   `yaml:"clusterIdentifiers,omitempty" json:"clusterIdentifiers,omitempty"`
+	Identities         Identities `yaml:"identities,omitempty" json:"identities,omitempty"`
+}
+
+// source describes a well-known must-gather file and how to extract values from each of the resources it contains.
+type source struct {
+	// pattern is a glob (as described in https://pkg.go.dev/path/filepath#Match) that is matched against the trailing
+	// path segments of every file in the must-gather, this avoids having to know the name of the image folder.
+	pattern string
+	// lines indicates that the file contains one json object per line (optionally gzipped), for example audit logs
+	lines   bool
+	extract func(resource map[string]interface{}) []string
+}
+
+// walk traverses the inputPath and runs the extractors of all matching sources, returning the deduplicated values.
+func walk(inputPath string, sources []source) (map[string]struct{}, error) {
+	values := map[string]struct{}{}
+	err := filepath.WalkDir(inputPath, func(path string, dirEntry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if dirEntry.IsDir() || !dirEntry.Type().IsRegular() {
+			return nil
+		}
+
+		relPath, err := filepath.Rel(inputPath, path)
+		if err != nil {
+			relPath = path
+		}
+
+		for _, s := range sources {
+			if !matchesSuffix(s.pattern, relPath) {
+				continue
+			}
+
+			extract := func(resource map[string]interface{}) {
+				for _, v := range s.extract(resource) {
+					if v != "" {
+						values[v] = struct{}{}
+					}
+				}
+			}
+			if s.lines {
+				err = readJSONLines(path, extract)
+			} else {
+				err = readResources(path, extract)
+			}
+			if err != nil {
+				klog.V(2).Infof("skipping discovery in %s: %v", relPath, err)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to discover in %s: %w", inputPath, err)
+	}
+	return values, nil
+}
+
+// matchesSuffix returns true if the pattern matches the last path segments of the given path.
+func matchesSuffix(pattern string, path string) bool {
+	patternSegments := strings.Count(pattern, "/") + 1
+	pathSegments := strings.Split(filepath.ToSlash(path), "/")
+	if len(pathSegments) < patternSegments {
+		return false
+	}
+	match, err := filepath.Match(pattern, strings.Join(pathSegments[len(pathSegments)-patternSegments:], "/"))
+	return err == nil && match
+}
+
+// readResources parses a yaml or json file and passes the contained resources to extract, unwrapping list types into
+// their items.
+func readResources(path string, extract func(resource map[string]interface{})) error {
+	var unmarshaller kube.ResourceUnmarshaller
+	switch {
+	case strings.HasSuffix(path, ".yml") || strings.HasSuffix(path, ".yaml"):
+		unmarshaller = yaml.Unmarshal
+	case strings.HasSuffix(path, ".json"):
+		unmarshaller = json.Unmarshal
+	default:
+		return kube.NoKubernetesResourceError
+	}
+
+	input, err := ioutil.ReadFile(path)
+	if err != nil {
+		return err
+	}
+
+	var resource map[string]interface{}
+	err = unmarshaller(input, &resource)
+	if err != nil {
+		return kube.NoKubernetesResourceError
+	}
+
+	if items, ok := resource["items"].([]interface{}); ok {
+		for _, i := range items {
+			if m, ok := i.(map[string]interface{}); ok {
+				extract(m)
+			}
+		}
+		return nil
+	}
+
+	extract(resource)
+	return nil
+}
+
+// maxJSONLineSize is the longest line readJSONLines parses, audit events with request and response objects can get
+// large. Longer lines are skipped.
+var maxJSONLineSize = 16 * 1024 * 1024
+
+// readJSONLines parses a file that contains a json object on every line and passes each of them to extract, lines that
+// are not json objects are skipped. The file is optionally gzipped and read line by line, since audit logs can get large.
+func readJSONLines(path string, extract func(resource map[string]interface{})) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	var input io.Reader = f
+	if strings.HasSuffix(path, ".gz") {
+		gz, err := gzip.NewReader(f)
+		if err != nil {
+			return err
+		}
+		defer gz.Close()
+		input = gz
+	}
+
+	reader := bufio.NewReader(input)
+	lineNumber := 0
+	for {
+		line, tooLong, err := readLine(reader)
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		lineNumber++
+		if tooLong {
+			// the rest of the file is still read, so that the identities after the line are discovered as well
+			klog.Warningf("skipped line %d of %s, it is longer than %d bytes", lineNumber, path, maxJSONLineSize)
+			continue
+		}
+		var resource map[string]interface{}
+		if json.Unmarshal(line, &resource) == nil {
+			extract(resource)
+		}
+	}
+}
+
+// readLine reads the next line from the reader. Lines longer than maxJSONLineSize are consumed, but not returned.
+func readLine(reader *bufio.Reader) ([]byte, bool, error) {
+	var line []byte
+	tooLong := false
+	for {
+		chunk, isPrefix, err := reader.ReadLine()
+		if err != nil {
+			return nil, false, err
+		}
+		if !tooLong && len(line)+len(chunk) > maxJSONLineSize {
+			tooLong = true
+			line = nil
+		}
+		if !tooLong {
+			line = append(line, chunk...)
+		}
+		if !isPrefix {
+			return line, tooLong, nil
+		}
+	}
+}
+
+// lookup walks the given keys down the nested maps and returns the string found at the end, or an empty string otherwise.
+func lookup(resource map[string]interface{}, keys ...string) string {
+	var current interface{} = resource
+	for _, k := range keys {
+		m, ok := current.(map[string]interface{})
+		if !ok {
+			return ""
+		}
+		current = m[k]
+	}
+	s, _ := current.(string)
+	return s
+}
+
+// lookupStrings walks the given keys down the nested maps and returns the list of strings found at the end.
+func lookupStrings(resource map[string]interface{}, keys ...string) []string {
+	var current interface{} = resource
+	for _, k := range keys {
+		m, ok := current.(map[string]interface{})
+		if !ok {
+			return nil
+		}
+		current = m[k]
+	}
+	list, _ := current.([]interface{})
+	var result []string
+	for _, i := range list {
+		if s, ok := i.(string); ok {
+			result = append(result, s)
+		}
+	}
+	return result
+}
+
+// collectStrings recursively returns all string values stored under the given key anywhere in the resource.
+func collectStrings(resource interface{}, key string) []string {
+	var result []string
+	switch r := resource.(type) {
+	case map[string]interface{}:
+		for k, v := range r {
+			if s, ok := v.(string); ok && k == key {
+				result = append(result, s)
+			} else {
+				result = append(result, collectStrings(v, key)...)
+			}
+		}
+	case []interface{}:
+		for _, v := range r {
+			result = append(result, collectStrings(v, key)...)
+		}
+	}
+	return result
+}
+
+func sortedKeys(m map[string]struct{}) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
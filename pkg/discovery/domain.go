@@ -0,0 +1,149 @@
+package discovery
+
+import (
+	"net"
+	"net/url"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ignoredDomains are cluster-internal domains that are not confidential and appear in every must-gather.
+var ignoredDomains = map[string]struct{}{
+	"cluster.local":     {},
+	"svc.cluster.local": {},
+	"localdomain":       {},
+	"localhost":         {},
+}
+
+var domainSources = []source{
+	{pattern: "config.openshift.io/dnses.yaml", extract: dnsBaseDomain},
+	{pattern: "config.openshift.io/dnses/*.yaml", extract: dnsBaseDomain},
+	{pattern: "config.openshift.io/infrastructures.yaml", extract: infrastructureDomains},
+	{pattern: "config.openshift.io/infrastructures/*.yaml", extract: infrastructureDomains},
+	{pattern: "config.openshift.io/ingresses.yaml", extract: ingressDomain},
+	{pattern: "config.openshift.io/ingresses/*.yaml", extract: ingressDomain},
+	{pattern: "route.openshift.io/routes.yaml", extract: routeDomain},
+	{pattern: "route.openshift.io/routes/*.yaml", extract: routeDomain},
+	{pattern: "core/nodes.yaml", extract: nodeDomain},
+	{pattern: "core/nodes/*.yaml", extract: nodeDomain},
+	{pattern: "kube-system/core/configmaps.yaml", extract: installConfigBaseDomain},
+	{pattern: "kube-system/core/configmaps/*.yaml", extract: installConfigBaseDomain},
+}
+
+// DiscoverDomains returns the base domains of the cluster that were found in the must-gather under inputPath.
+// Subdomains of other discovered domains are collapsed, as the Domain obfuscator will already match them.
+func DiscoverDomains(inputPath string) ([]string, error) {
+	values, err := walk(inputPath, domainSources)
+	if err != nil {
+		return nil, err
+	}
+
+	domains := map[string]struct{}{}
+	for v := range values {
+		d := normalizeDomain(v)
+		if d == "" {
+			continue
+		}
+		domains[d] = struct{}{}
+	}
+	return collapseSubdomains(sortedKeys(domains)), nil
+}
+
+func dnsBaseDomain(resource map[string]interface{}) []string {
+	return []string{lookup(resource, "spec", "baseDomain")}
+}
+
+func infrastructureDomains(resource map[string]interface{}) []string {
+	return []string{
+		hostFromURL(lookup(resource, "status", "apiServerURL"), "api."),
+		hostFromURL(lookup(resource, "status", "apiServerInternalURI"), "api-int."),
+		lookup(resource, "status", "etcdDiscoveryDomain"),
+	}
+}
+
+func ingressDomain(resource map[string]interface{}) []string {
+	return []string{strings.TrimPrefix(lookup(resource, "spec", "domain"), "apps.")}
+}
+
+func routeDomain(resource map[string]interface{}) []string {
+	return []string{parentDomain(lookup(resource, "spec", "host"))}
+}
+
+func nodeDomain(resource map[string]interface{}) []string {
+	return []string{parentDomain(lookup(resource, "metadata", "name"))}
+}
+
+func installConfigBaseDomain(resource map[string]interface{}) []string {
+	if lookup(resource, "metadata", "name") != "cluster-config-v1" {
+		return nil
+	}
+
+	var installConfig map[string]interface{}
+	err := yaml.Unmarshal([]byte(lookup(resource, "data", "install-config")), &installConfig)
+	if err != nil {
+		return nil
+	}
+	return []string{lookup(installConfig, "baseDomain")}
+}
+
+// hostFromURL returns the hostname of the given URL without the port and the given prefix.
+func hostFromURL(rawURL string, prefix string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return ""
+	}
+	return strings.TrimPrefix(u.Hostname(), prefix)
+}
+
+// parentDomain strips the first label off a fully qualified domain name, unqualified names return an empty string.
+func parentDomain(fqdn string) string {
+	i := strings.Index(fqdn, ".")
+	if i < 0 {
+		return ""
+	}
+	return fqdn[i+1:]
+}
+
+// normalizeDomain lower-cases the domain and returns an empty string for anything that is not a multi-label domain name.
+func normalizeDomain(d string) string {
+	d = strings.TrimSuffix(strings.ToLower(strings.TrimSpace(d)), ".")
+	if !strings.Contains(d, ".") || net.ParseIP(d) != nil {
+		return ""
+	}
+	if _, ok := ignoredDomains[d]; ok {
+		return ""
+	}
+	for _, r := range d {
+		if !(r == '.' || r == '-' || (r >= 'a' && r <= 'z') || (r >= '0' && r <= '9')) {
+			return ""
+		}
+	}
+	return d
+}
+
+// collapseSubdomains removes all domains that are a subdomain of another domain in the list.
+func collapseSubdomains(domains []string) []string {
+	sorted := make([]string, len(domains))
+	copy(sorted, domains)
+	sort.Slice(sorted, func(i, j int) bool {
+		return len(sorted[i]) < len(sorted[j])
+	})
+
+	var result []string
+	for _, d := range sorted {
+		isSubdomain := false
+		for _, r := range result {
+			if strings.HasSuffix(d, "."+r) {
+				isSubdomain = true
+				break
+			}
+		}
+		if !isSubdomain {
+			result = append(result, d)
+		}
+	}
+	sort.Strings(result)
+	return result
+}
@@ -0,0 +1,152 @@
+package discovery
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeTestFiles(t *testing.T, files map[string]string) string {
+	dir, err := os.MkdirTemp("", "discovery-*")
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		_ = os.RemoveAll(dir)
+	})
+
+	for path, contents := range files {
+		p := filepath.Join(dir, path)
+		require.NoError(t, os.MkdirAll(filepath.Dir(p), 0700))
+		require.NoError(t, os.WriteFile(p, []byte(contents), 0600))
+	}
+	return dir
+}
+
+func TestDiscoverDomains(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		files    map[string]string
+		expected []string
+	}{
+		{
+			name:     "empty must-gather",
+			files:    map[string]string{"timestamp": "now"},
+			expected: nil,
+		},
+		{
+			name: "dns base domain",
+			files: map[string]string{
+				"image/cluster-scoped-resources/config.openshift.io/dnses.yaml": `apiVersion: config.openshift.io/v1
+kind: DNSList
+items:
+- apiVersion: config.openshift.io/v1
+  kind: DNS
+  spec:
+    baseDomain: mycluster.example.com
+`,
+			},
+			expected: []string{"mycluster.example.com"},
+		},
+		{
+			name: "infrastructure and ingress",
+			files: map[string]string{
+				"image/cluster-scoped-resources/config.openshift.io/infrastructures.yaml": `apiVersion: config.openshift.io/v1
+kind: Infrastructure
+status:
+  apiServerURL: https://api.mycluster.example.com:6443
+  apiServerInternalURI: https://api-int.mycluster.example.com:6443
+`,
+				"image/cluster-scoped-resources/config.openshift.io/ingresses.yaml": `apiVersion: config.openshift.io/v1
+kind: Ingress
+spec:
+  domain: apps.other.example.org
+`,
+			},
+			expected: []string{"mycluster.example.com", "other.example.org"},
+		},
+		{
+			name: "routes and nodes",
+			files: map[string]string{
+				"image/namespaces/my-app/route.openshift.io/routes.yaml": `apiVersion: route.openshift.io/v1
+kind: RouteList
+items:
+- apiVersion: route.openshift.io/v1
+  kind: Route
+  spec:
+    host: shop.customer.net
+- apiVersion: route.openshift.io/v1
+  kind: Route
+  spec:
+    host: api.shop.customer.net
+`,
+				"image/cluster-scoped-resources/core/nodes/ip-10-0-1-2.ec2.internal.yaml": `apiVersion: v1
+kind: Node
+metadata:
+  name: ip-10-0-1-2.ec2.internal
+`,
+				"image/cluster-scoped-resources/core/nodes/master-0.yaml": `apiVersion: v1
+kind: Node
+metadata:
+  name: master-0
+`,
+			},
+			expected: []string{"customer.net", "ec2.internal"},
+		},
+		{
+			name: "install-config",
+			files: map[string]string{
+				"image/namespaces/kube-system/core/configmaps.yaml": `apiVersion: v1
+kind: ConfigMapList
+items:
+- apiVersion: v1
+  kind: ConfigMap
+  metadata:
+    name: cluster-config-v1
+  data:
+    install-config: |
+      apiVersion: v1
+      baseDomain: Example.COM
+- apiVersion: v1
+  kind: ConfigMap
+  metadata:
+    name: something-else
+  data:
+    install-config: |
+      baseDomain: not-relevant.com
+`,
+			},
+			expected: []string{"example.com"},
+		},
+		{
+			name: "ignores cluster-internal and malformed files",
+			files: map[string]string{
+				"image/namespaces/my-app/route.openshift.io/routes.yaml": `apiVersion: route.openshift.io/v1
+kind: Route
+spec:
+  host: my-svc.cluster.local
+`,
+				"image/cluster-scoped-resources/config.openshift.io/dnses.yaml": `{{ not yaml`,
+			},
+			expected: nil,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			dir := writeTestFiles(t, tc.files)
+			domains, err := DiscoverDomains(dir)
+			require.NoError(t, err)
+			assert.Equal(t, tc.expected, domains)
+		})
+	}
+}
+
+func TestCollapseSubdomains(t *testing.T) {
+	assert.Equal(t, []string{"example.com", "other.org"}, collapseSubdomains([]string{
+		"apps.cluster.example.com",
+		"other.org",
+		"cluster.example.com",
+		"example.com",
+	}))
+	assert.Equal(t, []string{"example.com", "myexample.com"}, collapseSubdomains([]string{"myexample.com", "example.com"}))
+}
@@ -0,0 +1,131 @@
+package discovery
+
+import (
+	"strings"
+)
+
+// systemIdentities are built-in identities that are not confidential, anything prefixed with "system:" is ignored as well.
+var systemIdentities = map[string]struct{}{
+	"kube:admin": {},
+}
+
+// Identities contains the user and group names that were discovered in the must-gather.
+type Identities struct {
+	Users  []string `yaml:"users,omitempty" json:"users,omitempty"`
+	Groups []string `yaml:"groups,omitempty" json:"groups,omitempty"`
+}
+
+// identityExtractor returns the user and group names found in the given resource.
+type identityExtractor func(resource map[string]interface{}) (users []string, groups []string)
+
+type identitySource struct {
+	pattern string
+	lines   bool
+	extract identityExtractor
+}
+
+var identitySources = []identitySource{
+	{pattern: "user.openshift.io/users.yaml", extract: userIdentities},
+	{pattern: "user.openshift.io/users/*.yaml", extract: userIdentities},
+	{pattern: "user.openshift.io/identities.yaml", extract: identityIdentities},
+	{pattern: "user.openshift.io/identities/*.yaml", extract: identityIdentities},
+	{pattern: "user.openshift.io/groups.yaml", extract: groupIdentities},
+	{pattern: "user.openshift.io/groups/*.yaml", extract: groupIdentities},
+	{pattern: "rbac.authorization.k8s.io/clusterrolebindings.yaml", extract: bindingIdentities},
+	{pattern: "rbac.authorization.k8s.io/clusterrolebindings/*.yaml", extract: bindingIdentities},
+	{pattern: "rbac.authorization.k8s.io/rolebindings.yaml", extract: bindingIdentities},
+	{pattern: "rbac.authorization.k8s.io/rolebindings/*.yaml", extract: bindingIdentities},
+	{pattern: "apiserver.openshift.io/apirequestcounts/*.yaml", extract: requestCountIdentities},
+	{pattern: "audit_logs/*/*.log", lines: true, extract: auditEventIdentities},
+	{pattern: "audit_logs/*/*.log.gz", lines: true, extract: auditEventIdentities},
+}
+
+// DiscoverIdentities returns the user and group names that were found in the must-gather under inputPath. They are
+// learned from the User, Identity and Group resources, RBAC binding subjects, API request counts and audit events.
+// System identities, for example "system:admin" or "system:serviceaccount:...", are never returned.
+func DiscoverIdentities(inputPath string) (Identities, error) {
+	groups := map[string]struct{}{}
+	sources := make([]source, len(identitySources))
+	for i, s := range identitySources {
+		extract := s.extract
+		sources[i] = source{pattern: s.pattern, lines: s.lines, extract: func(resource map[string]interface{}) []string {
+			u, g := extract(resource)
+			for _, name := range g {
+				if !isSystemIdentity(name) {
+					groups[name] = struct{}{}
+				}
+			}
+			return u
+		}}
+	}
+
+	values, err := walk(inputPath, sources)
+	if err != nil {
+		return Identities{}, err
+	}
+
+	users := map[string]struct{}{}
+	for u := range values {
+		if !isSystemIdentity(u) {
+			users[u] = struct{}{}
+		}
+	}
+	// a name that is used for both a user and a group is treated as a user
+	for u := range users {
+		delete(groups, u)
+	}
+	return Identities{Users: sortedKeys(users), Groups: sortedKeys(groups)}, nil
+}
+
+func isSystemIdentity(name string) bool {
+	if strings.HasPrefix(name, "system:") {
+		return true
+	}
+	_, ok := systemIdentities[name]
+	return ok
+}
+
+func userIdentities(resource map[string]interface{}) ([]string, []string) {
+	return []string{lookup(resource, "metadata", "name")}, lookupStrings(resource, "groups")
+}
+
+func identityIdentities(resource map[string]interface{}) ([]string, []string) {
+	return []string{lookup(resource, "providerUserName"), lookup(resource, "user", "name")}, nil
+}
+
+func groupIdentities(resource map[string]interface{}) ([]string, []string) {
+	return lookupStrings(resource, "users"), []string{lookup(resource, "metadata", "name")}
+}
+
+func bindingIdentities(resource map[string]interface{}) ([]string, []string) {
+	subjects, _ := resource["subjects"].([]interface{})
+	var users, groups []string
+	for _, s := range subjects {
+		subject, ok := s.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		switch lookup(subject, "kind") {
+		case "User":
+			users = append(users, lookup(subject, "name"))
+		case "Group":
+			groups = append(groups, lookup(subject, "name"))
+		}
+	}
+	return users, groups
+}
+
+func requestCountIdentities(resource map[string]interface{}) ([]string, []string) {
+	return collectStrings(resource["status"], "username"), nil
+}
+
+func auditEventIdentities(resource map[string]interface{}) ([]string, []string) {
+	users := []string{lookup(resource, "user", "username")}
+	groups := lookupStrings(resource, "user", "groups")
+	// impersonated requests carry the identity of the impersonated user as well
+	if impersonated := lookup(resource, "impersonatedUser", "username"); impersonated != "" {
+		users = append(users, impersonated)
+		groups = append(groups, lookupStrings(resource, "impersonatedUser", "groups")...)
+	}
+	return users, groups
+}
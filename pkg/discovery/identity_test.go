@@ -0,0 +1,87 @@
+package discovery
+
+import (
+	"bytes"
+	"compress/gzip"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDiscoverIdentities(t *testing.T) {
+	var gzipped bytes.Buffer
+	gz := gzip.NewWriter(&gzipped)
+	_, err := gz.Write([]byte(`{"kind":"Event","user":{"username":"carol","groups":["ops","system:authenticated"]}}` + "\n"))
+	require.NoError(t, err)
+	require.NoError(t, gz.Close())
+
+	dir := writeTestFiles(t, map[string]string{
+		"image/cluster-scoped-resources/user.openshift.io/users.yaml": `apiVersion: user.openshift.io/v1
+kind: UserList
+items:
+- apiVersion: user.openshift.io/v1
+  kind: User
+  metadata:
+    name: alice
+  groups:
+  - developers
+`,
+		"image/cluster-scoped-resources/user.openshift.io/identities/htpasswd:bob.yaml": `apiVersion: user.openshift.io/v1
+kind: Identity
+providerName: htpasswd
+providerUserName: bob
+user:
+  name: bob
+`,
+		"image/cluster-scoped-resources/user.openshift.io/groups.yaml": `apiVersion: user.openshift.io/v1
+kind: Group
+metadata:
+  name: admins
+users:
+- alice
+- dave
+`,
+		"image/namespaces/my-app/rbac.authorization.k8s.io/rolebindings.yaml": `apiVersion: rbac.authorization.k8s.io/v1
+kind: RoleBindingList
+items:
+- apiVersion: rbac.authorization.k8s.io/v1
+  kind: RoleBinding
+  subjects:
+  - kind: User
+    name: erin
+  - kind: Group
+    name: testers
+  - kind: ServiceAccount
+    name: default
+  - kind: User
+    name: system:admin
+`,
+		"image/audit_logs/kube-apiserver/master-0-audit.log": `{"kind":"Event","user":{"username":"frank","groups":["system:authenticated:oauth"]},"impersonatedUser":{"username":"kube:admin"}}
+{"kind":"Event","user":{"username":"system:serviceaccount:ns:sa"}}
+not json
+` + `{"kind":"Event","user":{"username":"grace"},"requestObject":{"data":"` + strings.Repeat("a", 128*1024) + `"}}`,
+		"image/audit_logs/kube-apiserver/master-1-audit-2021.log.gz": gzipped.String(),
+	})
+
+	identities, err := DiscoverIdentities(dir)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"alice", "bob", "carol", "dave", "erin", "frank", "grace"}, identities.Users)
+	assert.Equal(t, []string{"admins", "developers", "ops", "testers"}, identities.Groups)
+}
+
+func TestDiscoverIdentitiesSkipsTooLongAuditLines(t *testing.T) {
+	defer func(size int) { maxJSONLineSize = size }(maxJSONLineSize)
+	maxJSONLineSize = 1024
+
+	dir := writeTestFiles(t, map[string]string{
+		"image/audit_logs/kube-apiserver/master-0-audit.log": `{"kind":"Event","user":{"username":"alice"}}
+{"kind":"Event","user":{"username":"bob"},"requestObject":{"data":"` + strings.Repeat("a", 64*1024) + `"}}
+{"kind":"Event","user":{"username":"carol"}}`,
+	})
+
+	identities, err := DiscoverIdentities(dir)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"alice", "carol"}, identities.Users)
+}
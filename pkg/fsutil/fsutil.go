@@ -8,6 +8,7 @@ import (
 	"path/filepath"
 	"runtime"
 	"strconv"
+	"strings"
 	"syscall"
 
 	"k8s.io/klog/v2"
@@ -53,12 +54,9 @@ func Relink(readPath string, writePath string, readPathStat os.FileInfo) error {
 }
 
 func EnsureInputOutputPath(inputPath string, outputPath string, deleteOutputFolder bool) error {
-	_, err := os.Stat(inputPath)
+	err := EnsureInputPath(inputPath)
 	if err != nil {
-		if os.IsNotExist(err) {
-			return fmt.Errorf("input folder does not exist: %w", err)
-		}
-		return fmt.Errorf("failed to stat input folder: %w", err)
+		return err
 	}
 
 	err = ensureOutputPath(outputPath, deleteOutputFolder, inputPath)
@@ -69,6 +67,18 @@ func EnsureInputOutputPath(inputPath string, outputPath string, deleteOutputFold
 	return nil
 }
 
+// EnsureInputPath returns an error if the input folder can't be found, without touching the output folder.
+func EnsureInputPath(inputPath string) error {
+	_, err := os.Stat(inputPath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return fmt.Errorf("input folder does not exist: %w", err)
+		}
+		return fmt.Errorf("failed to stat input folder: %w", err)
+	}
+	return nil
+}
+
 func CreateNonConflictingFile(outputFilePath string, inputFileInfo os.FileInfo) (*os.File, error) {
 	// we need to assess whether the file exists already to ensure we don't overwrite existing obfuscated data.
 	// that can happen while obfuscating file names and their paths.
@@ -183,6 +193,67 @@ func ensureOutputPath(path string, deleteIfExists bool, inputFolderPath string)
 	return MkdirAllWithChown(path, inputFolderPath)
 }
 
+// IsWithin returns true if the path is the folder itself or any path below it. Symbolic links are resolved on both paths,
+// which don't need to exist yet.
+func IsWithin(path string, folder string) (bool, error) {
+	resolvedPath, err := resolvePath(path)
+	if err != nil {
+		return false, err
+	}
+	resolvedFolder, err := resolvePath(folder)
+	if err != nil {
+		return false, err
+	}
+
+	rel, err := filepath.Rel(resolvedFolder, resolvedPath)
+	if err != nil {
+		return false, nil
+	}
+	return rel == "." || (rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))), nil
+}
+
+// resolvePath returns the absolute path with all symbolic links resolved, including those whose target does not exist
+// yet. The part of the path that does not exist yet is appended to its closest existing parent as-is.
+func resolvePath(path string) (string, error) {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to determine absolute path of %s: %w", path, err)
+	}
+
+	missing := ""
+	existing := abs
+	for {
+		resolved, err := filepath.EvalSymlinks(existing)
+		if err == nil {
+			return filepath.Join(resolved, missing), nil
+		}
+		if !os.IsNotExist(err) {
+			return "", fmt.Errorf("failed to resolve %s: %w", existing, err)
+		}
+		// writing into a dangling symbolic link creates its target
+		if info, err := os.Lstat(existing); err == nil && info.Mode()&os.ModeSymlink != 0 {
+			target, err := os.Readlink(existing)
+			if err != nil {
+				return "", fmt.Errorf("failed to resolve %s: %w", existing, err)
+			}
+			if !filepath.IsAbs(target) {
+				target = filepath.Join(filepath.Dir(existing), target)
+			}
+			resolved, err := resolvePath(target)
+			if err != nil {
+				return "", err
+			}
+			return filepath.Join(resolved, missing), nil
+		}
+		parent := filepath.Dir(existing)
+		if parent == existing {
+			return abs, nil
+		}
+		missing = filepath.Join(filepath.Base(existing), missing)
+		existing = parent
+	}
+}
+
 func chown(path string, stat fs.FileInfo) error {
 	// there is no equivalent to chown in windows, thus we ignore it explicitly
 	if runtime.GOOS != "windows" {
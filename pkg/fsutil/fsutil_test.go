@@ -140,3 +140,37 @@ func TestSymlinkDetection(t *testing.T) {
 	require.NoError(t, err)
 	assert.Falsef(t, IsSymbolicLink(info), "%s should not be a symbolic link", info.Name())
 }
+
+func TestIsWithin(t *testing.T) {
+	testDir := t.TempDir()
+	outputDir := filepath.Join(testDir, "output")
+	require.NoError(t, os.Mkdir(outputDir, 0700))
+	require.NoError(t, os.Symlink(outputDir, filepath.Join(testDir, "link")))
+	require.NoError(t, os.Symlink(filepath.Join("output", "report.yaml"), filepath.Join(testDir, "dangling")))
+
+	for _, tc := range []struct {
+		name   string
+		path   string
+		within bool
+	}{
+		{name: "same folder", path: outputDir, within: true},
+		{name: "sub folder", path: filepath.Join(outputDir, "reports"), within: true},
+		{name: "not existing sub folder", path: filepath.Join(outputDir, "a", "b"), within: true},
+		{name: "unclean path", path: filepath.Join(testDir, "other", "..", "output"), within: true},
+		{name: "symbolic link", path: filepath.Join(testDir, "link", "reports"), within: true},
+		{name: "dangling symbolic link", path: filepath.Join(testDir, "dangling"), within: true},
+		{name: "parent", path: testDir, within: false},
+		{name: "sibling", path: filepath.Join(testDir, "output-reports"), within: false},
+		{name: "sibling with dots", path: filepath.Join(testDir, "..output"), within: false},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			within, err := IsWithin(tc.path, outputDir)
+			require.NoError(t, err)
+			assert.Equal(t, tc.within, within)
+		})
+	}
+
+	within, err := IsWithin(filepath.Join(outputDir, "report"), filepath.Join(testDir, "link"))
+	require.NoError(t, err)
+	assert.True(t, within, "folder should be resolved as well")
+}
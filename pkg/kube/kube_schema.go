@@ -4,6 +4,7 @@ package kube
 
 type Metadata struct {
 	Namespace string `yaml:"namespace" json:"namespace"`
+	Name      string `yaml:"name" json:"name"`
 }
 
 type Resource struct {
@@ -72,6 +72,7 @@ items:
 						Kind:       "Secret",
 						Metadata: Metadata{
 							Namespace: "kube-system",
+							Name:      "first",
 						},
 					},
 					{
@@ -79,6 +80,7 @@ items:
 						Kind:       "Secret",
 						Metadata: Metadata{
 							Namespace: "kube-system",
+							Name:      "second",
 						},
 					},
 				},
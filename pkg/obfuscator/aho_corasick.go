@@ -0,0 +1,136 @@
+package obfuscator
+
+import "sort"
+
+// ahoCorasick finds all occurrences of many patterns in a single pass over the input, which is independent of the
+// number of patterns. See https://en.wikipedia.org/wiki/Aho%E2%80%93Corasick_algorithm for details.
+type ahoCorasick struct {
+	nodes    []acNode
+	patterns []string
+	// foldCase matches ASCII letters regardless of their case
+	foldCase bool
+}
+
+type acNode struct {
+	children map[byte]int
+	// fail points to the node of the longest proper suffix that is also a prefix of a pattern
+	fail int
+	// pattern is the index of the pattern that ends in this node, -1 otherwise
+	pattern int
+	// output points to the next node on the fail chain that ends a pattern, -1 otherwise
+	output int
+}
+
+// acMatch is an occurrence of patterns[pattern] at input[start:end].
+type acMatch struct {
+	start   int
+	end     int
+	pattern int
+}
+
+func newAhoCorasick(patterns []string, foldCase bool) *ahoCorasick {
+	a := &ahoCorasick{patterns: patterns, foldCase: foldCase}
+	a.nodes = append(a.nodes, acNode{children: map[byte]int{}, pattern: -1, output: -1})
+	for i, p := range patterns {
+		current := 0
+		for j := 0; j < len(p); j++ {
+			c := a.fold(p[j])
+			next, ok := a.nodes[current].children[c]
+			if !ok {
+				next = len(a.nodes)
+				a.nodes = append(a.nodes, acNode{children: map[byte]int{}, pattern: -1, output: -1})
+				a.nodes[current].children[c] = next
+			}
+			current = next
+		}
+		// duplicate patterns are matched as the first one
+		if a.nodes[current].pattern < 0 {
+			a.nodes[current].pattern = i
+		}
+	}
+
+	// the fail links are computed breadth-first, as they always point to a node that is closer to the root
+	queue := make([]int, 0, len(a.nodes))
+	for _, child := range a.nodes[0].children {
+		queue = append(queue, child)
+	}
+	for len(queue) > 0 {
+		current := queue[0]
+		queue = queue[1:]
+		for c, child := range a.nodes[current].children {
+			fail := a.nodes[current].fail
+			for {
+				if next, ok := a.nodes[fail].children[c]; ok {
+					a.nodes[child].fail = next
+					break
+				}
+				if fail == 0 {
+					break
+				}
+				fail = a.nodes[fail].fail
+			}
+			failNode := a.nodes[a.nodes[child].fail]
+			if failNode.pattern >= 0 {
+				a.nodes[child].output = a.nodes[child].fail
+			} else {
+				a.nodes[child].output = failNode.output
+			}
+			queue = append(queue, child)
+		}
+	}
+	return a
+}
+
+func (a *ahoCorasick) fold(c byte) byte {
+	if a.foldCase && c >= 'A' && c <= 'Z' {
+		return c + ('a' - 'A')
+	}
+	return c
+}
+
+// findAll returns all, possibly overlapping, matches in the input.
+func (a *ahoCorasick) findAll(input string) []acMatch {
+	var matches []acMatch
+	current := 0
+	for i := 0; i < len(input); i++ {
+		c := a.fold(input[i])
+		for {
+			if next, ok := a.nodes[current].children[c]; ok {
+				current = next
+				break
+			}
+			if current == 0 {
+				break
+			}
+			current = a.nodes[current].fail
+		}
+		for n := current; n > 0; n = a.nodes[n].output {
+			if p := a.nodes[n].pattern; p >= 0 {
+				matches = append(matches, acMatch{start: i + 1 - len(a.patterns[p]), end: i + 1, pattern: p})
+			}
+		}
+	}
+	return matches
+}
+
+// findLeftmostLongest returns the non-overlapping matches that are accepted, preferring the match that starts first and
+// then the longest one. Matches that are not accepted don't block shorter or later matches.
+func (a *ahoCorasick) findLeftmostLongest(input string, accept func(m acMatch) bool) []acMatch {
+	matches := a.findAll(input)
+	sort.Slice(matches, func(i, j int) bool {
+		if matches[i].start == matches[j].start {
+			return matches[i].end > matches[j].end
+		}
+		return matches[i].start < matches[j].start
+	})
+
+	var selected []acMatch
+	last := 0
+	for _, m := range matches {
+		if m.start >= last && accept(m) {
+			selected = append(selected, m)
+			last = m.end
+		}
+	}
+	return selected
+}
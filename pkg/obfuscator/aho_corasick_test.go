@@ -0,0 +1,65 @@
+package obfuscator
+
+import (
+	"math/rand"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAhoCorasickFindAll(t *testing.T) {
+	a := newAhoCorasick([]string{"he", "she", "his", "hers"}, false)
+	assert.Equal(t, []acMatch{
+		{start: 1, end: 4, pattern: 1},
+		{start: 2, end: 4, pattern: 0},
+		{start: 2, end: 6, pattern: 3},
+	}, a.findAll("ushers"))
+}
+
+func TestAhoCorasickFoldCase(t *testing.T) {
+	a := newAhoCorasick([]string{"Hello"}, true)
+	assert.Equal(t, []acMatch{{start: 0, end: 5, pattern: 0}, {start: 6, end: 11, pattern: 0}}, a.findAll("hELLO HELLO"))
+}
+
+func TestAhoCorasickMatchesBruteForce(t *testing.T) {
+	r := rand.New(rand.NewSource(42))
+	randomString := func(maxLen int) string {
+		var sb strings.Builder
+		for i := 0; i < 1+r.Intn(maxLen); i++ {
+			sb.WriteByte("abc"[r.Intn(3)])
+		}
+		return sb.String()
+	}
+
+	for run := 0; run < 200; run++ {
+		var patterns []string
+		for i := 0; i < 1+r.Intn(8); i++ {
+			patterns = append(patterns, randomString(4))
+		}
+		input := randomString(40)
+
+		var expected []acMatch
+		for start := 0; start < len(input); start++ {
+			seen := map[string]bool{}
+			for i, p := range patterns {
+				if !seen[p] && strings.HasPrefix(input[start:], p) {
+					expected = append(expected, acMatch{start: start, end: start + len(p), pattern: i})
+				}
+				seen[p] = true
+			}
+		}
+
+		actual := newAhoCorasick(patterns, false).findAll(input)
+		for _, m := range [][]acMatch{expected, actual} {
+			sort.Slice(m, func(i, j int) bool {
+				if m[i].start == m[j].start {
+					return m[i].end < m[j].end
+				}
+				return m[i].start < m[j].start
+			})
+		}
+		assert.Equal(t, expected, actual, "patterns %v in %s", patterns, input)
+	}
+}
@@ -0,0 +1,13 @@
+package obfuscator
+
+// chainObfuscator hides the matches of the wrapped obfuscator from the MultiObfuscator, so that it runs on the output of
+// the preceding obfuscators instead of the original input.
+type chainObfuscator struct {
+	ReportingObfuscator
+}
+
+// NewChainObfuscator wraps the given obfuscator, so that it also matches the replacements of the obfuscators defined
+// before it, for example a regex that matches the replacements of a keyword.
+func NewChainObfuscator(obfuscator ReportingObfuscator) ReportingObfuscator {
+	return &chainObfuscator{ReportingObfuscator: obfuscator}
+}
@@ -0,0 +1,120 @@
+package obfuscator
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/openshift/must-gather-clean/pkg/schema"
+)
+
+const (
+	staticClusterIdentifierReplacement = "x-clusterid-x"
+	// the template is kept DNS-label safe, since the identifiers are very often part of resource and machine names
+	consistentClusterIdentifierTemplate           = "x-clusterid-%010d-x"
+	maximumSupportedObfuscationClusterIdentifiers = 9999999999
+)
+
+// every pattern must contain exactly one capture group that matches the identifier that should be replaced
+var (
+	// AWSAccountIDPattern finds the account ID in an ARN, for example arn:aws:iam::123456789012:user/name
+	AWSAccountIDPattern = regexp.MustCompile(`arn:aws[a-zA-Z-]*:[a-zA-Z0-9-]*:[a-zA-Z0-9-]*:([0-9]{12}):`)
+	// AzureSubscriptionIDPattern finds the subscription GUID in an Azure resource ID, for example /subscriptions/<guid>/resourceGroups/...
+	AzureSubscriptionIDPattern = regexp.MustCompile(`/subscriptions/([0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12})`)
+)
+
+type clusterIdentifiersObfuscator struct {
+	ReplacementTracker
+	patterns      []*regexp.Regexp
+	obfsGenerator generator
+}
+
+func (c *clusterIdentifiersObfuscator) Path(s string) string {
+	return applySpans(s, c.spans(s))
+}
+
+func (c *clusterIdentifiersObfuscator) Contents(s string) string {
+	return applySpans(s, c.spans(s))
+}
+
+func (c *clusterIdentifiersObfuscator) PathSpans(s string) []Span {
+	return c.spans(s)
+}
+
+func (c *clusterIdentifiersObfuscator) ContentsSpans(s string) []Span {
+	return c.spans(s)
+}
+
+// spans returns the identifiers found by all patterns, a match of an earlier pattern takes precedence.
+func (c *clusterIdentifiersObfuscator) spans(input string) []Span {
+	var spans [][]Span
+	for _, p := range c.patterns {
+		var patternSpans []Span
+		for _, m := range p.FindAllStringSubmatchIndex(input, -1) {
+			start, end := m[2], m[3]
+			if !isClusterIdentifierBoundary(input, start, end) {
+				continue
+			}
+			identifier := input[start:end]
+			patternSpans = append(patternSpans, Span{Start: start, End: end, Replace: func() string {
+				return c.obfsGenerator.generateReplacement(strings.ToLower(identifier), identifier, 1, c.ReplacementTracker)
+			}})
+		}
+		spans = append(spans, patternSpans)
+	}
+	return mergeSpans(spans...)
+}
+
+func isAlphanumeric(c byte) bool {
+	return (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || (c >= '0' && c <= '9')
+}
+
+// isClusterIdentifierBoundary ensures that only whole identifiers are matched, so the infrastructure name "ci-abc" won't
+// replace parts of "ci-abcde". Dashes and dots are boundaries, since the identifiers are the prefix of many resource names.
+func isClusterIdentifierBoundary(s string, start int, end int) bool {
+	if start > 0 && isAlphanumeric(s[start-1]) {
+		return false
+	}
+	return end == len(s) || !isAlphanumeric(s[end])
+}
+
+// NewClusterIdentifiersObfuscator returns an obfuscator that replaces the given cluster and cloud identifiers, for example
+// the cluster ID or infrastructure name. AWS account IDs in ARNs and Azure subscription IDs in resource IDs are always
+// detected, even if they were not supplied upfront.
+func NewClusterIdentifiersObfuscator(identifiers []string, replacementType schema.ObfuscateReplacementType, format ReplacementFormat, tracker ReplacementTracker) (ReportingObfuscator, error) {
+	err := format.validate(maximumSupportedObfuscationClusterIdentifiers, func(s string) bool {
+		probe, err := NewClusterIdentifiersObfuscator(identifiers, replacementType, ReplacementFormat{}, NewSimpleTracker())
+		return err == nil && probe.Contents(s) != s
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var patterns []*regexp.Regexp
+	if len(identifiers) > 0 {
+		sorted := make([]string, len(identifiers))
+		for i, id := range identifiers {
+			sorted[i] = regexp.QuoteMeta(id)
+		}
+		// the alternation matches leftmost-first, so the longest identifier must come first to match most specifically
+		sort.Slice(sorted, func(i, j int) bool {
+			return len(sorted[i]) > len(sorted[j])
+		})
+		p, err := regexp.Compile("(?i)(" + strings.Join(sorted, "|") + ")")
+		if err != nil {
+			return nil, err
+		}
+		patterns = append(patterns, p)
+	}
+	patterns = append(patterns, AWSAccountIDPattern, AzureSubscriptionIDPattern)
+
+	generator, err := newFormattedGenerator(format, consistentClusterIdentifierTemplate, staticClusterIdentifierReplacement, maximumSupportedObfuscationClusterIdentifiers, replacementType)
+	if err != nil {
+		return nil, err
+	}
+	return &clusterIdentifiersObfuscator{
+		ReplacementTracker: tracker,
+		patterns:           patterns,
+		obfsGenerator:      *generator,
+	}, nil
+}
@@ -0,0 +1,113 @@
+package obfuscator
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/openshift/must-gather-clean/pkg/schema"
+)
+
+func TestClusterIdentifiersObfuscatorContents(t *testing.T) {
+	for _, tc := range []struct {
+		name        string
+		identifiers []string
+		input       []string
+		output      []string
+		report      map[string]string
+	}{
+		{
+			name:        "cluster id and infra name",
+			identifiers: []string{"96eb6c40-16e8-4718-ade6-5d7c6f05e120", "ci-ln-5ylibmb-d5d6b-dfvr6"},
+			input: []string{
+				"clusterID: 96eb6c40-16e8-4718-ade6-5d7c6f05e120",
+				"name: ci-ln-5ylibmb-d5d6b-dfvr6-worker-us-east-1b-x8mzn",
+				"kubernetes.io/cluster/ci-ln-5ylibmb-d5d6b-dfvr6: owned",
+			},
+			output: []string{
+				"clusterID: x-clusterid-0000000001-x",
+				"name: x-clusterid-0000000002-x-worker-us-east-1b-x8mzn",
+				"kubernetes.io/cluster/x-clusterid-0000000002-x: owned",
+			},
+			report: map[string]string{
+				"96eb6c40-16e8-4718-ade6-5d7c6f05e120": "x-clusterid-0000000001-x",
+				"ci-ln-5ylibmb-d5d6b-dfvr6":            "x-clusterid-0000000002-x",
+			},
+		},
+		{
+			name:        "longest identifier first",
+			identifiers: []string{"mycluster", "mycluster-abcde"},
+			input:       []string{"mycluster-abcde-master-0 in mycluster"},
+			output:      []string{"x-clusterid-0000000001-x-master-0 in x-clusterid-0000000002-x"},
+			report: map[string]string{
+				"mycluster-abcde": "x-clusterid-0000000001-x",
+				"mycluster":       "x-clusterid-0000000002-x",
+			},
+		},
+		{
+			name:        "case insensitive guids",
+			identifiers: []string{"d38f1e38-4bed-438e-b227-833f997adf6a"},
+			input:       []string{"D38F1E38-4BED-438E-B227-833F997ADF6A and d38f1e38-4bed-438e-b227-833f997adf6a"},
+			output:      []string{"x-clusterid-0000000001-x and x-clusterid-0000000001-x"},
+			report: map[string]string{
+				"D38F1E38-4BED-438E-B227-833F997ADF6A": "x-clusterid-0000000001-x",
+				"d38f1e38-4bed-438e-b227-833f997adf6a": "x-clusterid-0000000001-x",
+			},
+		},
+		{
+			name: "undiscovered aws account and azure subscription",
+			input: []string{
+				"arn:aws:iam::460538899914:user/some-user",
+				"providerID: azure:///subscriptions/01422477-10c4-4a2d-ac1a-6612a5236803/resourceGroups/rg",
+			},
+			output: []string{
+				"arn:aws:iam::x-clusterid-0000000001-x:user/some-user",
+				"providerID: azure:///subscriptions/x-clusterid-0000000002-x/resourceGroups/rg",
+			},
+			report: map[string]string{
+				"460538899914":                         "x-clusterid-0000000001-x",
+				"01422477-10c4-4a2d-ac1a-6612a5236803": "x-clusterid-0000000002-x",
+			},
+		},
+		{
+			name:        "whole identifiers only",
+			identifiers: []string{"ci-abc", "460538899914"},
+			input: []string{
+				"name: ci-abcde-master-0 in xci-abc and ci-abc.example.com",
+				"account 4605388999140 and arn:aws:iam::460538899914:root",
+				"/subscriptions/01422477-10c4-4a2d-ac1a-6612a5236803a",
+			},
+			output: []string{
+				"name: ci-abcde-master-0 in xci-abc and x-clusterid-0000000001-x.example.com",
+				"account 4605388999140 and arn:aws:iam::x-clusterid-0000000002-x:root",
+				"/subscriptions/01422477-10c4-4a2d-ac1a-6612a5236803a",
+			},
+			report: map[string]string{
+				"ci-abc":       "x-clusterid-0000000001-x",
+				"460538899914": "x-clusterid-0000000002-x",
+			},
+		},
+		{
+			name:   "no identifiers",
+			input:  []string{"nothing to see here 123456789012"},
+			output: []string{"nothing to see here 123456789012"},
+			report: map[string]string{},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			o, err := NewClusterIdentifiersObfuscator(tc.identifiers, schema.ObfuscateReplacementTypeConsistent, ReplacementFormat{}, NewSimpleTracker())
+			require.NoError(t, err)
+			for i, line := range tc.input {
+				assert.Equal(t, tc.output[i], o.Contents(line))
+			}
+			assert.Equal(t, tc.report, o.Report().AsMap())
+		})
+	}
+}
+
+func TestClusterIdentifiersObfuscatorStaticPath(t *testing.T) {
+	o, err := NewClusterIdentifiersObfuscator([]string{"infra-id-xyz"}, schema.ObfuscateReplacementTypeStatic, ReplacementFormat{}, NewSimpleTracker())
+	require.NoError(t, err)
+	assert.Equal(t, "machines/x-clusterid-x-worker-a/x-clusterid-x.yaml", o.Path("machines/infra-id-xyz-worker-a/infra-id-xyz.yaml"))
+}
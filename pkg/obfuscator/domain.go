@@ -10,7 +10,7 @@ import (
 )
 
 const (
-	domainPattern                      = `([a-zA-Z0-9\.]*\.)?(%s)`
+	domainPattern                      = `([a-zA-Z0-9\.-]*\.)?(%s)`
 	obfuscatedTemplate                 = "domain%010d"
 	staticDomainReplacement            = "obfuscated.com"
 	maximumSupportedObfuscationDomains = 9999999999
@@ -23,41 +23,75 @@ type domainObfuscator struct {
 }
 
 func (d *domainObfuscator) Path(s string) string {
-	return d.replaceDomains(s)
+	return applySpans(s, d.spans(s))
 }
 
 func (d *domainObfuscator) Contents(s string) string {
-	return d.replaceDomains(s)
+	return applySpans(s, d.spans(s))
 }
 
-func (d *domainObfuscator) replaceDomains(input string) string {
-	output := input
+func (d *domainObfuscator) PathSpans(s string) []Span {
+	return d.spans(s)
+}
+
+func (d *domainObfuscator) ContentsSpans(s string) []Span {
+	return d.spans(s)
+}
+
+// spans returns the matches of all domains, the patterns are sorted so that the most specific domain takes precedence.
+func (d *domainObfuscator) spans(input string) []Span {
+	var spans [][]Span
 	for _, p := range d.domainPatterns {
-		matches := p.FindAllStringSubmatch(output, -1)
-		for _, m := range matches {
-			if len(m) != 3 {
+		var patternSpans []Span
+		for _, m := range p.FindAllStringSubmatchIndex(input, -1) {
+			if !isDomainBoundary(input, m[0], m[1]) {
 				continue
 			}
-			baseDomain := m[2]
-			subDomain := m[1]
-			obfuscatedBaseDomain := d.obfsGenerator.generateReplacement(baseDomain, m[0], 1, d.ReplacementTracker)
-			var replacement string
-			if subDomain != "" {
-				replacement = fmt.Sprintf("%s%s", subDomain, obfuscatedBaseDomain)
-			} else {
-				replacement = obfuscatedBaseDomain
+			original := input[m[0]:m[1]]
+			baseDomain := input[m[4]:m[5]]
+			subDomain := ""
+			if m[2] >= 0 {
+				subDomain = input[m[2]:m[3]]
 			}
-			// TODO(thomas): should just replace that one matching occurrence instead of all
-			output = strings.ReplaceAll(output, m[0], replacement)
+			patternSpans = append(patternSpans, Span{Start: m[0], End: m[1], Replace: func() string {
+				return subDomain + d.obfsGenerator.generateReplacement(baseDomain, original, 1, d.ReplacementTracker)
+			}})
 		}
+		spans = append(spans, patternSpans)
 	}
-	return output
+	return mergeSpans(spans...)
 }
 
-func NewDomainObfuscator(domains []string, replacementType schema.ObfuscateReplacementType, tracker ReplacementTracker) (ReportingObfuscator, error) {
+func isDomainCharacter(c byte) bool {
+	return c == '-' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || (c >= '0' && c <= '9')
+}
+
+// isDomainBoundary ensures that the match is not part of a longer name, for example "example.com" must not match within
+// "myexample.com" or "example.community".
+func isDomainBoundary(s string, start int, end int) bool {
+	if start > 0 && isDomainCharacter(s[start-1]) {
+		return false
+	}
+	return end == len(s) || !isDomainCharacter(s[end]) || s[end] == '-'
+}
+
+func NewDomainObfuscator(domains []string, replacementType schema.ObfuscateReplacementType, format ReplacementFormat, tracker ReplacementTracker) (ReportingObfuscator, error) {
 	if len(domains) == 0 {
 		return nil, fmt.Errorf("no domainNames supplied for the obfuscation type: Domain")
 	}
+	return NewDiscoveredDomainObfuscator(domains, replacementType, format, tracker)
+}
+
+// NewDiscoveredDomainObfuscator returns a domain obfuscator like NewDomainObfuscator, but the domains may be empty, since
+// the auto discovery might not find any. It does not replace anything then.
+func NewDiscoveredDomainObfuscator(domains []string, replacementType schema.ObfuscateReplacementType, format ReplacementFormat, tracker ReplacementTracker) (ReportingObfuscator, error) {
+	err := format.validate(maximumSupportedObfuscationDomains, func(s string) bool {
+		probe, err := NewDomainObfuscator(domains, replacementType, ReplacementFormat{}, NewSimpleTracker())
+		return err == nil && probe.Contents(s) != s
+	})
+	if err != nil {
+		return nil, err
+	}
 	patterns := make([]*regexp.Regexp, len(domains))
 	for i, d := range domains {
 		dd := strings.ReplaceAll(d, ".", "\\.")
@@ -74,7 +108,7 @@ func NewDomainObfuscator(domains []string, replacementType schema.ObfuscateRepla
 	})
 
 	// creating a new generator object
-	generator, err := newGenerator(obfuscatedTemplate, staticDomainReplacement, maximumSupportedObfuscationDomains, replacementType)
+	generator, err := newFormattedGenerator(format, obfuscatedTemplate, staticDomainReplacement, maximumSupportedObfuscationDomains, replacementType)
 	if err != nil {
 		return nil, err
 	}
@@ -28,7 +28,7 @@ func TestDomainObfuscatorContents(t *testing.T) {
 				"received request on domain0000000001",
 				"received request on https://docs.domain0000000002",
 			},
-			report: ReplacementReport{[]Replacement{
+			report: ReplacementReport{Replacements: []Replacement{
 				{Canonical: "openshift.com", ReplacedWith: "domain0000000001", Counter: map[string]uint{
 					"openshift.com": uint(1),
 				}},
@@ -55,7 +55,7 @@ func TestDomainObfuscatorContents(t *testing.T) {
 				"domain0000000002",
 				"beta.domain0000000002",
 			},
-			report: ReplacementReport{[]Replacement{
+			report: ReplacementReport{Replacements: []Replacement{
 				{Canonical: "docs.okd.io", ReplacedWith: "domain0000000001", Counter: map[string]uint{
 					"docs.okd.io": uint(1),
 				}},
@@ -81,7 +81,7 @@ func TestDomainObfuscatorContents(t *testing.T) {
 				"received request on ghi.abc.domain0000000001",
 				"received request on pqr.ghi.abc.domain0000000001",
 			},
-			report: ReplacementReport{[]Replacement{
+			report: ReplacementReport{Replacements: []Replacement{
 				{Canonical: "test.com", ReplacedWith: "domain0000000001",
 					Counter: map[string]uint{
 						"abc.test.com":         uint(1),
@@ -97,7 +97,7 @@ func TestDomainObfuscatorContents(t *testing.T) {
 		},
 	} {
 		t.Run(tc.name, func(t *testing.T) {
-			o, err := NewDomainObfuscator(tc.domains, schema.ObfuscateReplacementTypeConsistent, NewSimpleTracker())
+			o, err := NewDomainObfuscator(tc.domains, schema.ObfuscateReplacementTypeConsistent, ReplacementFormat{}, NewSimpleTracker())
 			require.NoError(t, err)
 			for idx, i := range tc.input {
 				output := o.Contents(i)
@@ -121,7 +121,7 @@ func TestDomainObfuscator_FileName(t *testing.T) {
 			domains: []string{"test.com"},
 			input:   "requests.test.com.log",
 			output:  "requests.domain0000000001.log",
-			report: ReplacementReport{[]Replacement{
+			report: ReplacementReport{Replacements: []Replacement{
 				{Canonical: "test.com", ReplacedWith: "domain0000000001", Counter: map[string]uint{
 					"requests.test.com": uint(1),
 				}},
@@ -132,7 +132,7 @@ func TestDomainObfuscator_FileName(t *testing.T) {
 			domains: []string{"test.com"},
 			input:   "report.test",
 			output:  "report.test",
-			report:  ReplacementReport{[]Replacement{}},
+			report:  ReplacementReport{Replacements: []Replacement{}},
 		},
 		{
 			name: "overlapping domains",
@@ -142,9 +142,9 @@ func TestDomainObfuscator_FileName(t *testing.T) {
 			},
 			input:  "must-gather-output/namespaces/openshift-kube-apiserver/pods/installer-13-master-02.pamoedo-dualstack.qe.devcluster.openshift.com/installer/installer/logs",
 			output: "must-gather-output/namespaces/openshift-kube-apiserver/pods/installer-13-master-02.pamoedo-dualstack.qe.domain0000000001/installer/installer/logs",
-			report: ReplacementReport{[]Replacement{
+			report: ReplacementReport{Replacements: []Replacement{
 				{Canonical: "devcluster.openshift.com", ReplacedWith: "domain0000000001", Counter: map[string]uint{
-					"dualstack.qe.devcluster.openshift.com": uint(1),
+					"installer-13-master-02.pamoedo-dualstack.qe.devcluster.openshift.com": uint(1),
 				}},
 			}},
 		},
@@ -156,9 +156,9 @@ func TestDomainObfuscator_FileName(t *testing.T) {
 			},
 			input:  "must-gather-output/namespaces/openshift-kube-apiserver/pods/installer-13-master-02.pamoedo-dualstack.qe.devcluster.openshift.com/installer/installer/logs",
 			output: "must-gather-output/namespaces/openshift-kube-apiserver/pods/installer-13-master-02.pamoedo-dualstack.qe.domain0000000001/installer/installer/logs",
-			report: ReplacementReport{[]Replacement{
+			report: ReplacementReport{Replacements: []Replacement{
 				{Canonical: "devcluster.openshift.com", ReplacedWith: "domain0000000001", Counter: map[string]uint{
-					"dualstack.qe.devcluster.openshift.com": uint(1),
+					"installer-13-master-02.pamoedo-dualstack.qe.devcluster.openshift.com": uint(1),
 				}},
 			}},
 		},
@@ -171,15 +171,15 @@ func TestDomainObfuscator_FileName(t *testing.T) {
 			},
 			input:  "must-gather-output/namespaces/openshift-kube-apiserver/pods/installer-13-master-02.pamoedo-dualstack.qe.devcluster.openshift.com/installer/installer/logs",
 			output: "must-gather-output/namespaces/openshift-kube-apiserver/pods/installer-13-master-02.pamoedo-dualstack.domain0000000001/installer/installer/logs",
-			report: ReplacementReport{[]Replacement{
+			report: ReplacementReport{Replacements: []Replacement{
 				{Canonical: "qe.devcluster.openshift.com", ReplacedWith: "domain0000000001", Counter: map[string]uint{
-					"dualstack.qe.devcluster.openshift.com": uint(1),
+					"installer-13-master-02.pamoedo-dualstack.qe.devcluster.openshift.com": uint(1),
 				}},
 			}},
 		},
 	} {
 		t.Run(tc.name, func(t *testing.T) {
-			o, err := NewDomainObfuscator(tc.domains, schema.ObfuscateReplacementTypeConsistent, NewSimpleTracker())
+			o, err := NewDomainObfuscator(tc.domains, schema.ObfuscateReplacementTypeConsistent, ReplacementFormat{}, NewSimpleTracker())
 			require.NoError(t, err)
 			output := o.Path(tc.input)
 			assert.Equal(t, tc.output, output)
@@ -189,13 +189,13 @@ func TestDomainObfuscator_FileName(t *testing.T) {
 }
 
 func TestBadDomainInput(t *testing.T) {
-	_, err := NewDomainObfuscator([]string{"[mustgather.com"}, schema.ObfuscateReplacementTypeConsistent, NewSimpleTracker())
+	_, err := NewDomainObfuscator([]string{"[mustgather.com"}, schema.ObfuscateReplacementTypeConsistent, ReplacementFormat{}, NewSimpleTracker())
 	assert.Error(t, err)
 	assert.Contains(t, err.Error(), "failed to generate regex")
 }
 
 func TestNoDomainInput(t *testing.T) {
-	_, err := NewDomainObfuscator([]string{}, schema.ObfuscateReplacementTypeConsistent, NewSimpleTracker())
+	_, err := NewDomainObfuscator([]string{}, schema.ObfuscateReplacementTypeConsistent, ReplacementFormat{}, NewSimpleTracker())
 	assert.Error(t, err)
 	assert.Contains(t, err.Error(), "no domainNames supplied for the obfuscation type: Domain")
 }
@@ -214,7 +214,7 @@ func TestDomainObfuscationStatic(t *testing.T) {
 			domains: []string{"test.com"},
 			input:   []string{"requests.test.com.log"},
 			output:  []string{"requests." + staticDomainReplacement + ".log"},
-			report: ReplacementReport{[]Replacement{
+			report: ReplacementReport{Replacements: []Replacement{
 				{Canonical: "test.com", ReplacedWith: staticDomainReplacement,
 					Counter: map[string]uint{
 						"requests.test.com": uint(1),
@@ -226,28 +226,27 @@ func TestDomainObfuscationStatic(t *testing.T) {
 			domains: []string{"test.com"},
 			input:   []string{"report.test"},
 			output:  []string{"report.test"},
-			report:  ReplacementReport{[]Replacement{}},
+			report:  ReplacementReport{Replacements: []Replacement{}},
 		},
 		{
-			name:    "Multiple-Matching Domains",
+			name:    "Only whole domains match",
 			domains: []string{"test.com"},
 			input: []string{
 				"The first domain is report.test.com and the second domain is example-test.com",
 			},
 			output: []string{
-				"The first domain is report." + staticDomainReplacement + " and the second domain is example-" + staticDomainReplacement,
+				"The first domain is report." + staticDomainReplacement + " and the second domain is example-test.com",
 			},
-			report: ReplacementReport{[]Replacement{
+			report: ReplacementReport{Replacements: []Replacement{
 				{Canonical: "test.com", ReplacedWith: staticDomainReplacement,
 					Counter: map[string]uint{
 						"report.test.com": uint(1),
-						"test.com":        uint(1),
 					}},
 			}},
 		},
 	} {
 		t.Run(tc.name, func(t *testing.T) {
-			o, err := NewDomainObfuscator(tc.domains, schema.ObfuscateReplacementTypeStatic, NewSimpleTracker())
+			o, err := NewDomainObfuscator(tc.domains, schema.ObfuscateReplacementTypeStatic, ReplacementFormat{}, NewSimpleTracker())
 			require.NoError(t, err)
 			for idx, i := range tc.input {
 				output := o.Contents(i)
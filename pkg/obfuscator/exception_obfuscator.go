@@ -0,0 +1,166 @@
+package obfuscator
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"sync"
+
+	"github.com/openshift/must-gather-clean/pkg/schema"
+)
+
+// maximumExceptionsPerLine bounds the number of exceptions searched for in a single input.
+const maximumExceptionsPerLine = 0xFFFD
+
+// exceptionObfuscator drops all matches of the wrapped obfuscator that are within an exception.
+type exceptionObfuscator struct {
+	ReportingObfuscator
+	spanObfuscator SpanObfuscator
+	pattern        *regexp.Regexp
+	lock           sync.Mutex
+	skipped        map[string]uint
+}
+
+func (e *exceptionObfuscator) Path(s string) string {
+	return applySpans(s, e.PathSpans(s))
+}
+
+func (e *exceptionObfuscator) Contents(s string) string {
+	return applySpans(s, e.ContentsSpans(s))
+}
+
+func (e *exceptionObfuscator) PathSpans(s string) []Span {
+	return e.withoutExceptions(s, e.spanObfuscator.PathSpans(s))
+}
+
+func (e *exceptionObfuscator) ContentsSpans(s string) []Span {
+	return e.withoutExceptions(s, e.spanObfuscator.ContentsSpans(s))
+}
+
+func (e *exceptionObfuscator) Report() ReplacementReport {
+	report := e.ReportingObfuscator.Report()
+
+	e.lock.Lock()
+	defer e.lock.Unlock()
+	if len(e.skipped) > 0 {
+		report.Skipped = map[string]uint{}
+		for s, count := range e.skipped {
+			report.Skipped[s] = count
+		}
+	}
+	return report
+}
+
+// withoutExceptions drops the spans that are within an exception, only the exceptions that actually prevented a
+// replacement are counted as skipped. A span that only contains or partially overlaps an exception is still replaced,
+// otherwise an excepted word would keep every value containing it in cleartext.
+func (e *exceptionObfuscator) withoutExceptions(input string, spans []Span) []Span {
+	if len(spans) == 0 {
+		return spans
+	}
+	exceptions := e.find(input)
+	if len(exceptions) == 0 {
+		return spans
+	}
+
+	var filtered []Span
+	var dropped []Span
+	for _, s := range spans {
+		if withinAny(exceptions, s) {
+			dropped = append(dropped, s)
+		} else {
+			filtered = append(filtered, s)
+		}
+	}
+	if len(dropped) == 0 {
+		return filtered
+	}
+
+	e.lock.Lock()
+	defer e.lock.Unlock()
+	for _, ex := range exceptions {
+		if containsAny(ex, dropped) {
+			e.skipped[input[ex.Start:ex.End]]++
+		}
+	}
+	return filtered
+}
+
+// find returns the non-empty exceptions in the input.
+func (e *exceptionObfuscator) find(input string) []Span {
+	var exceptions []Span
+	for _, m := range e.pattern.FindAllStringIndex(input, maximumExceptionsPerLine) {
+		// empty matches of a regex exception have nothing to protect
+		if m[0] == m[1] {
+			continue
+		}
+		exceptions = append(exceptions, Span{Start: m[0], End: m[1]})
+	}
+	return exceptions
+}
+
+// NewExceptionObfuscator wraps the given obfuscator, so that it won't obfuscate any of the given exceptions. If there are
+// no exceptions the obfuscator is returned as-is. The obfuscator must report its matches, so they can be compared with
+// the exceptions.
+func NewExceptionObfuscator(exceptions []schema.ObfuscateExceptionsElem, obfuscator ReportingObfuscator) (ReportingObfuscator, error) {
+	if len(exceptions) == 0 {
+		return obfuscator, nil
+	}
+
+	var patterns []string
+	for _, e := range exceptions {
+		switch {
+		case e.Literal != nil && e.Regex != nil:
+			return nil, fmt.Errorf("exception must either define a literal or a regex, not both: '%s' and '%s'", *e.Literal, *e.Regex)
+		case e.Literal != nil && *e.Literal != "":
+			patterns = append(patterns, regexp.QuoteMeta(*e.Literal))
+		case e.Regex != nil && *e.Regex != "":
+			if _, err := regexp.Compile(*e.Regex); err != nil {
+				return nil, fmt.Errorf("exception regex %s is invalid: %w", *e.Regex, err)
+			}
+			patterns = append(patterns, *e.Regex)
+		default:
+			return nil, fmt.Errorf("exception must define a non-empty literal or regex")
+		}
+	}
+
+	pattern, err := regexp.Compile("(?:" + strings.Join(patterns, ")|(?:") + ")")
+	if err != nil {
+		return nil, err
+	}
+	so, ok := obfuscator.(SpanObfuscator)
+	if !ok {
+		return nil, fmt.Errorf("exceptions are not supported by obfuscators that don't report their matches")
+	}
+	return &exceptionObfuscator{
+		ReportingObfuscator: obfuscator,
+		spanObfuscator:      so,
+		pattern:             pattern,
+		skipped:             map[string]uint{},
+	}, nil
+}
+
+// within returns true if the span lies entirely within the exception.
+func within(s Span, exception Span) bool {
+	return exception.Start <= s.Start && s.End <= exception.End
+}
+
+// withinAny returns true if the span is within any of the exceptions.
+func withinAny(exceptions []Span, s Span) bool {
+	for _, ex := range exceptions {
+		if within(s, ex) {
+			return true
+		}
+	}
+	return false
+}
+
+// containsAny returns true if any of the spans is within the exception.
+func containsAny(exception Span, spans []Span) bool {
+	for _, s := range spans {
+		if within(s, exception) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,138 @@
+package obfuscator
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/openshift/must-gather-clean/pkg/schema"
+)
+
+func literalException(s string) schema.ObfuscateExceptionsElem {
+	return schema.ObfuscateExceptionsElem{Literal: &s}
+}
+
+func regexException(s string) schema.ObfuscateExceptionsElem {
+	return schema.ObfuscateExceptionsElem{Regex: &s}
+}
+
+func TestExceptionObfuscator(t *testing.T) {
+	for _, tc := range []struct {
+		name       string
+		exceptions []schema.ObfuscateExceptionsElem
+		input      string
+		output     string
+		skipped    map[string]uint
+	}{
+		{
+			name:       "no exceptions",
+			exceptions: nil,
+			input:      "pulling quay.io/openshift from registry.example.com",
+			output:     "pulling xxxxxxx/openshift from xxxxxxxxxxxxxxxxxxxx",
+		},
+		{
+			name:       "literal",
+			exceptions: []schema.ObfuscateExceptionsElem{literalException("quay.io")},
+			input:      "pulling quay.io/openshift from registry.example.com and quay.io",
+			output:     "pulling quay.io/openshift from xxxxxxxxxxxxxxxxxxxx and quay.io",
+			skipped:    map[string]uint{"quay.io": 2},
+		},
+		{
+			name:       "regex",
+			exceptions: []schema.ObfuscateExceptionsElem{regexException(`[a-z.]+\.svc`)},
+			input:      "calling kubernetes.default.svc and api.example.com",
+			output:     "calling kubernetes.default.svc and xxxxxxxxxxxxxxx",
+			skipped:    map[string]uint{"kubernetes.default.svc": 1},
+		},
+		{
+			name:       "literal and regex",
+			exceptions: []schema.ObfuscateExceptionsElem{literalException("quay.io"), regexException(`[a-z.]+\.svc`)},
+			input:      "quay.io kubernetes.default.svc api.example.com",
+			output:     "quay.io kubernetes.default.svc xxxxxxxxxxxxxxx",
+			skipped:    map[string]uint{"quay.io": 1, "kubernetes.default.svc": 1},
+		},
+		{
+			name:       "literal with regex characters",
+			exceptions: []schema.ObfuscateExceptionsElem{literalException("a.b.io")},
+			input:      "a.b.io axb.io",
+			output:     "a.b.io xxxxxx",
+			skipped:    map[string]uint{"a.b.io": 1},
+		},
+		{
+			name:       "exception without a match",
+			exceptions: []schema.ObfuscateExceptionsElem{literalException("openshift"), literalException("quay.io")},
+			input:      "pulling quay.io/openshift from registry.example.com",
+			output:     "pulling quay.io/openshift from xxxxxxxxxxxxxxxxxxxx",
+			skipped:    map[string]uint{"quay.io": 1},
+		},
+		{
+			name:       "exception within a match",
+			exceptions: []schema.ObfuscateExceptionsElem{literalException("example")},
+			input:      "registry.example.com and example",
+			output:     "xxxxxxxxxxxxxxxxxxxx and example",
+		},
+		{
+			name:       "exception overlapping a match",
+			exceptions: []schema.ObfuscateExceptionsElem{literalException("registry.example")},
+			input:      "registry.example.com",
+			output:     "xxxxxxxxxxxxxxxxxxxx",
+		},
+		{
+			name:       "match within an exception",
+			exceptions: []schema.ObfuscateExceptionsElem{literalException("see registry.example.com for details")},
+			input:      "see registry.example.com for details, not registry.example.com",
+			output:     "see registry.example.com for details, not xxxxxxxxxxxxxxxxxxxx",
+			skipped:    map[string]uint{"see registry.example.com for details": 1},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			regex, err := NewRegexObfuscator(`[a-z.]+\.(com|io|svc)`, nil, schema.ObfuscateReplacementTypeStatic, ReplacementFormat{}, NewSimpleTracker())
+			require.NoError(t, err)
+			o, err := NewExceptionObfuscator(tc.exceptions, regex)
+			require.NoError(t, err)
+			assert.Equal(t, tc.output, o.Contents(tc.input))
+			assert.Equal(t, tc.output, o.Path(tc.input))
+			for k := range tc.skipped {
+				tc.skipped[k] *= 2
+			}
+			assert.Equal(t, tc.skipped, o.Report().Skipped)
+		})
+	}
+}
+
+func TestExceptionObfuscatorWithDomains(t *testing.T) {
+	domain, err := NewDomainObfuscator([]string{"example.com"}, schema.ObfuscateReplacementTypeConsistent, ReplacementFormat{}, NewSimpleTracker())
+	require.NoError(t, err)
+	o, err := NewExceptionObfuscator([]schema.ObfuscateExceptionsElem{literalException("public.example.com")}, domain)
+	require.NoError(t, err)
+
+	assert.Equal(t, "public.example.com and api.domain0000000001", o.Contents("public.example.com and api.example.com"))
+	assert.Equal(t, map[string]uint{"public.example.com": 1}, o.Report().Skipped)
+	assert.Equal(t, map[string]string{"api.example.com": "domain0000000001"}, o.Report().AsMap())
+}
+
+func TestExceptionObfuscatorInvalid(t *testing.T) {
+	literal := "quay.io"
+	regex := "quay[.io"
+	for _, tc := range []struct {
+		name       string
+		exceptions []schema.ObfuscateExceptionsElem
+	}{
+		{name: "empty", exceptions: []schema.ObfuscateExceptionsElem{{}}},
+		{name: "literal and regex", exceptions: []schema.ObfuscateExceptionsElem{{Literal: &literal, Regex: &literal}}},
+		{name: "invalid regex", exceptions: []schema.ObfuscateExceptionsElem{{Regex: &regex}}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			regex, err := NewRegexObfuscator(`quay\.io`, nil, schema.ObfuscateReplacementTypeStatic, ReplacementFormat{}, NewSimpleTracker())
+			require.NoError(t, err)
+			_, err = NewExceptionObfuscator(tc.exceptions, regex)
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestExceptionObfuscatorWithoutSpans(t *testing.T) {
+	_, err := NewExceptionObfuscator([]schema.ObfuscateExceptionsElem{literalException("quay.io")}, NoopObfuscator{})
+	assert.EqualError(t, err, "exceptions are not supported by obfuscators that don't report their matches")
+}
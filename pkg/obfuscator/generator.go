@@ -2,6 +2,10 @@ package obfuscator
 
 import (
 	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
 
 	"github.com/openshift/must-gather-clean/pkg/schema"
 	"k8s.io/klog/v2"
@@ -46,6 +50,70 @@ func (g *generator) generateReplacement(key string, original string, count uint,
 	return replacement
 }
 
+// templateVerbPattern matches the integer verb of a template, for example "%06d" in "token-%06d"
+var templateVerbPattern = regexp.MustCompile(`%[-+ 0]*([0-9]*)d`)
+
+// maximumForTemplate validates that the user supplied template contains exactly one integer verb and returns the
+// maximum number of replacements it supports. For a template with a width like "%06d" these are all numbers that
+// fit the width, otherwise defaultMax is returned.
+func maximumForTemplate(template string, defaultMax int) (int, error) {
+	stripped := strings.ReplaceAll(template, "%%", "")
+	verbs := templateVerbPattern.FindAllStringSubmatch(stripped, -1)
+	if len(verbs) != 1 || strings.Count(stripped, "%") != 1 {
+		return 0, fmt.Errorf("template '%s' must contain exactly one integer verb, for example 'token-%%06d'", template)
+	}
+	width, err := strconv.Atoi(verbs[0][1])
+	if err != nil || width >= int(math.Log10(float64(defaultMax)))+1 {
+		return defaultMax, nil
+	}
+	return int(math.Pow10(width)) - 1, nil
+}
+
+// ReplacementFormat customizes the replacements of an obfuscator, empty fields keep the defaults of the obfuscator.
+type ReplacementFormat struct {
+	// Template is used for Consistent replacements, it must contain exactly one integer verb, e.g. "host-%04d.example.invalid"
+	Template string
+	// Static is used for Static replacements
+	Static string
+}
+
+// validate ensures that the template creates unique replacements and that neither the template nor the static
+// replacement would be detected again by the obfuscator, which is checked by calling detects on sample replacements.
+func (f ReplacementFormat) validate(defaultMax int, detects func(s string) bool) error {
+	var samples []string
+	if f.Template != "" {
+		maxSupported, err := maximumForTemplate(f.Template, defaultMax)
+		if err != nil {
+			return err
+		}
+		samples = append(samples, fmt.Sprintf(f.Template, 1), fmt.Sprintf(f.Template, maxSupported))
+	}
+	if f.Static != "" {
+		samples = append(samples, f.Static)
+	}
+	for _, sample := range samples {
+		if detects(sample) {
+			return fmt.Errorf("replacement '%s' would be obfuscated again, please choose a template or static replacement that is not detected", sample)
+		}
+	}
+	return nil
+}
+
+// newFormattedGenerator creates a generator with the given defaults, where the fields of the format take precedence.
+func newFormattedGenerator(format ReplacementFormat, template, static string, maxSupported int, replacementType schema.ObfuscateReplacementType) (*generator, error) {
+	if format.Template != "" {
+		m, err := maximumForTemplate(format.Template, maxSupported)
+		if err != nil {
+			return nil, err
+		}
+		template, maxSupported = format.Template, m
+	}
+	if format.Static != "" {
+		static = format.Static
+	}
+	return newGenerator(template, static, maxSupported, replacementType)
+}
+
 // newGenerator creates a generator objects and populates with the provided arguments
 func newGenerator(template, static string, maxSupported int, replacementType schema.ObfuscateReplacementType) (*generator, error) {
 	if replacementType != schema.ObfuscateReplacementTypeStatic && replacementType != schema.ObfuscateReplacementTypeConsistent {
@@ -41,3 +41,135 @@ func TestGeneratorOverLimit(t *testing.T) {
 	assert.Equal(t, "", g.generateConsistentReplacement())
 	assert.True(t, exitCalled, "should have called exit function")
 }
+
+func TestMaximumForTemplate(t *testing.T) {
+	for _, tc := range []struct {
+		template string
+		max      int
+	}{
+		{template: "token-%d", max: 9999999999},
+		{template: "token-%02d", max: 99},
+		{template: "token-%-6d", max: 999999},
+		{template: "100%%-token-%03d", max: 999},
+		{template: "token-%012d", max: 9999999999},
+	} {
+		t.Run(tc.template, func(t *testing.T) {
+			m, err := maximumForTemplate(tc.template, 9999999999)
+			require.NoError(t, err)
+			assert.Equal(t, tc.max, m)
+		})
+	}
+}
+
+func TestReplacementFormat(t *testing.T) {
+	for _, tc := range []struct {
+		name   string
+		create func(format ReplacementFormat) (ReportingObfuscator, error)
+		format ReplacementFormat
+		input  string
+		output string
+	}{
+		{
+			name: "domain template",
+			create: func(format ReplacementFormat) (ReportingObfuscator, error) {
+				return NewDomainObfuscator([]string{"example.com"}, schema.ObfuscateReplacementTypeConsistent, format, NewSimpleTracker())
+			},
+			format: ReplacementFormat{Template: "domain%04d.invalid"},
+			input:  "reach api.example.com and example.com",
+			output: "reach api.domain0001.invalid and domain0001.invalid",
+		},
+		{
+			name: "mac static",
+			create: func(format ReplacementFormat) (ReportingObfuscator, error) {
+				return NewMacAddressObfuscator(schema.ObfuscateReplacementTypeStatic, format, NewSimpleTracker())
+			},
+			format: ReplacementFormat{Static: "<mac>"},
+			input:  "link 29-7E-8C-8C-60-C9",
+			output: "link <mac>",
+		},
+		{
+			name: "ip template shared by both families",
+			create: func(format ReplacementFormat) (ReportingObfuscator, error) {
+				return NewIPObfuscator(nil, nil, schema.ObfuscateReplacementTypeConsistent, format, NewSimpleTracker())
+			},
+			format: ReplacementFormat{Template: "ip-%06d"},
+			input:  "10.0.0.1 and 2001:db8::1 and 10.0.0.1",
+			output: "ip-000001 and ip-000002 and ip-000001",
+		},
+		{
+			name: "identity template shared by users and groups",
+			create: func(format ReplacementFormat) (ReportingObfuscator, error) {
+				return NewIdentityObfuscator([]string{"alice"}, []string{"admins"}, schema.ObfuscateReplacementTypeConsistent, format, NewSimpleTracker())
+			},
+			format: ReplacementFormat{Template: "principal-%d"},
+			input:  "user alice in group admins",
+			output: "user principal-1 in group principal-2",
+		},
+		{
+			name: "keywords template",
+			create: func(format ReplacementFormat) (ReportingObfuscator, error) {
+				return NewKeywordsObfuscator(nil, []string{"secret"}, false, false, schema.ObfuscateReplacementTypeConsistent, format)
+			},
+			format: ReplacementFormat{Template: "kw-%03d"},
+			input:  "a secret word",
+			output: "a kw-001 word",
+		},
+		{
+			name: "regex static",
+			create: func(format ReplacementFormat) (ReportingObfuscator, error) {
+				return NewRegexObfuscator(`token=\w+`, nil, schema.ObfuscateReplacementTypeStatic, format, NewSimpleTracker())
+			},
+			format: ReplacementFormat{Static: "[redacted]"},
+			input:  "login token=abc123",
+			output: "login [redacted]",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			o, err := tc.create(tc.format)
+			require.NoError(t, err)
+			assert.Equal(t, tc.output, o.Contents(tc.input))
+		})
+	}
+}
+
+func TestReplacementFormatValidation(t *testing.T) {
+	for _, tc := range []struct {
+		name   string
+		create func() (ReportingObfuscator, error)
+		err    string
+	}{
+		{
+			name: "ip template is detected again",
+			create: func() (ReportingObfuscator, error) {
+				return NewIPObfuscator(nil, nil, schema.ObfuscateReplacementTypeConsistent, ReplacementFormat{Template: "10.0.0.%d"}, NewSimpleTracker())
+			},
+			err: "replacement '10.0.0.1' would be obfuscated again, please choose a template or static replacement that is not detected",
+		},
+		{
+			name: "ip static is detected again",
+			create: func() (ReportingObfuscator, error) {
+				return NewIPObfuscator(nil, nil, schema.ObfuscateReplacementTypeStatic, ReplacementFormat{Static: "1.2.3.4"}, NewSimpleTracker())
+			},
+			err: "replacement '1.2.3.4' would be obfuscated again, please choose a template or static replacement that is not detected",
+		},
+		{
+			name: "domain template contains the domain",
+			create: func() (ReportingObfuscator, error) {
+				return NewDomainObfuscator([]string{"example.com"}, schema.ObfuscateReplacementTypeConsistent, ReplacementFormat{Template: "host-%d.example.com"}, NewSimpleTracker())
+			},
+			err: "replacement 'host-1.example.com' would be obfuscated again, please choose a template or static replacement that is not detected",
+		},
+		{
+			name: "template without an integer verb",
+			create: func() (ReportingObfuscator, error) {
+				return NewMacAddressObfuscator(schema.ObfuscateReplacementTypeConsistent, ReplacementFormat{Template: "mac-%s"}, NewSimpleTracker())
+			},
+			err: "template 'mac-%s' must contain exactly one integer verb, for example 'token-%06d'",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := tc.create()
+			assert.EqualError(t, err, tc.err)
+		})
+	}
+}
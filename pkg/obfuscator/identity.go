@@ -0,0 +1,166 @@
+package obfuscator
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/openshift/must-gather-clean/pkg/schema"
+)
+
+const (
+	staticUserReplacement  = "user-xxxxxxxx"
+	staticGroupReplacement = "group-xxxxxxxx"
+	// the templates are kept DNS-label safe, since identities are also used in resource names (e.g. rolebindings)
+	consistentUserTemplate                = "user-%08d"
+	consistentGroupTemplate               = "group-%08d"
+	maximumSupportedObfuscationIdentities = 99999999
+	// systemIdentityPrefix marks built-in identities, for example system:admin or system:serviceaccount:ns:name
+	systemIdentityPrefix = "system:"
+)
+
+var identityFileExtensions = []string{".yaml", ".yml", ".json", ".log"}
+
+type identityObfuscator struct {
+	ReplacementTracker
+	pattern        *regexp.Regexp
+	groups         map[string]struct{}
+	userGenerator  *generator
+	groupGenerator *generator
+}
+
+func (i *identityObfuscator) Path(s string) string {
+	return applySpans(s, i.spans(s))
+}
+
+func (i *identityObfuscator) Contents(s string) string {
+	return applySpans(s, i.spans(s))
+}
+
+func (i *identityObfuscator) PathSpans(s string) []Span {
+	return i.spans(s)
+}
+
+func (i *identityObfuscator) ContentsSpans(s string) []Span {
+	return i.spans(s)
+}
+
+func (i *identityObfuscator) spans(input string) []Span {
+	if i.pattern == nil {
+		return nil
+	}
+
+	var spans []Span
+	for _, m := range i.pattern.FindAllStringIndex(input, -1) {
+		start, end := m[0], m[1]
+		if !isIdentityBoundary(input, start, end) || isWithinSystemIdentity(input, start) {
+			continue
+		}
+
+		name := input[start:end]
+		g := i.userGenerator
+		if _, ok := i.groups[name]; ok {
+			g = i.groupGenerator
+		}
+		spans = append(spans, Span{Start: start, End: end, Replace: func() string {
+			return g.generateReplacement(name, name, 1, i.ReplacementTracker)
+		}})
+	}
+	return spans
+}
+
+func isIdentityCharacter(c byte) bool {
+	return c == '.' || c == '_' || c == '@' || c == '-' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || (c >= '0' && c <= '9')
+}
+
+// isIdentityBoundary ensures that only whole identities are matched, so the user "bob" won't replace parts of "bobcat".
+// A trailing file extension counts as a boundary, so that files named after an identity are matched as well. So does a
+// dot that ends a sentence, as in "logged in as bob.".
+func isIdentityBoundary(s string, start int, end int) bool {
+	if start > 0 && isIdentityCharacter(s[start-1]) {
+		return false
+	}
+	if end == len(s) || !isIdentityCharacter(s[end]) {
+		return true
+	}
+	if s[end] == '.' && (end+1 == len(s) || !isIdentityCharacter(s[end+1])) {
+		return true
+	}
+	for _, ext := range identityFileExtensions {
+		if strings.HasPrefix(s[end:], ext) && (end+len(ext) == len(s) || !isIdentityCharacter(s[end+len(ext)])) {
+			return true
+		}
+	}
+	return false
+}
+
+// isWithinSystemIdentity returns true if the match is part of a system identity like system:serviceaccount:ns:name.
+func isWithinSystemIdentity(s string, start int) bool {
+	tokenStart := start
+	for tokenStart > 0 && (isIdentityCharacter(s[tokenStart-1]) || s[tokenStart-1] == ':') {
+		tokenStart--
+	}
+	return strings.HasPrefix(s[tokenStart:], systemIdentityPrefix)
+}
+
+// NewIdentityObfuscator returns an obfuscator that replaces the given user and group names as whole words, for example
+// with "user-00000042". System identities (prefixed with "system:") are never replaced.
+func NewIdentityObfuscator(users []string, groups []string, replacementType schema.ObfuscateReplacementType, format ReplacementFormat, tracker ReplacementTracker) (ReportingObfuscator, error) {
+	err := format.validate(maximumSupportedObfuscationIdentities, func(s string) bool {
+		probe, err := NewIdentityObfuscator(users, groups, replacementType, ReplacementFormat{}, NewSimpleTracker())
+		return err == nil && probe.Contents(s) != s
+	})
+	if err != nil {
+		return nil, err
+	}
+	userGenerator, err := newFormattedGenerator(format, consistentUserTemplate, staticUserReplacement, maximumSupportedObfuscationIdentities, replacementType)
+	if err != nil {
+		return nil, err
+	}
+	// a custom template is shared by users and groups, so they need to share the counter to be unique
+	groupGenerator := userGenerator
+	if format.Template == "" {
+		groupGenerator, err = newFormattedGenerator(format, consistentGroupTemplate, staticGroupReplacement, maximumSupportedObfuscationIdentities, replacementType)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	groupSet := map[string]struct{}{}
+	var names []string
+	for _, u := range users {
+		if u != "" && !strings.HasPrefix(u, systemIdentityPrefix) {
+			names = append(names, regexp.QuoteMeta(u))
+		}
+	}
+	for _, g := range groups {
+		if g != "" && !strings.HasPrefix(g, systemIdentityPrefix) {
+			groupSet[g] = struct{}{}
+			names = append(names, regexp.QuoteMeta(g))
+		}
+	}
+	// a name that is used for both a user and a group is treated as a user
+	for _, u := range users {
+		delete(groupSet, u)
+	}
+
+	var pattern *regexp.Regexp
+	if len(names) > 0 {
+		// the alternation matches leftmost-first, so the longest name must come first to match most specifically
+		sort.Slice(names, func(i, j int) bool {
+			return len(names[i]) > len(names[j])
+		})
+		pattern, err = regexp.Compile(strings.Join(names, "|"))
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return &identityObfuscator{
+		ReplacementTracker: tracker,
+		pattern:            pattern,
+		groups:             groupSet,
+		userGenerator:      userGenerator,
+		groupGenerator:     groupGenerator,
+	}, nil
+}
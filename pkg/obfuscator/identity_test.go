@@ -0,0 +1,101 @@
+package obfuscator
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/openshift/must-gather-clean/pkg/schema"
+)
+
+func TestIdentityObfuscatorContents(t *testing.T) {
+	for _, tc := range []struct {
+		name   string
+		users  []string
+		groups []string
+		input  []string
+		output []string
+		report map[string]string
+	}{
+		{
+			name:   "users and groups",
+			users:  []string{"alice@example.com", "bob"},
+			groups: []string{"cluster-admins-team"},
+			input: []string{
+				`"user":{"username":"alice@example.com","groups":["cluster-admins-team","system:authenticated"]}`,
+				"subjects: [{kind: User, name: bob}]",
+				"bob approved the request",
+			},
+			output: []string{
+				`"user":{"username":"user-00000001","groups":["group-00000001","system:authenticated"]}`,
+				"subjects: [{kind: User, name: user-00000002}]",
+				"user-00000002 approved the request",
+			},
+			report: map[string]string{
+				"alice@example.com":   "user-00000001",
+				"cluster-admins-team": "group-00000001",
+				"bob":                 "user-00000002",
+			},
+		},
+		{
+			name:   "whole words only",
+			users:  []string{"bob"},
+			input:  []string{"bobcat bob-pod bob.txt bob.yamlx bob.yaml bob"},
+			output: []string{"bobcat bob-pod bob.txt bob.yamlx user-00000001.yaml user-00000001"},
+			report: map[string]string{"bob": "user-00000001"},
+		},
+		{
+			name:   "end of sentence",
+			users:  []string{"bob", "alice"},
+			input:  []string{"logged in as bob. then alice.", "bob.smith and alice.b"},
+			output: []string{"logged in as user-00000001. then user-00000002.", "bob.smith and alice.b"},
+			report: map[string]string{"bob": "user-00000001", "alice": "user-00000002"},
+		},
+		{
+			name:  "system identities are untouched",
+			users: []string{"admin", "system:admin"},
+			input: []string{
+				"system:serviceaccount:admin:default",
+				"system:admin",
+				"admin",
+			},
+			output: []string{
+				"system:serviceaccount:admin:default",
+				"system:admin",
+				"user-00000001",
+			},
+			report: map[string]string{"admin": "user-00000001"},
+		},
+		{
+			name:   "provider identities",
+			users:  []string{"jdoe"},
+			input:  []string{"identities: [htpasswd:jdoe]"},
+			output: []string{"identities: [htpasswd:user-00000001]"},
+			report: map[string]string{"jdoe": "user-00000001"},
+		},
+		{
+			name:   "nothing learned",
+			input:  []string{"alice and bob"},
+			output: []string{"alice and bob"},
+			report: map[string]string{},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			o, err := NewIdentityObfuscator(tc.users, tc.groups, schema.ObfuscateReplacementTypeConsistent, ReplacementFormat{}, NewSimpleTracker())
+			require.NoError(t, err)
+			for i, line := range tc.input {
+				assert.Equal(t, tc.output[i], o.Contents(line))
+			}
+			assert.Equal(t, tc.report, o.Report().AsMap())
+		})
+	}
+}
+
+func TestIdentityObfuscatorStatic(t *testing.T) {
+	o, err := NewIdentityObfuscator([]string{"alice"}, []string{"devs"}, schema.ObfuscateReplacementTypeStatic, ReplacementFormat{}, NewSimpleTracker())
+	require.NoError(t, err)
+	assert.Equal(t, "user-xxxxxxxx is in group-xxxxxxxx", o.Contents("alice is in devs"))
+	assert.Equal(t, "users/user-xxxxxxxx.yaml", o.Path("users/alice.yaml"))
+	assert.Equal(t, "rolebindings/alice-edit.yaml", o.Path("rolebindings/alice-edit.yaml"))
+}
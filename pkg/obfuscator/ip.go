@@ -22,71 +22,183 @@ const (
 
 var (
 	ipv4re = `(([1-9]|[1-9][0-9]|1[0-9]{2}|2[0-4][0-9]|25[0-5])[.]([0-9]|[1-9][0-9]|1[0-9]{2}|2[0-4][0-9]|25[0-5])[.]([0-9]|[1-9][0-9]|1[0-9]{2}|2[0-4][0-9]|25[0-5])[.]|([1-9]|[1-9][0-9]|1[0-9]{2}|2[0-4][0-9]|25[0-5])[_-]([0-9]|[1-9][0-9]|1[0-9]{2}|2[0-4][0-9]|25[0-5])[_-]([0-9]|[1-9][0-9]|1[0-9]{2}|2[0-4][0-9]|25[0-5])[_-])([0-9]|[1-9][0-9]|1[0-9]{2}|2[0-4][0-9]|25[0-5]){1,3}`
-	// ipv6re is not perfect. it can still catch words like :face:bad as a valid ipv6 address
-	ipv6re      = `([a-f0-9]{0,4}[:]){1,8}[a-f0-9]{1,4}`
+	// ipv6re only finds candidates, which are a run of hex digits, colons and dots with at least two colons. Every
+	// candidate is validated with isIPv6Boundary and net.ParseIP, so words like ":face:bad" or "client-ca::kube" are
+	// not replaced.
+	ipv6re      = `[0-9a-fA-F.:]*:[0-9a-fA-F.:]*:[0-9a-fA-F.:]*`
 	ipv6Pattern = regexp.MustCompile(ipv6re)
 	ipv4Pattern = regexp.MustCompile(ipv4re)
 	excludedIPs = map[string]struct{}{
 		"127.0.0.1": {},
 		"0.0.0.0":   {},
 		"::1":       {},
+		"::":        {},
 	}
 )
 
 type ipObfuscator struct {
 	ReplacementTracker
-	replacements []replacementGenerator
-}
-
-type replacementGenerator struct {
-	pattern   *regexp.Regexp
-	generator *generator
+	ipv4Generator *generator
+	ipv6Generator *generator
+	exclude       []ipRange
+	onlyRanges    []ipRange
 }
 
 func (o *ipObfuscator) Path(s string) string {
-	return o.replace(s)
+	return applySpans(s, o.spans(s))
 }
 
 func (o *ipObfuscator) Contents(s string) string {
-	return o.replace(s)
+	return applySpans(s, o.spans(s))
+}
+
+func (o *ipObfuscator) PathSpans(s string) []Span {
+	return o.spans(s)
+}
+
+func (o *ipObfuscator) ContentsSpans(s string) []Span {
+	return o.spans(s)
+}
+
+func (o *ipObfuscator) spans(s string) []Span {
+	// IPv6 takes precedence, so that the IPv4 part of an embedded address (e.g. 64:ff9b::10.0.0.1) is not replaced on its own
+	return mergeSpans(o.ipv6Spans(s), o.ipv4Spans(s))
 }
 
-func (o *ipObfuscator) replace(s string) string {
-	output := s
+func (o *ipObfuscator) ipv4Spans(s string) []Span {
+	var spans []Span
+	for _, m := range ipv4Pattern.FindAllStringIndex(s, -1) {
+		original := s[m[0]:m[1]]
+		cleaned := strings.ReplaceAll(strings.ReplaceAll(original, "_", "."), "-", ".")
+		if ip := net.ParseIP(cleaned); ip != nil && !o.isExcluded(ip) {
+			spans = append(spans, Span{Start: m[0], End: m[1], Replace: func() string {
+				return o.ipv4Generator.generateReplacement(cleaned, original, 1, o.ReplacementTracker)
+			}})
+		}
+	}
+	return spans
+}
 
-	for _, r := range o.replacements {
-		ipMatches := r.pattern.FindAllString(output, -1)
-		for _, m := range ipMatches {
-			// if the match is in the exclude-list then do not replace.
-			if _, ok := excludedIPs[m]; ok {
+func (o *ipObfuscator) ipv6Spans(s string) []Span {
+	var spans []Span
+	for _, m := range ipv6Pattern.FindAllStringIndex(s, -1) {
+		start, end, ip := parseIPv6Candidate(s, m[0], m[1])
+		if ip == nil {
+			continue
+		}
+		original := s[start:end]
+		if ipv4 := ip.To4(); ipv4 != nil {
+			// IPv4-mapped addresses like ::ffff:10.0.0.1 are left to the IPv4 replacement, the prefix is not confidential
+			if strings.Contains(original, ".") {
 				continue
 			}
-
-			cleaned := strings.ToUpper(strings.ReplaceAll(strings.ReplaceAll(m, "_", "."), "-", "."))
-			if ip := net.ParseIP(cleaned); ip != nil {
-				replacement := r.generator.generateReplacement(cleaned, m, 1, o.ReplacementTracker)
-				// TODO(thomas): should just replace that one matching occurrence instead of all
-				output = strings.ReplaceAll(output, m, replacement)
+			if o.isExcluded(ipv4) {
+				continue
 			}
+			// the hex form ::ffff:a00:1 is replaced as a whole, with the same value as its dotted IPv4 address
+			canonical := ipv4.String()
+			spans = append(spans, Span{Start: start, End: end, Replace: func() string {
+				return o.ipv4Generator.generateReplacement(canonical, original, 1, o.ReplacementTracker)
+			}})
+			continue
+		}
+		if o.isExcluded(ip) {
+			continue
+		}
+		// the canonical form is used as the key, so compressed and expanded notations are replaced with the same value
+		canonical := ip.String()
+		spans = append(spans, Span{Start: start, End: end, Replace: func() string {
+			return o.ipv6Generator.generateReplacement(canonical, original, 1, o.ReplacementTracker)
+		}})
+	}
+	return spans
+}
+
+// isExcluded returns true if the address must not be replaced, because it is always excluded, it is part of the
+// configured exclusions or it is not part of the configured onlyRanges.
+func (o *ipObfuscator) isExcluded(ip net.IP) bool {
+	if _, ok := excludedIPs[ip.String()]; ok {
+		return true
+	}
+	if inIPRanges(o.exclude, ip) {
+		return true
+	}
+	return len(o.onlyRanges) > 0 && !inIPRanges(o.onlyRanges, ip)
+}
+
+// parseIPv6Candidate validates the candidate s[start:end] and returns the bounds of the contained IPv6 address. A
+// single separating colon in front, as in "addr:fe80::1", and a trailing colon or dot that ends a sentence are trimmed.
+// Zone IDs ("fe80::1%eth0") and ports ("[fd00::1]:6443") are not part of the candidate and thus stay untouched.
+func parseIPv6Candidate(s string, start int, end int) (int, int, net.IP) {
+	if !isIPv6Boundary(s, start, end) {
+		return 0, 0, nil
+	}
+	if s[start] == ':' && !strings.HasPrefix(s[start:], "::") {
+		start++
+	}
+	if ip := net.ParseIP(s[start:end]); ip != nil {
+		return start, end, ip
+	}
+	if last := s[end-1]; (last == ':' || last == '.') && !strings.HasSuffix(s[start:end], "::") {
+		if ip := net.ParseIP(s[start : end-1]); ip != nil {
+			return start, end - 1, ip
 		}
 	}
-	return output
+	return 0, 0, nil
+}
+
+func isIPv6WordCharacter(c byte) bool {
+	return c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || (c >= '0' && c <= '9')
+}
+
+// isIPv6Boundary ensures the candidate is not part of a longer word, for example "client-ca::kube-system".
+// A leading colon separates a key from its value, in which case the word in front is not considered.
+func isIPv6Boundary(s string, start int, end int) bool {
+	if end < len(s) && isIPv6WordCharacter(s[end]) {
+		return false
+	}
+	if s[start] == ':' && !strings.HasPrefix(s[start:], "::") {
+		return true
+	}
+	return start == 0 || !isIPv6WordCharacter(s[start-1])
 }
 
-func NewIPObfuscator(replacementType schema.ObfuscateReplacementType, tracker ReplacementTracker) (ReportingObfuscator, error) {
-	genIPv4, err := newGenerator(consistentIPv4Template, obfuscatedStaticIPv4, maximumSupportedObfuscationsIP, replacementType)
+// NewIPObfuscator returns an obfuscator for IPv4 and IPv6 addresses. Addresses within the exclude ranges are never
+// replaced and when onlyRanges is not empty, only the addresses within those ranges are replaced. Both accept IP
+// addresses, CIDRs and the names of the presets in ipRangePresets.
+func NewIPObfuscator(exclude []string, onlyRanges []string, replacementType schema.ObfuscateReplacementType, format ReplacementFormat, tracker ReplacementTracker) (ReportingObfuscator, error) {
+	excludeRanges, err := parseIPRanges(exclude)
+	if err != nil {
+		return nil, err
+	}
+	includeRanges, err := parseIPRanges(onlyRanges)
 	if err != nil {
 		return nil, err
 	}
-	genIPv6, err := newGenerator(consistentIPv6Template, obfuscatedStaticIPv6, maximumSupportedObfuscationsIP, replacementType)
+	err = format.validate(maximumSupportedObfuscationsIP, func(s string) bool {
+		probe, err := NewIPObfuscator(nil, nil, replacementType, ReplacementFormat{}, NewSimpleTracker())
+		return err == nil && probe.Contents(s) != s
+	})
 	if err != nil {
 		return nil, err
 	}
+	genIPv4, err := newFormattedGenerator(format, consistentIPv4Template, obfuscatedStaticIPv4, maximumSupportedObfuscationsIP, replacementType)
+	if err != nil {
+		return nil, err
+	}
+	// a custom template is shared by both address families, so they need to share the counter to be unique
+	genIPv6 := genIPv4
+	if format.Template == "" {
+		genIPv6, err = newFormattedGenerator(format, consistentIPv6Template, obfuscatedStaticIPv6, maximumSupportedObfuscationsIP, replacementType)
+		if err != nil {
+			return nil, err
+		}
+	}
 	return &ipObfuscator{
 		ReplacementTracker: tracker,
-		replacements: []replacementGenerator{
-			{pattern: ipv4Pattern, generator: genIPv4},
-			{pattern: ipv6Pattern, generator: genIPv6},
-		},
+		ipv4Generator:      genIPv4,
+		ipv6Generator:      genIPv6,
+		exclude:            excludeRanges,
+		onlyRanges:         includeRanges,
 	}, nil
 }
@@ -0,0 +1,93 @@
+package obfuscator
+
+import (
+	"fmt"
+	"net"
+	"sort"
+	"strings"
+)
+
+// ipRange returns true if the given address is part of the range.
+type ipRange func(ip net.IP) bool
+
+var (
+	loopbackRange       = cidrRange("127.0.0.0/8", "::1/128")
+	linkLocalRange      = cidrRange("169.254.0.0/16", "fe80::/10")
+	privateRange        = cidrRange("10.0.0.0/8", "172.16.0.0/12", "192.168.0.0/16", "fc00::/7")
+	multicastRange      = cidrRange("224.0.0.0/4", "ff00::/8")
+	unspecifiedRange    = cidrRange("0.0.0.0/32", "::/128")
+	serviceNetworkRange = cidrRange("172.30.0.0/16", "fd02::/112")
+	// publicRange contains everything that is not reserved for a special purpose by one of the other presets
+	publicRange ipRange = func(ip net.IP) bool {
+		return !loopbackRange(ip) && !linkLocalRange(ip) && !privateRange(ip) && !multicastRange(ip) && !unspecifiedRange(ip)
+	}
+
+	ipRangePresets = map[string]ipRange{
+		"loopback":        loopbackRange,
+		"link-local":      linkLocalRange,
+		"private":         privateRange,
+		"public":          publicRange,
+		"multicast":       multicastRange,
+		"unspecified":     unspecifiedRange,
+		"service-network": serviceNetworkRange,
+	}
+)
+
+func cidrRange(cidrs ...string) ipRange {
+	var networks []*net.IPNet
+	for _, c := range cidrs {
+		_, network, err := net.ParseCIDR(c)
+		if err != nil {
+			panic(err)
+		}
+		networks = append(networks, network)
+	}
+	return func(ip net.IP) bool {
+		for _, n := range networks {
+			if n.Contains(ip) {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// parseIPRanges converts the given IP addresses, CIDRs and preset names into ranges.
+func parseIPRanges(entries []string) ([]ipRange, error) {
+	var ranges []ipRange
+	for _, e := range entries {
+		entry := strings.TrimSpace(e)
+		if preset, ok := ipRangePresets[strings.ToLower(entry)]; ok {
+			ranges = append(ranges, preset)
+			continue
+		}
+		if _, network, err := net.ParseCIDR(entry); err == nil {
+			ranges = append(ranges, network.Contains)
+			continue
+		}
+		if address := net.ParseIP(entry); address != nil {
+			ranges = append(ranges, address.Equal)
+			continue
+		}
+		return nil, fmt.Errorf("invalid IP range '%s', must be an IP address, a CIDR or one of the presets: %s", e, strings.Join(ipRangePresetNames(), ", "))
+	}
+	return ranges, nil
+}
+
+func ipRangePresetNames() []string {
+	var names []string
+	for name := range ipRangePresets {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func inIPRanges(ranges []ipRange, ip net.IP) bool {
+	for _, r := range ranges {
+		if r(ip) {
+			return true
+		}
+	}
+	return false
+}
@@ -1,6 +1,8 @@
 package obfuscator
 
 import (
+	"io/ioutil"
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -147,7 +149,7 @@ func TestIPObfuscatorStatic(t *testing.T) {
 		},
 	} {
 		t.Run(tc.name, func(t *testing.T) {
-			o, err := NewIPObfuscator(schema.ObfuscateReplacementTypeStatic, NewSimpleTracker())
+			o, err := NewIPObfuscator(nil, nil, schema.ObfuscateReplacementTypeStatic, ReplacementFormat{}, NewSimpleTracker())
 			require.NoError(t, err)
 			output := o.Contents(tc.input)
 			assert.Equal(t, tc.output, output)
@@ -256,7 +258,7 @@ func TestIPObfuscatorConsistent(t *testing.T) {
 		},
 	} {
 		t.Run(tc.name, func(t *testing.T) {
-			o, err := NewIPObfuscator(schema.ObfuscateReplacementTypeConsistent, NewSimpleTracker())
+			o, err := NewIPObfuscator(nil, nil, schema.ObfuscateReplacementTypeConsistent, ReplacementFormat{}, NewSimpleTracker())
 			require.NoError(t, err)
 			for i := 0; i < len(tc.input); i++ {
 				assert.Equal(t, tc.output[i], o.Contents(tc.input[i]))
@@ -288,7 +290,7 @@ func TestIPObfuscationInPaths(t *testing.T) {
 		},
 	} {
 		t.Run(tc.name, func(t *testing.T) {
-			o, err := NewIPObfuscator(schema.ObfuscateReplacementTypeConsistent, NewSimpleTracker())
+			o, err := NewIPObfuscator(nil, nil, schema.ObfuscateReplacementTypeConsistent, ReplacementFormat{}, NewSimpleTracker())
 			require.NoError(t, err)
 			obfuscated := o.Path(tc.input)
 			assert.Equal(t, tc.output, obfuscated)
@@ -308,8 +310,8 @@ func TestIPv6CanonicalKeyConsistent(t *testing.T) {
 			name:   "valid ipv6 address",
 			input:  "received request from 2001:db8::ff00:42:8329",
 			output: "received request from x-ipv6-0000000001-x",
-			report: ReplacementReport{[]Replacement{
-				{Canonical: "2001:DB8::FF00:42:8329", ReplacedWith: "x-ipv6-0000000001-x", Counter: map[string]uint{
+			report: ReplacementReport{Replacements: []Replacement{
+				{Canonical: "2001:db8::ff00:42:8329", ReplacedWith: "x-ipv6-0000000001-x", Counter: map[string]uint{
 					"2001:db8::ff00:42:8329": 1,
 				}},
 			}},
@@ -318,35 +320,42 @@ func TestIPv6CanonicalKeyConsistent(t *testing.T) {
 			name:   "mixed ipv4 and ipv6",
 			input:  "tunneling ::2fa:bf9 as 192.168.1.30",
 			output: "tunneling x-ipv6-0000000001-x as x-ipv4-0000000001-x",
-			report: ReplacementReport{[]Replacement{
+			report: ReplacementReport{Replacements: []Replacement{
 				{Canonical: "192.168.1.30", ReplacedWith: "x-ipv4-0000000001-x",
 					Counter: map[string]uint{
 						"192.168.1.30": 1,
 					}},
-				{Canonical: "::2FA:BF9", ReplacedWith: "x-ipv6-0000000001-x",
+				{Canonical: "::2fa:bf9", ReplacedWith: "x-ipv6-0000000001-x",
 					Counter: map[string]uint{
 						"::2fa:bf9": 1,
 					}},
 			}},
 		},
+		{
+			name:   "compressed and expanded ipv6 share the key",
+			input:  "from 2001:db8::1 to 2001:0DB8:0000:0000:0000:0000:0000:0001",
+			output: "from x-ipv6-0000000001-x to x-ipv6-0000000001-x",
+			report: ReplacementReport{Replacements: []Replacement{
+				{Canonical: "2001:db8::1", ReplacedWith: "x-ipv6-0000000001-x", Counter: map[string]uint{
+					"2001:db8::1": 1,
+					"2001:0DB8:0000:0000:0000:0000:0000:0001": 1,
+				}},
+			}},
+		},
 		{
 			name:   "mixed ipv4 and ipv6 logline",
 			input:  "2021-08-03T09:35:59.743794348Z ::ffff:10.130.0.1 - - [03/Aug/2021 09:25:59] \"GET / HTTP/1.1\" 200 - 2021-08-03T09:35:59.743794348Z ::ffff:10.130.0.1 - - [03/Aug/2021 09:25:59] \"GET / HTTP/1.1\" 200 - 2021-08-03T09:35:59.743794348Z ::ffff:10.130.0.1 - - [03/Aug/2021 09:25:59] \"GET / HTTP/1.1\" 200 - 2021-08-03T09:35:59.743794348Z ::ffff:10.130.0.1 - - [03/Aug/2021 09:25:59] \"GET / HTTP/1.1\" 200 - 2021-08-03T09:35:59.743794348Z ::ffff:10.130.0.1 - - [03/Aug/2021 09:25:59] \"GET / HTTP/1.1\" 200 - 2021-08-03T09:35:59.743794348Z ::ffff:10.130.0.1 - - [03/Aug/2021 09:25:59] \"GET / HTTP/1.1\" 200 -",
-			output: "2021-08-03T09:35:59.743794348Z x-ipv6-0000000001-x:x-ipv4-0000000001-x - - [03/Aug/2021 09:25:59] \"GET / HTTP/1.1\" 200 - 2021-08-03T09:35:59.743794348Z x-ipv6-0000000001-x:x-ipv4-0000000001-x - - [03/Aug/2021 09:25:59] \"GET / HTTP/1.1\" 200 - 2021-08-03T09:35:59.743794348Z x-ipv6-0000000001-x:x-ipv4-0000000001-x - - [03/Aug/2021 09:25:59] \"GET / HTTP/1.1\" 200 - 2021-08-03T09:35:59.743794348Z x-ipv6-0000000001-x:x-ipv4-0000000001-x - - [03/Aug/2021 09:25:59] \"GET / HTTP/1.1\" 200 - 2021-08-03T09:35:59.743794348Z x-ipv6-0000000001-x:x-ipv4-0000000001-x - - [03/Aug/2021 09:25:59] \"GET / HTTP/1.1\" 200 - 2021-08-03T09:35:59.743794348Z x-ipv6-0000000001-x:x-ipv4-0000000001-x - - [03/Aug/2021 09:25:59] \"GET / HTTP/1.1\" 200 -",
-			report: ReplacementReport{[]Replacement{
+			output: "2021-08-03T09:35:59.743794348Z ::ffff:x-ipv4-0000000001-x - - [03/Aug/2021 09:25:59] \"GET / HTTP/1.1\" 200 - 2021-08-03T09:35:59.743794348Z ::ffff:x-ipv4-0000000001-x - - [03/Aug/2021 09:25:59] \"GET / HTTP/1.1\" 200 - 2021-08-03T09:35:59.743794348Z ::ffff:x-ipv4-0000000001-x - - [03/Aug/2021 09:25:59] \"GET / HTTP/1.1\" 200 - 2021-08-03T09:35:59.743794348Z ::ffff:x-ipv4-0000000001-x - - [03/Aug/2021 09:25:59] \"GET / HTTP/1.1\" 200 - 2021-08-03T09:35:59.743794348Z ::ffff:x-ipv4-0000000001-x - - [03/Aug/2021 09:25:59] \"GET / HTTP/1.1\" 200 - 2021-08-03T09:35:59.743794348Z ::ffff:x-ipv4-0000000001-x - - [03/Aug/2021 09:25:59] \"GET / HTTP/1.1\" 200 -",
+			report: ReplacementReport{Replacements: []Replacement{
 				{Canonical: "10.130.0.1", ReplacedWith: "x-ipv4-0000000001-x",
 					Counter: map[string]uint{
 						"10.130.0.1": 6,
 					}},
-				{Canonical: "::FFFF", ReplacedWith: "x-ipv6-0000000001-x",
-					Counter: map[string]uint{
-						"::ffff": 6,
-					}},
 			}},
 		},
 	} {
 		t.Run(tc.name, func(t *testing.T) {
-			o, err := NewIPObfuscator(schema.ObfuscateReplacementTypeConsistent, NewSimpleTracker())
+			o, err := NewIPObfuscator(nil, nil, schema.ObfuscateReplacementTypeConsistent, ReplacementFormat{}, NewSimpleTracker())
 			require.NoError(t, err)
 			output := o.Contents(tc.input)
 			assert.Equal(t, tc.output, output)
@@ -366,8 +375,8 @@ func TestIPv6CanonicalKeyStatic(t *testing.T) {
 			name:   "valid ipv6 address",
 			input:  "received request from 2001:db8::ff00:42:8329",
 			output: "received request from xxxx:xxxx:xxxx:xxxx:xxxx:xxxx:xxxx:xxxx",
-			report: ReplacementReport{[]Replacement{
-				{Canonical: "2001:DB8::FF00:42:8329", ReplacedWith: obfuscatedStaticIPv6,
+			report: ReplacementReport{Replacements: []Replacement{
+				{Canonical: "2001:db8::ff00:42:8329", ReplacedWith: obfuscatedStaticIPv6,
 					Counter: map[string]uint{
 						"2001:db8::ff00:42:8329": 1,
 					},
@@ -378,8 +387,8 @@ func TestIPv6CanonicalKeyStatic(t *testing.T) {
 			name:   "mixed ipv4 and ipv6",
 			input:  "tunneling ::2fa:bf9 as 192.168.1.30",
 			output: "tunneling xxxx:xxxx:xxxx:xxxx:xxxx:xxxx:xxxx:xxxx as xxx.xxx.xxx.xxx",
-			report: ReplacementReport{[]Replacement{
-				{Canonical: "::2FA:BF9", ReplacedWith: obfuscatedStaticIPv6,
+			report: ReplacementReport{Replacements: []Replacement{
+				{Canonical: "::2fa:bf9", ReplacedWith: obfuscatedStaticIPv6,
 					Counter: map[string]uint{
 						"::2fa:bf9": 1,
 					}},
@@ -391,7 +400,7 @@ func TestIPv6CanonicalKeyStatic(t *testing.T) {
 		},
 	} {
 		t.Run(tc.name, func(t *testing.T) {
-			o, err := NewIPObfuscator(schema.ObfuscateReplacementTypeStatic, NewSimpleTracker())
+			o, err := NewIPObfuscator(nil, nil, schema.ObfuscateReplacementTypeStatic, ReplacementFormat{}, NewSimpleTracker())
 			require.NoError(t, err)
 			output := o.Contents(tc.input)
 			assert.Equal(t, tc.output, output)
@@ -411,7 +420,7 @@ func TestIPObfuscatorWithCount(t *testing.T) {
 			name:   "valid ipv4 address four times",
 			input:  "same IP 192.168.1.10 address 192.168.1.10 repeated 192.168.1.10 four times 192.168.1.10",
 			output: "same IP xxx.xxx.xxx.xxx address xxx.xxx.xxx.xxx repeated xxx.xxx.xxx.xxx four times xxx.xxx.xxx.xxx",
-			report: ReplacementReport{[]Replacement{
+			report: ReplacementReport{Replacements: []Replacement{
 				{Canonical: "192.168.1.10", ReplacedWith: obfuscatedStaticIPv4,
 					Counter: map[string]uint{
 						"192.168.1.10": 4,
@@ -422,7 +431,7 @@ func TestIPObfuscatorWithCount(t *testing.T) {
 			name:   "valid ipv4 address dots and dashes",
 			input:  "same IP 192.168.1.10 address 192-168-1-10 repeated 192.168.1.10 four times 192-168-1-10",
 			output: "same IP xxx.xxx.xxx.xxx address xxx.xxx.xxx.xxx repeated xxx.xxx.xxx.xxx four times xxx.xxx.xxx.xxx",
-			report: ReplacementReport{[]Replacement{
+			report: ReplacementReport{Replacements: []Replacement{
 				{Canonical: "192.168.1.10", ReplacedWith: obfuscatedStaticIPv4,
 					Counter: map[string]uint{
 						"192.168.1.10": 2,
@@ -433,7 +442,7 @@ func TestIPObfuscatorWithCount(t *testing.T) {
 		},
 	} {
 		t.Run(tc.name, func(t *testing.T) {
-			o, err := NewIPObfuscator(schema.ObfuscateReplacementTypeStatic, NewSimpleTracker())
+			o, err := NewIPObfuscator(nil, nil, schema.ObfuscateReplacementTypeStatic, ReplacementFormat{}, NewSimpleTracker())
 			require.NoError(t, err)
 			output := o.Contents(tc.input)
 			assert.Equal(t, tc.output, output)
@@ -441,3 +450,113 @@ func TestIPObfuscatorWithCount(t *testing.T) {
 		})
 	}
 }
+
+func TestIPv6Detection(t *testing.T) {
+	for _, tc := range []struct {
+		name   string
+		input  string
+		output string
+	}{
+		{name: "hex words", input: "it catches :face:bad and face:b00c: too", output: "it catches :face:bad and face:b00c: too"},
+		{name: "double colon separators", input: "client-ca::kube-system::extension-apiserver-authentication", output: "client-ca::kube-system::extension-apiserver-authentication"},
+		{name: "condition reasons", input: "OAuthServerRouteEndpointAccessibleController_SyncError::OAuthServerServiceEndpointAccessibleController_SyncError", output: "OAuthServerRouteEndpointAccessibleController_SyncError::OAuthServerServiceEndpointAccessibleController_SyncError"},
+		{name: "cert paths", input: "serving-cert::/var/run/secrets/serving-cert/tls.crt::/etc/kubernetes/static-pod-certs", output: "serving-cert::/var/run/secrets/serving-cert/tls.crt::/etc/kubernetes/static-pod-certs"},
+		{name: "timestamps", input: "2021-09-14T14:42:37.280882200Z [14/Sep/2021 14:42:37]", output: "2021-09-14T14:42:37.280882200Z [14/Sep/2021 14:42:37]"},
+		{name: "mac address", input: "MacAddress:a2:51:44:2d:8a:97", output: "MacAddress:a2:51:44:2d:8a:97"},
+		{name: "unspecified address", input: "listening on [::]:8443", output: "listening on [::]:8443"},
+		{name: "bracketed with port", input: "dial tcp [fd00::1]:6443: connect: connection refused", output: "dial tcp [x-ipv6-0000000001-x]:6443: connect: connection refused"},
+		{name: "zone id", input: "ping fe80::1%eth0 and fe80::1%25ens3", output: "ping x-ipv6-0000000001-x%eth0 and x-ipv6-0000000001-x%25ens3"},
+		{name: "key value", input: "addr:fd00::1 address=fd00::2", output: "addr:x-ipv6-0000000001-x address=x-ipv6-0000000002-x"},
+		{name: "end of sentence", input: "unreachable: fd00::1. retrying fd00::2:", output: "unreachable: x-ipv6-0000000001-x. retrying x-ipv6-0000000002-x:"},
+		{name: "ipv4-mapped", input: "::ffff:10.0.0.1 and ::FFFF:127.0.0.1", output: "::ffff:x-ipv4-0000000001-x and ::FFFF:127.0.0.1"},
+		{name: "ipv4-mapped hex", input: "::ffff:a00:1 is 10.0.0.1, ::ffff:7f00:1 is not replaced", output: "x-ipv4-0000000001-x is x-ipv4-0000000001-x, ::ffff:7f00:1 is not replaced"},
+		{name: "ipv4-embedded", input: "nat64 64:ff9b::10.0.0.1", output: "nat64 x-ipv6-0000000001-x"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			o, err := NewIPObfuscator(nil, nil, schema.ObfuscateReplacementTypeConsistent, ReplacementFormat{}, NewSimpleTracker())
+			require.NoError(t, err)
+			assert.Equal(t, tc.output, o.Contents(tc.input))
+		})
+	}
+}
+
+// TestIPv6Corpus runs the obfuscator over lines taken from the must-gathers in test/files, every odd line of the corpus
+// is an input and the following line is its expected output.
+func TestIPv6Corpus(t *testing.T) {
+	corpus, err := ioutil.ReadFile("testfiles/ipv6_corpus.txt")
+	require.NoError(t, err)
+	lines := strings.Split(strings.TrimSuffix(string(corpus), "\n"), "\n")
+	require.Equal(t, 0, len(lines)%2)
+
+	o, err := NewIPObfuscator(nil, nil, schema.ObfuscateReplacementTypeConsistent, ReplacementFormat{}, NewSimpleTracker())
+	require.NoError(t, err)
+	for i := 0; i < len(lines); i += 2 {
+		assert.Equal(t, lines[i+1], o.Contents(lines[i]), "corpus line %d", i+1)
+	}
+}
+
+func TestIPObfuscatorRanges(t *testing.T) {
+	for _, tc := range []struct {
+		name       string
+		exclude    []string
+		onlyRanges []string
+		input      string
+		output     string
+	}{
+		{
+			name:   "default exclusions",
+			input:  "listen on 127.0.0.1, 127-0-0-1, 0.0.0.0, ::1 and [::]",
+			output: "listen on 127.0.0.1, 127-0-0-1, 0.0.0.0, ::1 and [::]",
+		},
+		{
+			name:    "single addresses",
+			exclude: []string{"169.254.169.254", "8.8.8.8", "fd00::1"},
+			input:   "metadata 169.254.169.254 dns 8.8.8.8 node 10.0.0.1 fd00::1 fd00::2",
+			output:  "metadata 169.254.169.254 dns 8.8.8.8 node x-ipv4-0000000001-x fd00::1 x-ipv6-0000000001-x",
+		},
+		{
+			name:    "cidrs",
+			exclude: []string{"172.30.0.0/16", "fd02::/112"},
+			input:   "service 172.30.0.1 fd02::a pod 10.128.0.14",
+			output:  "service 172.30.0.1 fd02::a pod x-ipv4-0000000001-x",
+		},
+		{
+			name:    "presets",
+			exclude: []string{"loopback", "Link-Local", "service-network"},
+			input:   "127.0.0.53 169.254.0.1 fe80::1 172.30.0.10 10.0.0.1",
+			output:  "127.0.0.53 169.254.0.1 fe80::1 172.30.0.10 x-ipv4-0000000001-x",
+		},
+		{
+			name:       "only public",
+			onlyRanges: []string{"public"},
+			input:      "private 10.0.0.1 192.168.1.1 fd00::1 public 8.8.8.8 2001:db8::1",
+			output:     "private 10.0.0.1 192.168.1.1 fd00::1 public x-ipv4-0000000001-x x-ipv6-0000000001-x",
+		},
+		{
+			name:       "only private",
+			onlyRanges: []string{"private"},
+			input:      "private 10.0.0.1 192.168.1.1 fd00::1 public 8.8.8.8 2001:db8::1",
+			output:     "private x-ipv4-0000000001-x x-ipv4-0000000002-x x-ipv6-0000000001-x public 8.8.8.8 2001:db8::1",
+		},
+		{
+			name:       "exclusions take precedence",
+			exclude:    []string{"service-network"},
+			onlyRanges: []string{"private"},
+			input:      "10.0.0.1 172.30.0.1",
+			output:     "x-ipv4-0000000001-x 172.30.0.1",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			o, err := NewIPObfuscator(tc.exclude, tc.onlyRanges, schema.ObfuscateReplacementTypeConsistent, ReplacementFormat{}, NewSimpleTracker())
+			require.NoError(t, err)
+			assert.Equal(t, tc.output, o.Contents(tc.input))
+		})
+	}
+}
+
+func TestIPObfuscatorInvalidRanges(t *testing.T) {
+	_, err := NewIPObfuscator([]string{"10.0.0.0/33"}, nil, schema.ObfuscateReplacementTypeConsistent, ReplacementFormat{}, NewSimpleTracker())
+	assert.EqualError(t, err, "invalid IP range '10.0.0.0/33', must be an IP address, a CIDR or one of the presets: link-local, loopback, multicast, private, public, service-network, unspecified")
+	_, err = NewIPObfuscator(nil, []string{"internal"}, schema.ObfuscateReplacementTypeConsistent, ReplacementFormat{}, NewSimpleTracker())
+	assert.Error(t, err)
+}
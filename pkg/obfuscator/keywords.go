@@ -1,41 +1,163 @@
 package obfuscator
 
 import (
+	"bufio"
+	"fmt"
+	"os"
+	"sort"
 	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/openshift/must-gather-clean/pkg/schema"
+)
+
+const (
+	staticKeywordReplacement            = "x-keyword-x"
+	consistentKeywordTemplate           = "x-keyword-%010d-x"
+	maximumSupportedObfuscationKeywords = 9999999999
 )
 
 type keywordsObfuscator struct {
 	ReplacementTracker
-	replacements map[string]string
+	matcher *ahoCorasick
+	// replacements holds the fixed replacement of each pattern, patterns without one are generated by obfsGenerator
+	replacements  []*string
+	wholeWords    bool
+	obfsGenerator *generator
 }
 
 func (o *keywordsObfuscator) Path(name string) string {
-	return replace(name, o.replacements, o.ReplacementTracker)
+	return applySpans(name, o.spans(name))
 }
 
 func (o *keywordsObfuscator) Contents(contents string) string {
-	return replace(contents, o.replacements, o.ReplacementTracker)
+	return applySpans(contents, o.spans(contents))
+}
+
+func (o *keywordsObfuscator) PathSpans(name string) []Span {
+	return o.spans(name)
+}
+
+func (o *keywordsObfuscator) ContentsSpans(contents string) []Span {
+	return o.spans(contents)
+}
+
+func (o *keywordsObfuscator) spans(input string) []Span {
+	if o.matcher == nil {
+		return nil
+	}
+	matches := o.matcher.findLeftmostLongest(input, func(m acMatch) bool {
+		return !o.wholeWords || isWholeWord(input, m.start, m.end)
+	})
+
+	spans := make([]Span, 0, len(matches))
+	for _, m := range matches {
+		keyword := o.matcher.patterns[m.pattern]
+		original := input[m.start:m.end]
+		r := o.replacements[m.pattern]
+		spans = append(spans, Span{Start: m.start, End: m.end, Replace: func() string {
+			if r != nil {
+				return o.GenerateIfAbsent(keyword, original, 1, func() string {
+					return *r
+				})
+			}
+			return o.obfsGenerator.generateReplacement(keyword, original, 1, o.ReplacementTracker)
+		}})
+	}
+	return spans
+}
+
+func isWordRune(r rune) bool {
+	return r == '_' || unicode.IsLetter(r) || unicode.IsDigit(r)
 }
 
-func replace(name string, replacements map[string]string, reporter ReplacementTracker) string {
-	for keyword, replacement := range replacements {
-		if strings.Contains(name, keyword) {
-			cnt := uint(strings.Count(name, keyword))
-			_ = reporter.GenerateIfAbsent(keyword, keyword, cnt, func() string {
-				return replacement
-			})
-			name = strings.Replace(name, keyword, replacement, -1)
+// isWholeWord returns true if the match is neither preceded nor followed by a letter, digit or underscore.
+func isWholeWord(s string, start int, end int) bool {
+	if start > 0 {
+		if r, _ := utf8.DecodeLastRuneInString(s[:start]); isWordRune(r) {
+			return false
 		}
 	}
-	return name
+	if end < len(s) {
+		if r, _ := utf8.DecodeRuneInString(s[end:]); isWordRune(r) {
+			return false
+		}
+	}
+	return true
 }
 
-// NewKeywordsObfuscator returns an Obfuscator which replace all occurrences of keys in the map
-// passed to it with the value of the key.
-func NewKeywordsObfuscator(replacements map[string]string) ReportingObfuscator {
+// ReadKeywordsFile returns the keywords in the given file, one per line. Empty lines and comments starting with '#' are
+// skipped, surrounding whitespace is trimmed.
+func ReadKeywordsFile(path string) ([]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open keywords file %s: %w", path, err)
+	}
+	defer f.Close()
+
+	var keywords []string
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		keywords = append(keywords, line)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read keywords file %s: %w", path, err)
+	}
+	return keywords, nil
+}
+
+// NewKeywordsObfuscator returns an Obfuscator which replaces all occurrences of the keys in the map passed to it with
+// the value of the key, and all occurrences of the keywords with a replacement according to the replacementType. When
+// keywords overlap, the one that starts first and then the longest one is replaced.
+func NewKeywordsObfuscator(replacements map[string]string, keywords []string, caseInsensitive bool, wholeWords bool, replacementType schema.ObfuscateReplacementType, format ReplacementFormat) (ReportingObfuscator, error) {
+	err := format.validate(maximumSupportedObfuscationKeywords, func(s string) bool {
+		probe, err := NewKeywordsObfuscator(replacements, keywords, caseInsensitive, wholeWords, replacementType, ReplacementFormat{})
+		return err == nil && probe.Contents(s) != s
+	})
+	if err != nil {
+		return nil, err
+	}
+
 	tracker := NewSimpleTrackerMap(replacements)
-	return &keywordsObfuscator{
+	o := &keywordsObfuscator{
 		ReplacementTracker: tracker,
-		replacements:       replacements,
+		wholeWords:         wholeWords,
+	}
+
+	// the patterns are sorted, so that duplicates (e.g. when matching case-insensitive) are resolved deterministically
+	var patterns []string
+	for k := range replacements {
+		if k != "" {
+			patterns = append(patterns, k)
+		}
+	}
+	sort.Strings(patterns)
+	for _, k := range patterns {
+		r := replacements[k]
+		o.replacements = append(o.replacements, &r)
+	}
+
+	if len(keywords) > 0 {
+		g, err := newFormattedGenerator(format, consistentKeywordTemplate, staticKeywordReplacement, maximumSupportedObfuscationKeywords, replacementType)
+		if err != nil {
+			return nil, err
+		}
+		o.obfsGenerator = g
+		for _, k := range keywords {
+			if _, ok := replacements[k]; !ok && k != "" {
+				patterns = append(patterns, k)
+				o.replacements = append(o.replacements, nil)
+			}
+		}
+	}
+
+	if len(patterns) > 0 {
+		o.matcher = newAhoCorasick(patterns, caseInsensitive)
 	}
+	return o, nil
 }
@@ -1,9 +1,16 @@
 package obfuscator
 
 import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 
+	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+
+	"github.com/openshift/must-gather-clean/pkg/schema"
 )
 
 func TestNewKeywordsObfuscator(t *testing.T) {
@@ -21,7 +28,7 @@ func TestNewKeywordsObfuscator(t *testing.T) {
 			},
 			input:          "input with unique-word",
 			expectedOutput: "input with replacement",
-			expectLegend: ReplacementReport{[]Replacement{
+			expectLegend: ReplacementReport{Replacements: []Replacement{
 				{Canonical: "unique-word", ReplacedWith: "replacement",
 					Counter: map[string]uint{
 						"unique-word": 1,
@@ -35,7 +42,7 @@ func TestNewKeywordsObfuscator(t *testing.T) {
 			},
 			input:          "input with common words",
 			expectedOutput: "input with common words",
-			expectLegend: ReplacementReport{[]Replacement{
+			expectLegend: ReplacementReport{Replacements: []Replacement{
 				{Canonical: "unique-word", ReplacedWith: "replacement",
 					Counter: map[string]uint{
 						"unique-word": 0,
@@ -50,7 +57,7 @@ func TestNewKeywordsObfuscator(t *testing.T) {
 			},
 			input:          "input with first-unique word",
 			expectedOutput: "input with first-replacement word",
-			expectLegend: ReplacementReport{[]Replacement{
+			expectLegend: ReplacementReport{Replacements: []Replacement{
 				{Canonical: "first-unique", ReplacedWith: "first-replacement",
 					Counter: map[string]uint{
 						"first-unique": 1,
@@ -69,7 +76,7 @@ func TestNewKeywordsObfuscator(t *testing.T) {
 			},
 			input:          "input with foo foo foo times foo",
 			expectedOutput: "input with four four four times four",
-			expectLegend: ReplacementReport{[]Replacement{
+			expectLegend: ReplacementReport{Replacements: []Replacement{
 				{Canonical: "foo", ReplacedWith: "four",
 					Counter: map[string]uint{
 						"foo": 4,
@@ -82,9 +89,134 @@ func TestNewKeywordsObfuscator(t *testing.T) {
 		},
 	} {
 		t.Run(tc.name, func(t *testing.T) {
-			o := NewKeywordsObfuscator(tc.replacements)
+			o, err := NewKeywordsObfuscator(tc.replacements, nil, false, false, schema.ObfuscateReplacementTypeStatic, ReplacementFormat{})
+			require.NoError(t, err)
 			require.Equal(t, tc.expectedOutput, o.Contents(tc.input))
 			replacementReportsMatch(t, tc.expectLegend, o.Report())
 		})
 	}
 }
+
+func TestKeywordsObfuscatorOptions(t *testing.T) {
+	for _, tc := range []struct {
+		name            string
+		replacements    map[string]string
+		keywords        []string
+		caseInsensitive bool
+		wholeWords      bool
+		replacementType schema.ObfuscateReplacementType
+		input           string
+		output          string
+	}{
+		{
+			name:         "longest match first",
+			replacements: map[string]string{"node": "a", "node1": "b", "node1.example": "c"},
+			input:        "node1.example.com node1 node2",
+			output:       "c.com b a2",
+		},
+		{
+			name:         "leftmost match first",
+			replacements: map[string]string{"abc": "x", "bcd": "y"},
+			input:        "abcd",
+			output:       "xd",
+		},
+		{
+			name:         "no chained replacements",
+			replacements: map[string]string{"a": "b", "b": "c"},
+			input:        "a b",
+			output:       "b c",
+		},
+		{
+			name:            "case insensitive",
+			replacements:    map[string]string{"Secret": "hidden"},
+			caseInsensitive: true,
+			input:           "secret SECRET SeCrEt",
+			output:          "hidden hidden hidden",
+		},
+		{
+			name:         "case sensitive by default",
+			replacements: map[string]string{"Secret": "hidden"},
+			input:        "secret Secret",
+			output:       "secret hidden",
+		},
+		{
+			name:         "whole words",
+			replacements: map[string]string{"node1": "host-a"},
+			wholeWords:   true,
+			input:        "node1 node10 xnode1 node1.example.com node1_x",
+			output:       "host-a node10 xnode1 host-a.example.com node1_x",
+		},
+		{
+			name:         "whole words falls back to shorter match",
+			replacements: map[string]string{"node": "a", "node1": "b"},
+			wholeWords:   true,
+			input:        "node1x node node1",
+			output:       "node1x a b",
+		},
+		{
+			name:            "keywords consistent",
+			keywords:        []string{"alpha.example.com", "beta.example.com"},
+			replacementType: schema.ObfuscateReplacementTypeConsistent,
+			input:           "beta.example.com alpha.example.com beta.example.com",
+			output:          "x-keyword-0000000001-x x-keyword-0000000002-x x-keyword-0000000001-x",
+		},
+		{
+			name:            "keywords static",
+			keywords:        []string{"alpha.example.com"},
+			replacementType: schema.ObfuscateReplacementTypeStatic,
+			input:           "alpha.example.com",
+			output:          "x-keyword-x",
+		},
+		{
+			name:            "replacements take precedence over keywords",
+			replacements:    map[string]string{"alpha": "omega"},
+			keywords:        []string{"alpha", "beta"},
+			replacementType: schema.ObfuscateReplacementTypeStatic,
+			input:           "alpha beta",
+			output:          "omega x-keyword-x",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			o, err := NewKeywordsObfuscator(tc.replacements, tc.keywords, tc.caseInsensitive, tc.wholeWords, tc.replacementType, ReplacementFormat{})
+			require.NoError(t, err)
+			assert.Equal(t, tc.output, o.Contents(tc.input))
+		})
+	}
+}
+
+func TestKeywordsObfuscatorCaseInsensitiveReport(t *testing.T) {
+	o, err := NewKeywordsObfuscator(map[string]string{"secret": "hidden"}, nil, true, false, schema.ObfuscateReplacementTypeStatic, ReplacementFormat{})
+	require.NoError(t, err)
+	o.Contents("secret SECRET secret")
+	replacementReportsMatch(t, ReplacementReport{Replacements: []Replacement{
+		{Canonical: "secret", ReplacedWith: "hidden", Counter: map[string]uint{"secret": 2, "SECRET": 1}},
+	}}, o.Report())
+}
+
+func TestKeywordsObfuscatorManyKeywords(t *testing.T) {
+	var keywords []string
+	var input []string
+	for i := 0; i < 5000; i++ {
+		keywords = append(keywords, fmt.Sprintf("host-%d.example.com", i))
+		input = append(input, fmt.Sprintf("host-%d.example.com", i*2))
+	}
+	o, err := NewKeywordsObfuscator(nil, keywords, false, true, schema.ObfuscateReplacementTypeStatic, ReplacementFormat{})
+	require.NoError(t, err)
+	output := o.Contents(strings.Join(input, " "))
+	// only the first half of the hosts in the input are keywords
+	assert.Equal(t, 2500, strings.Count(output, staticKeywordReplacement))
+	assert.Equal(t, 2500, strings.Count(output, ".example.com"))
+}
+
+func TestReadKeywordsFile(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "keywords.txt")
+	require.NoError(t, os.WriteFile(path, []byte("# customer hosts\nalpha.example.com\n\n  beta.example.com  \n"), 0600))
+
+	keywords, err := ReadKeywordsFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"alpha.example.com", "beta.example.com"}, keywords)
+
+	_, err = ReadKeywordsFile(filepath.Join(dir, "missing.txt"))
+	assert.Error(t, err)
+}
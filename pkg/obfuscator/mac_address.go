@@ -15,40 +15,134 @@ const (
 	maximumSupportedObfuscationsMAC = 9999999999
 )
 
+var (
+	// this regex differs from the standard `(?:[0-9a-fA-F]([:-])?){12}`, to not match very frequently happening UUIDs in K8s
+	separatedMacPattern = regexp.MustCompile(`([0-9a-fA-F]{2}[:-]){5}[0-9a-fA-F]{2}`)
+	// dotted MACs are commonly used by Cisco, for example '6980.6fe6.7c05'
+	dottedMacPattern = regexp.MustCompile(`[0-9a-fA-F]{4}\.[0-9a-fA-F]{4}\.[0-9a-fA-F]{4}`)
+	// squashed MACs like '69806FE67C05' are indistinguishable from other hex strings (e.g. the last group of a UUID),
+	// thus they are only matched after a key that hints at a MAC address, for example "mac: ", "HWaddr " or "link/ether ".
+	// The key must be a whole word, so that "machineID: ", "whether " or "together " do not count.
+	squashedMacPattern = regexp.MustCompile(`[0-9a-fA-F]{12}`)
+	squashedMacContext = regexp.MustCompile(`(?i)\b(mac|hwaddr|ether)([ _-]?addr(ess)?)?(["']?\s*[:=]\s*["']?|\s+)$`)
+)
+
+// macContextWindow is the number of bytes in front of a squashed MAC that are checked for a hinting key.
+const macContextWindow = 32
+
+type macPattern struct {
+	regex *regexp.Regexp
+	// boundary returns true when the match is not part of a larger token and should be replaced.
+	boundary func(s string, start int, end int) bool
+}
+
 type macAddressObfuscator struct {
 	ReplacementTracker
-	regex         *regexp.Regexp
+	patterns      []macPattern
 	obfsGenerator generator
 }
 
 func (m *macAddressObfuscator) Path(s string) string {
-	return m.Contents(s)
+	return applySpans(s, m.spans(s))
 }
 
 func (m *macAddressObfuscator) Contents(s string) string {
-	matches := m.regex.FindAllString(s, -1)
-	for _, mac := range matches {
-		// normalizing the MAC Address string to the Uppercase to avoid the duplicate reporting
-		match := strings.ToUpper(strings.ReplaceAll(mac, "-", ":"))
-		replacement := m.obfsGenerator.generateReplacement(match, mac, 1, m.ReplacementTracker)
-		s = strings.ReplaceAll(s, mac, replacement)
+	return applySpans(s, m.spans(s))
+}
+
+func (m *macAddressObfuscator) PathSpans(s string) []Span {
+	return m.spans(s)
+}
+
+func (m *macAddressObfuscator) ContentsSpans(s string) []Span {
+	return m.spans(s)
+}
+
+// spans returns the matches of all notations, a match of an earlier pattern takes precedence over an overlapping one.
+func (m *macAddressObfuscator) spans(s string) []Span {
+	var spans [][]Span
+	for _, p := range m.patterns {
+		var patternSpans []Span
+		for _, idx := range p.regex.FindAllStringIndex(s, -1) {
+			start, end := idx[0], idx[1]
+			if p.boundary != nil && !p.boundary(s, start, end) {
+				continue
+			}
+			mac := s[start:end]
+			patternSpans = append(patternSpans, Span{Start: start, End: end, Replace: func() string {
+				return m.obfsGenerator.generateReplacement(canonicalMac(mac), mac, 1, m.ReplacementTracker)
+			}})
+		}
+		spans = append(spans, patternSpans)
 	}
-	return s
+	return mergeSpans(spans...)
 }
 
-func NewMacAddressObfuscator(replacementType schema.ObfuscateReplacementType, tracker ReplacementTracker) (ReportingObfuscator, error) {
-	// this regex differs from the standard `(?:[0-9a-fA-F]([:-])?){12}`, to not match very frequently happening UUIDs in K8s
-	// the main culprit is the support for squashed MACs like '69806FE67C05', which won't be supported with the below
-	regex := regexp.MustCompile(`([0-9a-fA-F]{2}[:-]){5}[0-9a-fA-F]{2}`)
+// canonicalMac normalizes all supported notations to the upper-cased colon form to avoid the duplicate reporting,
+// for example '6980.6fe6.7c05' and '69806FE67C05' both become '69:80:6F:E6:7C:05'.
+func canonicalMac(mac string) string {
+	squashed := strings.ToUpper(strings.NewReplacer(":", "", "-", "", ".", "").Replace(mac))
+	var sb strings.Builder
+	for i := 0; i < len(squashed); i += 2 {
+		if i > 0 {
+			sb.WriteByte(':')
+		}
+		sb.WriteString(squashed[i : i+2])
+	}
+	return sb.String()
+}
 
+func isMacTokenCharacter(c byte) bool {
+	return c == '.' || c == '-' || c == ':' || c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || (c >= '0' && c <= '9')
+}
+
+func isHexCharacter(c byte) bool {
+	return (c >= 'a' && c <= 'f') || (c >= 'A' && c <= 'F') || (c >= '0' && c <= '9')
+}
+
+// isMacTokenBoundary ensures the match is a standalone token, for example not a part of a UUID or a version string.
+// A dot that ends a sentence is a boundary, as long as no further hex group follows it.
+func isMacTokenBoundary(s string, start int, end int) bool {
+	if start > 0 && isMacTokenCharacter(s[start-1]) {
+		return false
+	}
+	if end == len(s) || !isMacTokenCharacter(s[end]) {
+		return true
+	}
+	return s[end] == '.' && (end+1 == len(s) || !isHexCharacter(s[end+1]))
+}
+
+func isSquashedMac(s string, start int, end int) bool {
+	if !isMacTokenBoundary(s, start, end) {
+		return false
+	}
+	contextStart := start - macContextWindow
+	if contextStart < 0 {
+		contextStart = 0
+	}
+	return squashedMacContext.MatchString(s[contextStart:start])
+}
+
+func NewMacAddressObfuscator(replacementType schema.ObfuscateReplacementType, format ReplacementFormat, tracker ReplacementTracker) (ReportingObfuscator, error) {
+	err := format.validate(maximumSupportedObfuscationsMAC, func(s string) bool {
+		probe, err := NewMacAddressObfuscator(replacementType, ReplacementFormat{}, NewSimpleTracker())
+		return err == nil && probe.Contents(s) != s
+	})
+	if err != nil {
+		return nil, err
+	}
 	// creating a new generator object
-	generator, err := newGenerator(consistentMACTemplate, staticMacReplacement, maximumSupportedObfuscationsMAC, replacementType)
+	generator, err := newFormattedGenerator(format, consistentMACTemplate, staticMacReplacement, maximumSupportedObfuscationsMAC, replacementType)
 	if err != nil {
 		return nil, err
 	}
 	return &macAddressObfuscator{
 		ReplacementTracker: tracker,
-		regex:              regex,
-		obfsGenerator:      *generator,
+		patterns: []macPattern{
+			{regex: separatedMacPattern},
+			{regex: dottedMacPattern, boundary: isMacTokenBoundary},
+			{regex: squashedMacPattern, boundary: isSquashedMac},
+		},
+		obfsGenerator: *generator,
 	}, nil
 }
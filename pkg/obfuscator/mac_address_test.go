@@ -10,13 +10,13 @@ import (
 )
 
 func TestMacStaticReplacement(t *testing.T) {
-	o, _ := NewMacAddressObfuscator(schema.ObfuscateReplacementTypeStatic, NewSimpleTracker())
+	o, _ := NewMacAddressObfuscator(schema.ObfuscateReplacementTypeStatic, ReplacementFormat{}, NewSimpleTracker())
 	assert.Equal(t, staticMacReplacement, o.Contents("29-7E-8C-8C-60-C9"))
 	assert.Equal(t, map[string]string{"29-7E-8C-8C-60-C9": staticMacReplacement}, o.Report().AsMap())
 }
 
 func TestMacConsistentReplacement(t *testing.T) {
-	o, _ := NewMacAddressObfuscator(schema.ObfuscateReplacementTypeConsistent, NewSimpleTracker())
+	o, _ := NewMacAddressObfuscator(schema.ObfuscateReplacementTypeConsistent, ReplacementFormat{}, NewSimpleTracker())
 	assert.Equal(t, "x-mac-0000000001-x", o.Contents("29-7E-8C-8C-60-C9"))
 	// This testcase reports both the original detected MAC address as well as the normalized MAC address
 	assert.Equal(t, map[string]string{"29-7E-8C-8C-60-C9": "x-mac-0000000001-x"}, o.Report().AsMap())
@@ -25,7 +25,7 @@ func TestMacConsistentReplacement(t *testing.T) {
 func TestMacReplacementManyMatchLine(t *testing.T) {
 	input := "ss eb:a1:2a:b2:09:bf as 29-7E-8C-8C-60-C9 with some stuff around it and lowercased eb-a1-2a-b2-09-bf"
 	expected := "ss xx:xx:xx:xx:xx:xx as xx:xx:xx:xx:xx:xx with some stuff around it and lowercased xx:xx:xx:xx:xx:xx"
-	o, _ := NewMacAddressObfuscator(schema.ObfuscateReplacementTypeStatic, NewSimpleTracker())
+	o, _ := NewMacAddressObfuscator(schema.ObfuscateReplacementTypeStatic, ReplacementFormat{}, NewSimpleTracker())
 	assert.Equal(t, expected, o.Contents(input))
 	assert.Equal(t, map[string]string{
 		"eb:a1:2a:b2:09:bf": staticMacReplacement,
@@ -52,7 +52,7 @@ func TestMacReplacementStatic(t *testing.T) {
 		{name: "mac as guid", input: "4a5299ac-6104-479d-aed4-b79faedffcb4", expectedOutput: "4a5299ac-6104-479d-aed4-b79faedffcb4"},
 	} {
 		t.Run(tc.name, func(t *testing.T) {
-			o, _ := NewMacAddressObfuscator(schema.ObfuscateReplacementTypeStatic, NewSimpleTracker())
+			o, _ := NewMacAddressObfuscator(schema.ObfuscateReplacementTypeStatic, ReplacementFormat{}, NewSimpleTracker())
 			assert.Equal(t, tc.expectedOutput, o.Contents(tc.input))
 		})
 	}
@@ -69,7 +69,7 @@ func TestMACObfuscatorWithCount(t *testing.T) {
 			input:          "mac bf-51-a4-1b-7d-0b 16-7C-44-26-24-14 BF:51:A4:1B:7D:0B 16:7C:44:26:24:14 BF-51-A4-1B-7D-0B bf:51:a4:1b:7d:0b",
 			expectedOutput: fmt.Sprintf("mac %s %s %s %s %s %s", staticMacReplacement, staticMacReplacement, staticMacReplacement, staticMacReplacement, staticMacReplacement, staticMacReplacement),
 			report: ReplacementReport{
-				[]Replacement{
+				Replacements: []Replacement{
 					{Canonical: "16:7C:44:26:24:14", ReplacedWith: staticMacReplacement,
 						Counter: map[string]uint{
 							"16:7C:44:26:24:14": 1,
@@ -87,7 +87,7 @@ func TestMACObfuscatorWithCount(t *testing.T) {
 		},
 	} {
 		t.Run(tc.name, func(t *testing.T) {
-			o, err := NewMacAddressObfuscator(schema.ObfuscateReplacementTypeStatic, NewSimpleTracker())
+			o, err := NewMacAddressObfuscator(schema.ObfuscateReplacementTypeStatic, ReplacementFormat{}, NewSimpleTracker())
 			require.NoError(t, err)
 			assert.Equal(t, tc.expectedOutput, o.Contents(tc.input))
 			replacementReportsMatch(t, tc.report, o.Report())
@@ -152,7 +152,7 @@ func TestMACConsistentObfuscator(t *testing.T) {
 		},
 	} {
 		t.Run(tc.name, func(t *testing.T) {
-			o, err := NewMacAddressObfuscator(schema.ObfuscateReplacementTypeConsistent, NewSimpleTracker())
+			o, err := NewMacAddressObfuscator(schema.ObfuscateReplacementTypeConsistent, ReplacementFormat{}, NewSimpleTracker())
 			require.NoError(t, err)
 			for i := 0; i < len(tc.input); i++ {
 				assert.Equal(t, tc.output[i], o.Contents(tc.input[i]))
@@ -161,3 +161,53 @@ func TestMACConsistentObfuscator(t *testing.T) {
 		})
 	}
 }
+
+func TestMacSquashedAndDottedReplacement(t *testing.T) {
+	for _, tc := range []struct {
+		name   string
+		input  string
+		output string
+		report map[string]string
+	}{
+		{name: "squashed without context", input: "id 69806FE67C05", output: "id 69806FE67C05", report: map[string]string{}},
+		{name: "squashed in a sentence", input: "the mac address of 69806fe67c05", output: "the mac address of 69806fe67c05", report: map[string]string{}},
+		{name: "squashed yaml key", input: "  macAddress: 69806FE67C05", output: "  macAddress: x-mac-0000000001-x", report: map[string]string{"69806FE67C05": "x-mac-0000000001-x"}},
+		{name: "squashed json key", input: `{"mac": "69806fe67c05"}`, output: `{"mac": "x-mac-0000000001-x"}`, report: map[string]string{"69806fe67c05": "x-mac-0000000001-x"}},
+		{name: "squashed firmware dump", input: "Permanent MAC Address: 69806FE67C05", output: "Permanent MAC Address: x-mac-0000000001-x", report: map[string]string{"69806FE67C05": "x-mac-0000000001-x"}},
+		{name: "squashed hwaddr", input: "eth0 HWaddr 69806FE67C05", output: "eth0 HWaddr x-mac-0000000001-x", report: map[string]string{"69806FE67C05": "x-mac-0000000001-x"}},
+		{name: "squashed uuid after key", input: "mac: 4a5299ac-6104-479d-aed4-b79faedffcb4", output: "mac: 4a5299ac-6104-479d-aed4-b79faedffcb4", report: map[string]string{}},
+		{name: "squashed longer hex after key", input: "ether: 69806FE67C05AB", output: "ether: 69806FE67C05AB", report: map[string]string{}},
+		{name: "squashed after machineID", input: "machineID: 69806FE67C05", output: "machineID: 69806FE67C05", report: map[string]string{}},
+		{name: "squashed after whether", input: "check whether 69806FE67C05", output: "check whether 69806FE67C05", report: map[string]string{}},
+		{name: "squashed after together", input: "together 69806FE67C05", output: "together 69806FE67C05", report: map[string]string{}},
+		{name: "squashed link ether", input: "link/ether 69806fe67c05", output: "link/ether x-mac-0000000001-x", report: map[string]string{"69806fe67c05": "x-mac-0000000001-x"}},
+		{name: "squashed mac_address key", input: "mac_address=69806fe67c05", output: "mac_address=x-mac-0000000001-x", report: map[string]string{"69806fe67c05": "x-mac-0000000001-x"}},
+		{name: "dotted", input: "interface 6980.6fe6.7c05 is up", output: "interface x-mac-0000000001-x is up", report: map[string]string{"6980.6fe6.7c05": "x-mac-0000000001-x"}},
+		{name: "dotted within a longer token", input: "version 1234.5678.9012.3456", output: "version 1234.5678.9012.3456", report: map[string]string{}},
+		{name: "dotted end of sentence", input: "interface 6980.6fe6.7c05.", output: "interface x-mac-0000000001-x.", report: map[string]string{"6980.6fe6.7c05": "x-mac-0000000001-x"}},
+		{name: "dotted end of sentence in a line", input: "the interface is 6980.6fe6.7c05. It is up", output: "the interface is x-mac-0000000001-x. It is up", report: map[string]string{"6980.6fe6.7c05": "x-mac-0000000001-x"}},
+		{name: "squashed end of sentence", input: "link/ether 69806fe67c05.", output: "link/ether x-mac-0000000001-x.", report: map[string]string{"69806fe67c05": "x-mac-0000000001-x"}},
+		{name: "squashed end of sentence in a line", input: "HWaddr 69806FE67C05. Next line", output: "HWaddr x-mac-0000000001-x. Next line", report: map[string]string{"69806FE67C05": "x-mac-0000000001-x"}},
+		{name: "squashed followed by a hex group", input: "ether: 69806FE67C05.AB", output: "ether: 69806FE67C05.AB", report: map[string]string{}},
+		{
+			name:   "all notations share the same replacement",
+			input:  "69:80:6F:E6:7C:05 6980.6fe6.7c05 link/ether 69806fe67c05",
+			output: "x-mac-0000000001-x x-mac-0000000001-x link/ether x-mac-0000000001-x",
+			report: map[string]string{
+				"69:80:6F:E6:7C:05": "x-mac-0000000001-x",
+				"6980.6fe6.7c05":    "x-mac-0000000001-x",
+				"69806fe67c05":      "x-mac-0000000001-x",
+			},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			o, err := NewMacAddressObfuscator(schema.ObfuscateReplacementTypeConsistent, ReplacementFormat{}, NewSimpleTracker())
+			require.NoError(t, err)
+			assert.Equal(t, tc.output, o.Contents(tc.input))
+			assert.Equal(t, tc.report, o.Report().AsMap())
+			if len(o.Report().Replacements) > 0 {
+				assert.Equal(t, "69:80:6F:E6:7C:05", o.Report().Replacements[0].Canonical)
+			}
+		})
+	}
+}
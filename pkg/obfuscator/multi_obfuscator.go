@@ -1,33 +1,180 @@
 package obfuscator
 
+import (
+	"sort"
+	"strings"
+
+	"github.com/openshift/must-gather-clean/pkg/kube"
+)
+
+// Match is a value that was replaced by an obfuscator of a MultiObfuscator.
+type Match struct {
+	// Obfuscator is the index of the obfuscator in the MultiObfuscator it was first created with
+	Obfuscator int
+	// Offset is the position of the original value within the input
+	Offset       int
+	Original     string
+	ReplacedWith string
+}
+
+// MatchFunc is called for every replaced value.
+type MatchFunc func(Match)
+
 type MultiObfuscator struct {
 	obfuscators []ReportingObfuscator
+	// priorities decide which obfuscator wins when matches overlap, the higher priority wins
+	priorities []int
+	// indices are the positions of the obfuscators in the MultiObfuscator they were selected from
+	indices []int
+}
+
+// spansFunc returns the matches of the obfuscator at index i within s.
+type spansFunc func(i int, o SpanObfuscator, s string) []Span
+
+// replaceFunc replaces the matches of the obfuscator at index i within s.
+type replaceFunc func(i int, o ReportingObfuscator, s string) string
+
+func pathSpans(_ int, o SpanObfuscator, s string) []Span {
+	return o.PathSpans(s)
+}
+
+func contentsSpans(_ int, o SpanObfuscator, s string) []Span {
+	return o.ContentsSpans(s)
+}
+
+func replacePath(_ int, o ReportingObfuscator, s string) string {
+	return o.Path(s)
+}
+
+func replaceContents(_ int, o ReportingObfuscator, s string) string {
+	return o.Contents(s)
 }
 
 func (m *MultiObfuscator) Path(s string) string {
-	for _, obfuscator := range m.obfuscators {
-		s = obfuscator.Path(s)
+	return m.obfuscate(s, pathSpans, replacePath, nil)
+}
+
+func (m *MultiObfuscator) Contents(s string) string {
+	return m.obfuscate(s, contentsSpans, replaceContents, nil)
+}
+
+// PathMatches obfuscates the path like Path and calls fn for every replaced value.
+func (m *MultiObfuscator) PathMatches(s string, fn MatchFunc) string {
+	return m.obfuscate(s, pathSpans, replacePath, fn)
+}
+
+// ContentsMatches obfuscates the contents like Contents and calls fn for every replaced value. Obfuscators that don't
+// report their matches, like custom ones, are not reported. The matches of obfuscators in chain mode are reported with
+// their offset within the output of the preceding obfuscators.
+func (m *MultiObfuscator) ContentsMatches(s string, fn MatchFunc) string {
+	return m.obfuscate(s, contentsSpans, replaceContents, fn)
+}
+
+// ContentsBlockMatches obfuscates a block of joined lines like ContentsMatches. The obfuscators for which multiline
+// returns true, given their index in the MultiObfuscator they were first created with, match across the lines of the
+// block. All others match within each of its lines. Both share a single pass over the block, so the priorities and the
+// order of the obfuscators apply across them and no obfuscator sees the replacements of another.
+func (m *MultiObfuscator) ContentsBlockMatches(block string, multiline func(i int) bool, fn MatchFunc) string {
+	spans := func(i int, o SpanObfuscator, s string) []Span {
+		if multiline(m.indices[i]) {
+			return o.ContentsSpans(s)
+		}
+		var spans []Span
+		start := 0
+		for _, line := range strings.SplitAfter(s, "\n") {
+			for _, span := range o.ContentsSpans(line) {
+				spans = append(spans, Span{Start: start + span.Start, End: start + span.End, Replace: span.Replace})
+			}
+			start += len(line)
+		}
+		return spans
 	}
+	replace := func(i int, o ReportingObfuscator, s string) string {
+		if multiline(m.indices[i]) {
+			return o.Contents(s)
+		}
+		var sb strings.Builder
+		for _, line := range strings.SplitAfter(s, "\n") {
+			sb.WriteString(o.Contents(line))
+		}
+		return sb.String()
+	}
+	return m.obfuscate(block, spans, replace, fn)
+}
 
-	return s
+// obfuscate runs the obfuscators in order of their definition. Consecutive obfuscators that report their matches share
+// a single pass over the input, so that every part of it is replaced at most once and no obfuscator sees the
+// replacements of another. All other obfuscators, for example those in chain mode, run on the output of the
+// preceding obfuscators.
+func (m *MultiObfuscator) obfuscate(s string, spans spansFunc, replace replaceFunc, fn MatchFunc) string {
+	var pending []int
+	for i, o := range m.obfuscators {
+		if _, ok := o.(SpanObfuscator); ok {
+			pending = append(pending, i)
+			continue
+		}
+		s = m.replaceSpans(s, pending, spans, fn)
+		pending = nil
+		if c, ok := o.(*chainObfuscator); ok && fn != nil {
+			if so, ok := c.ReportingObfuscator.(SpanObfuscator); ok {
+				s = applySpans(s, m.reportSpans(i, s, spans(i, so, s), fn))
+				continue
+			}
+		}
+		s = replace(i, o, s)
+	}
+	return m.replaceSpans(s, pending, spans, fn)
 }
 
-func (m *MultiObfuscator) Contents(s string) string {
-	for _, obfuscator := range m.obfuscators {
-		s = obfuscator.Contents(s)
+// replaceSpans collects the matches of the given obfuscators and replaces them in one go. Overlapping matches are
+// resolved by the priority of the obfuscators, with ties going to the obfuscator that was defined first.
+func (m *MultiObfuscator) replaceSpans(s string, indices []int, spans spansFunc, fn MatchFunc) string {
+	if len(indices) == 0 {
+		return s
 	}
+	sort.SliceStable(indices, func(i, j int) bool {
+		return m.priorities[indices[i]] > m.priorities[indices[j]]
+	})
 
-	return s
+	matches := make([][]Span, 0, len(indices))
+	for _, i := range indices {
+		matches = append(matches, m.reportSpans(i, s, spans(i, m.obfuscators[i].(SpanObfuscator), s), fn))
+	}
+	return applySpans(s, mergeSpans(matches...))
+}
+
+// reportSpans wraps the replacements of the spans of the obfuscator at index i, so that fn is called for the spans that
+// are applied.
+func (m *MultiObfuscator) reportSpans(i int, s string, spans []Span, fn MatchFunc) []Span {
+	if fn == nil {
+		return spans
+	}
+	for j := range spans {
+		span := spans[j]
+		spans[j].Replace = func() string {
+			replacement := span.Replace()
+			fn(Match{Obfuscator: m.indices[i], Offset: span.Start, Original: s[span.Start:span.End], ReplacedWith: replacement})
+			return replacement
+		}
+	}
+	return spans
 }
 
 func (m *MultiObfuscator) Report() ReplacementReport {
 	var replacements []Replacement
+	var skipped map[string]uint
 	for _, obfuscator := range m.obfuscators {
 		report := obfuscator.Report()
 		replacements = append(replacements, report.Replacements...)
+		for s, count := range report.Skipped {
+			if skipped == nil {
+				skipped = map[string]uint{}
+			}
+			skipped[s] += count
+		}
 	}
 
-	return ReplacementReport{Replacements: replacements}
+	return ReplacementReport{Replacements: replacements, Skipped: skipped}
 }
 
 func (m *MultiObfuscator) ReportPerObfuscator() []ReplacementReport {
@@ -39,6 +186,63 @@ func (m *MultiObfuscator) ReportPerObfuscator() []ReplacementReport {
 	return multiReport
 }
 
+// Select returns a MultiObfuscator with the obfuscators for which keep returns true, given their index. The obfuscators
+// are shared, so their replacements stay consistent and are reported by both.
+func (m *MultiObfuscator) Select(keep func(i int) bool) *MultiObfuscator {
+	selected := &MultiObfuscator{}
+	for i := range m.obfuscators {
+		if keep(i) {
+			selected.add(m, i, m.obfuscators[i])
+		}
+	}
+	return selected
+}
+
+// ForFile returns a MultiObfuscator with the obfuscators that apply to the file at the given path, dropping those whose
+// scope excludes it. The resources are nil if the file does not contain Kubernetes resources. The receiver is returned
+// as-is when no obfuscator is scoped.
+func (m *MultiObfuscator) ForFile(path string, resources *kube.ResourceListWithPath) *MultiObfuscator {
+	isScoped := false
+	for _, o := range m.obfuscators {
+		if _, ok := o.(scoped); ok {
+			isScoped = true
+			break
+		}
+	}
+	if !isScoped {
+		return m
+	}
+
+	selected := &MultiObfuscator{}
+	for i, o := range m.obfuscators {
+		if s, ok := o.(scoped); ok {
+			if !s.appliesTo(path, resources) {
+				continue
+			}
+			o = s.unscoped()
+		}
+		selected.add(m, i, o)
+	}
+	return selected
+}
+
+// add appends the given obfuscator, which takes the place of the obfuscator at index i of the MultiObfuscator from.
+func (m *MultiObfuscator) add(from *MultiObfuscator, i int, o ReportingObfuscator) {
+	m.obfuscators = append(m.obfuscators, o)
+	m.priorities = append(m.priorities, from.priorities[i])
+	m.indices = append(m.indices, from.indices[i])
+}
+
 func NewMultiObfuscator(o []ReportingObfuscator) *MultiObfuscator {
-	return &MultiObfuscator{obfuscators: o}
+	return NewPrioritizedMultiObfuscator(o, make([]int, len(o)))
+}
+
+// NewPrioritizedMultiObfuscator returns a MultiObfuscator where the priority at the same index as the obfuscator
+// decides which obfuscator replaces overlapping matches.
+func NewPrioritizedMultiObfuscator(o []ReportingObfuscator, priorities []int) *MultiObfuscator {
+	indices := make([]int, len(o))
+	for i := range indices {
+		indices[i] = i
+	}
+	return &MultiObfuscator{obfuscators: o, priorities: priorities, indices: indices}
 }
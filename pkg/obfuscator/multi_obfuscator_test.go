@@ -4,7 +4,9 @@ import (
 	"strings"
 	"testing"
 
+	"github.com/openshift/must-gather-clean/pkg/schema"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 type splitObfuscator struct {
@@ -103,3 +105,150 @@ func TestMultiObfuscationReportMulti(t *testing.T) {
 		{"must be split thrice": "be split thrice"},
 		{"be split thrice": "split thrice"}}, reportsAsMap)
 }
+
+func TestMultiObfuscationSpans(t *testing.T) {
+	newMac := func() ReportingObfuscator {
+		o, err := NewMacAddressObfuscator(schema.ObfuscateReplacementTypeConsistent, ReplacementFormat{}, NewSimpleTracker())
+		require.NoError(t, err)
+		return o
+	}
+	newDigits := func() ReportingObfuscator {
+		o, err := NewRegexObfuscator("[0-9]{10}", nil, schema.ObfuscateReplacementTypeStatic, ReplacementFormat{}, NewSimpleTracker())
+		require.NoError(t, err)
+		return o
+	}
+	newHost := func() ReportingObfuscator {
+		o, err := NewRegexObfuscator("host-[0-9]+", nil, schema.ObfuscateReplacementTypeStatic, ReplacementFormat{}, NewSimpleTracker())
+		require.NoError(t, err)
+		return o
+	}
+	newKeywords := func() ReportingObfuscator {
+		o, err := NewKeywordsObfuscator(map[string]string{"secret-host": "1234567890"}, nil, false, false, schema.ObfuscateReplacementTypeStatic, ReplacementFormat{})
+		require.NoError(t, err)
+		return o
+	}
+
+	for _, tc := range []struct {
+		name        string
+		obfuscators []ReportingObfuscator
+		priorities  []int
+		input       string
+		output      string
+	}{
+		{
+			name:        "replacements are not matched again",
+			obfuscators: []ReportingObfuscator{newMac(), newDigits()},
+			priorities:  []int{0, 0},
+			input:       "mac 29-7E-8C-8C-60-C9 id 1234567890",
+			output:      "mac x-mac-0000000001-x id xxxxxxxxxx",
+		},
+		{
+			name:        "first definition wins overlapping matches",
+			obfuscators: []ReportingObfuscator{newHost(), newKeywords()},
+			priorities:  []int{0, 0},
+			input:       "connect to secret-host-42",
+			output:      "connect to secret-xxxxxxx",
+		},
+		{
+			name:        "higher priority wins overlapping matches",
+			obfuscators: []ReportingObfuscator{newHost(), newKeywords()},
+			priorities:  []int{0, 1},
+			input:       "connect to secret-host-42",
+			output:      "connect to 1234567890-42",
+		},
+		{
+			name:        "chain matches the replacements of the preceding obfuscators",
+			obfuscators: []ReportingObfuscator{newKeywords(), NewChainObfuscator(newDigits())},
+			priorities:  []int{0, 0},
+			input:       "connect to secret-host",
+			output:      "connect to xxxxxxxxxx",
+		},
+		{
+			name:        "without chain the replacement of a keyword stays",
+			obfuscators: []ReportingObfuscator{newKeywords(), newDigits()},
+			priorities:  []int{0, 0},
+			input:       "connect to secret-host",
+			output:      "connect to 1234567890",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			mo := NewPrioritizedMultiObfuscator(tc.obfuscators, tc.priorities)
+			assert.Equal(t, tc.output, mo.Contents(tc.input))
+			assert.Equal(t, tc.output, mo.Path(tc.input))
+		})
+	}
+}
+
+func TestMultiObfuscationSpansReportOnlyReplaced(t *testing.T) {
+	digits, err := NewRegexObfuscator("[0-9]+", nil, schema.ObfuscateReplacementTypeStatic, ReplacementFormat{}, NewSimpleTracker())
+	require.NoError(t, err)
+	mac, err := NewMacAddressObfuscator(schema.ObfuscateReplacementTypeConsistent, ReplacementFormat{}, NewSimpleTracker())
+	require.NoError(t, err)
+
+	mo := NewPrioritizedMultiObfuscator([]ReportingObfuscator{digits, mac}, []int{0, 1})
+	assert.Equal(t, "x-mac-0000000001-x x", mo.Contents("29-7E-8C-8C-60-C9 7"))
+	assert.Equal(t, []map[string]string{
+		{"7": "x"},
+		{"29-7E-8C-8C-60-C9": "x-mac-0000000001-x"},
+	}, []map[string]string{mo.ReportPerObfuscator()[0].AsMap(), mo.ReportPerObfuscator()[1].AsMap()})
+}
+
+func TestMultiObfuscationMatches(t *testing.T) {
+	digits, err := NewRegexObfuscator("[0-9]+", nil, schema.ObfuscateReplacementTypeStatic, ReplacementFormat{}, NewSimpleTracker())
+	require.NoError(t, err)
+	mac, err := NewMacAddressObfuscator(schema.ObfuscateReplacementTypeConsistent, ReplacementFormat{}, NewSimpleTracker())
+	require.NoError(t, err)
+	keywords, err := NewKeywordsObfuscator(map[string]string{"x-mac": "hw"}, nil, false, false, schema.ObfuscateReplacementTypeStatic, ReplacementFormat{})
+	require.NoError(t, err)
+	custom := &splitObfuscator{tracker: NewSimpleTracker()}
+
+	mo := NewPrioritizedMultiObfuscator([]ReportingObfuscator{custom, digits, mac, NewChainObfuscator(keywords)}, []int{0, 0, 1, 0})
+	selected := mo.Select(func(i int) bool { return i > 0 })
+
+	var matches []Match
+	output := selected.ContentsMatches("29-7E-8C-8C-60-C9 7", func(m Match) {
+		matches = append(matches, m)
+	})
+	assert.Equal(t, "hw-0000000001-x x", output)
+	assert.Equal(t, []Match{
+		{Obfuscator: 2, Offset: 0, Original: "29-7E-8C-8C-60-C9", ReplacedWith: "x-mac-0000000001-x"},
+		{Obfuscator: 1, Offset: 18, Original: "7", ReplacedWith: "x"},
+		{Obfuscator: 3, Offset: 0, Original: "x-mac", ReplacedWith: "hw"},
+	}, matches)
+
+	matches = nil
+	assert.Equal(t, "x", mo.PathMatches("a b 7", func(m Match) {
+		matches = append(matches, m)
+	}))
+	assert.Equal(t, []Match{{Obfuscator: 1, Offset: 0, Original: "7", ReplacedWith: "x"}}, matches)
+}
+
+func TestMultiObfuscationContentsBlockMatches(t *testing.T) {
+	key, err := NewRegexObfuscator(`(?s)BEGIN KEY.*?END KEY`, nil, schema.ObfuscateReplacementTypeStatic, ReplacementFormat{Static: "<key>"}, NewSimpleTracker())
+	require.NoError(t, err)
+	keywords, err := NewKeywordsObfuscator(map[string]string{"key": "word"}, nil, false, true, schema.ObfuscateReplacementTypeStatic, ReplacementFormat{})
+	require.NoError(t, err)
+	digits, err := NewRegexObfuscator(`[0-9]+\n?[0-9]*`, nil, schema.ObfuscateReplacementTypeStatic, ReplacementFormat{}, NewSimpleTracker())
+	require.NoError(t, err)
+	multiline := func(i int) bool { return i == 1 }
+
+	// the line obfuscators neither see the replacement of the multiline obfuscator nor match across lines
+	mo := NewMultiObfuscator([]ReportingObfuscator{keywords, key, digits})
+	var matches []Match
+	output := mo.ContentsBlockMatches("BEGIN KEY\nabc\nEND KEY\n12\n34 key\n", multiline, func(m Match) {
+		matches = append(matches, m)
+	})
+	assert.Equal(t, "<key>\nxx\nxx word\n", output)
+	assert.Equal(t, []Match{
+		{Obfuscator: 1, Offset: 0, Original: "BEGIN KEY\nabc\nEND KEY", ReplacedWith: "<key>"},
+		{Obfuscator: 2, Offset: 22, Original: "12\n", ReplacedWith: "xx\n"},
+		{Obfuscator: 2, Offset: 25, Original: "34", ReplacedWith: "xx"},
+		{Obfuscator: 0, Offset: 28, Original: "key", ReplacedWith: "word"},
+	}, matches)
+
+	// the line obfuscator with the higher priority wins over the overlapping multiline match
+	upperKeywords, err := NewKeywordsObfuscator(map[string]string{"KEY": "word"}, nil, false, true, schema.ObfuscateReplacementTypeStatic, ReplacementFormat{})
+	require.NoError(t, err)
+	mo = NewPrioritizedMultiObfuscator([]ReportingObfuscator{upperKeywords, key}, []int{1, 0})
+	assert.Equal(t, "BEGIN word\nabc\nEND word\n", mo.ContentsBlockMatches("BEGIN KEY\nabc\nEND KEY\n", multiline, nil))
+}
@@ -30,7 +30,7 @@ func (d NoopObfuscator) Report() ReplacementReport {
 			},
 		})
 	}
-	return ReplacementReport{r}
+	return ReplacementReport{Replacements: r}
 }
 
 func (d NoopObfuscator) ReportReplacement(a string, b string) {
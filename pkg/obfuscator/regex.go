@@ -3,42 +3,155 @@ package obfuscator
 import (
 	"fmt"
 	"regexp"
+	"sort"
+	"strconv"
 	"strings"
+
+	"github.com/openshift/must-gather-clean/pkg/schema"
+)
+
+const (
+	consistentRegexTemplate           = "x-regex-%010d-x"
+	maximumSupportedObfuscationsRegex = 9999999999
 )
 
 type regexObfuscator struct {
 	ReplacementTracker
 	pattern *regexp.Regexp
+	// groups are the indices of the capture groups to obfuscate, the whole match (0) is obfuscated if there are none
+	groups []int
+	// maskStatic replaces every character with 'x' when no custom static replacement was given
+	maskStatic    bool
+	obfsGenerator generator
 }
 
 func (r *regexObfuscator) Path(s string) string {
-	return r.replace(s)
+	return applySpans(s, r.matchSpans(s))
 }
 
 func (r *regexObfuscator) Contents(s string) string {
-	return r.replace(s)
+	return applySpans(s, r.matchSpans(s))
+}
+
+func (r *regexObfuscator) PathSpans(s string) []Span {
+	return r.matchSpans(s)
+}
+
+func (r *regexObfuscator) ContentsSpans(s string) []Span {
+	return r.matchSpans(s)
 }
 
-func (r *regexObfuscator) replace(input string) string {
-	output := input
-	matches := r.pattern.FindAllString(input, -1)
-	for _, m := range matches {
-		replacement := strings.Repeat("x", len(m))
-		r.GenerateIfAbsent(m, m, 1, func() string {
+func (r *regexObfuscator) matchSpans(input string) []Span {
+	var spans []Span
+	last := 0
+	for _, m := range r.pattern.FindAllStringSubmatchIndex(input, -1) {
+		for _, span := range r.spans(m) {
+			start, end := span[0], span[1]
+			// groups that didn't participate, are empty or nested in another group have nothing (more) to obfuscate
+			if start < last || start >= end {
+				continue
+			}
+			match := input[start:end]
+			spans = append(spans, Span{Start: start, End: end, Replace: func() string {
+				return r.replacement(match)
+			}})
+			last = end
+		}
+	}
+	return spans
+}
+
+// spans returns the start and end indices of the configured groups within a match, ordered by their position.
+func (r *regexObfuscator) spans(match []int) [][2]int {
+	var spans [][2]int
+	for _, g := range r.groups {
+		if match[2*g] >= 0 {
+			spans = append(spans, [2]int{match[2*g], match[2*g+1]})
+		}
+	}
+	sort.Slice(spans, func(i, j int) bool {
+		if spans[i][0] == spans[j][0] {
+			return spans[i][1] > spans[j][1]
+		}
+		return spans[i][0] < spans[j][0]
+	})
+	return spans
+}
+
+func (r *regexObfuscator) replacement(s string) string {
+	if r.maskStatic {
+		replacement := maskKeepingLineBreaks(s)
+		return r.GenerateIfAbsent(s, s, 1, func() string {
 			return replacement
 		})
-		output = strings.ReplaceAll(output, m, replacement)
 	}
-	return output
+	return r.obfsGenerator.generateReplacement(s, s, 1, r.ReplacementTracker)
+}
+
+// maskKeepingLineBreaks replaces every byte with 'x', except line breaks of matches across multiple lines.
+func maskKeepingLineBreaks(s string) string {
+	masked := []byte(strings.Repeat("x", len(s)))
+	for i := 0; i < len(s); i++ {
+		if s[i] == '\n' {
+			masked[i] = '\n'
+		}
+	}
+	return string(masked)
+}
+
+// captureGroupIndices resolves the given capture group names and numbers to their indices in the pattern.
+func captureGroupIndices(pattern *regexp.Regexp, captureGroups []string) ([]int, error) {
+	if len(captureGroups) == 0 {
+		return []int{0}, nil
+	}
+
+	var groups []int
+	for _, g := range captureGroups {
+		index := pattern.SubexpIndex(g)
+		if n, err := strconv.Atoi(g); err == nil {
+			index = n
+		}
+		if index < 1 || index > pattern.NumSubexp() {
+			return nil, fmt.Errorf("capture group %s does not exist in pattern %s", g, pattern)
+		}
+		groups = append(groups, index)
+	}
+	return groups, nil
 }
 
-func NewRegexObfuscator(pattern string, tracker ReplacementTracker) (ReportingObfuscator, error) {
+// NewRegexObfuscator returns an obfuscator that replaces the matches of the pattern, or only the given capture groups
+// of it. A Static replacement without a custom format replaces every character with 'x'.
+func NewRegexObfuscator(pattern string, captureGroups []string, replacementType schema.ObfuscateReplacementType, format ReplacementFormat, tracker ReplacementTracker) (ReportingObfuscator, error) {
 	regex, err := regexp.Compile(pattern)
 	if err != nil {
 		return nil, fmt.Errorf("pattern %s is invalid: %w", pattern, err)
 	}
+	groups, err := captureGroupIndices(regex, captureGroups)
+	if err != nil {
+		return nil, err
+	}
+
+	// an unset replacement type keeps the default masking, like it is done for the schema default
+	if replacementType == "" {
+		replacementType = schema.ObfuscateReplacementTypeStatic
+	}
+	err = format.validate(maximumSupportedObfuscationsRegex, func(s string) bool {
+		probe, err := NewRegexObfuscator(pattern, captureGroups, replacementType, ReplacementFormat{}, NewSimpleTracker())
+		return err == nil && probe.Contents(s) != s
+	})
+	if err != nil {
+		return nil, err
+	}
+	generator, err := newFormattedGenerator(format, consistentRegexTemplate, "", maximumSupportedObfuscationsRegex, replacementType)
+	if err != nil {
+		return nil, err
+	}
+
 	return &regexObfuscator{
 		pattern:            regex,
+		groups:             groups,
+		maskStatic:         replacementType == schema.ObfuscateReplacementTypeStatic && format.Static == "",
+		obfsGenerator:      *generator,
 		ReplacementTracker: tracker,
 	}, nil
 }
@@ -5,6 +5,8 @@ import (
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+
+	"github.com/openshift/must-gather-clean/pkg/schema"
 )
 
 func TestRegexObfuscator(t *testing.T) {
@@ -42,7 +44,7 @@ func TestRegexObfuscator(t *testing.T) {
 		},
 	} {
 		t.Run(tc.name, func(t *testing.T) {
-			o, err := NewRegexObfuscator(tc.pattern, NewSimpleTracker())
+			o, err := NewRegexObfuscator(tc.pattern, nil, schema.ObfuscateReplacementTypeStatic, ReplacementFormat{}, NewSimpleTracker())
 			require.NoError(t, err)
 			output := o.Contents(tc.input)
 			assert.Equal(t, tc.output, output)
@@ -50,3 +52,106 @@ func TestRegexObfuscator(t *testing.T) {
 		})
 	}
 }
+
+func TestRegexObfuscatorReplacements(t *testing.T) {
+	for _, tc := range []struct {
+		name              string
+		pattern           string
+		captureGroups     []string
+		replacementType   schema.ObfuscateReplacementType
+		template          string
+		staticReplacement string
+		input             string
+		output            string
+		report            map[string]string
+	}{
+		{
+			name:            "named capture group",
+			pattern:         `password=(?P<pw>\S+)`,
+			captureGroups:   []string{"pw"},
+			replacementType: schema.ObfuscateReplacementTypeStatic,
+			input:           "login user=admin password=hunter2 ok",
+			output:          "login user=admin password=xxxxxxx ok",
+			report:          map[string]string{"hunter2": "xxxxxxx"},
+		},
+		{
+			name:            "numbered capture groups",
+			pattern:         `(\w+)@(\w+)\.com`,
+			captureGroups:   []string{"1", "2"},
+			replacementType: schema.ObfuscateReplacementTypeConsistent,
+			input:           "mail alice@example.com and bob@example.com",
+			output:          "mail x-regex-0000000001-x@x-regex-0000000002-x.com and x-regex-0000000003-x@x-regex-0000000002-x.com",
+			report: map[string]string{
+				"alice":   "x-regex-0000000001-x",
+				"example": "x-regex-0000000002-x",
+				"bob":     "x-regex-0000000003-x",
+			},
+		},
+		{
+			name:            "nested capture groups",
+			pattern:         `token: ((\w+)-\w+)`,
+			captureGroups:   []string{"1", "2"},
+			replacementType: schema.ObfuscateReplacementTypeStatic,
+			input:           "token: abc-def",
+			output:          "token: xxxxxxx",
+			report:          map[string]string{"abc-def": "xxxxxxx"},
+		},
+		{
+			name:            "optional capture group",
+			pattern:         `key(=(\w+))?`,
+			captureGroups:   []string{"2"},
+			replacementType: schema.ObfuscateReplacementTypeStatic,
+			input:           "key key=value",
+			output:          "key key=xxxxx",
+			report:          map[string]string{"value": "xxxxx"},
+		},
+		{
+			name:            "consistent with template",
+			pattern:         `sha256~[A-Za-z0-9_-]+`,
+			replacementType: schema.ObfuscateReplacementTypeConsistent,
+			template:        "token-%06d",
+			input:           "sha256~abc sha256~def sha256~abc",
+			output:          "token-000001 token-000002 token-000001",
+			report:          map[string]string{"sha256~abc": "token-000001", "sha256~def": "token-000002"},
+		},
+		{
+			name:              "custom static replacement",
+			pattern:           `password=(\S+)`,
+			captureGroups:     []string{"1"},
+			replacementType:   schema.ObfuscateReplacementTypeStatic,
+			staticReplacement: "<redacted>",
+			input:             "password=hunter2 password=letmein",
+			output:            "password=<redacted> password=<redacted>",
+			report:            map[string]string{"hunter2": "<redacted>", "letmein": "<redacted>"},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			o, err := NewRegexObfuscator(tc.pattern, tc.captureGroups, tc.replacementType, ReplacementFormat{Template: tc.template, Static: tc.staticReplacement}, NewSimpleTracker())
+			require.NoError(t, err)
+			assert.Equal(t, tc.output, o.Contents(tc.input))
+			assert.Equal(t, tc.report, o.Report().AsMap())
+		})
+	}
+}
+
+func TestRegexObfuscatorInvalid(t *testing.T) {
+	for _, tc := range []struct {
+		name          string
+		pattern       string
+		captureGroups []string
+		template      string
+		err           string
+	}{
+		{name: "invalid pattern", pattern: `(`, err: "pattern ( is invalid: error parsing regexp: missing closing ): `(`"},
+		{name: "unknown group name", pattern: `(?P<a>\w+)`, captureGroups: []string{"b"}, err: "capture group b does not exist in pattern (?P<a>\\w+)"},
+		{name: "group number out of range", pattern: `(\w+)`, captureGroups: []string{"2"}, err: "capture group 2 does not exist in pattern (\\w+)"},
+		{name: "template without verb", pattern: `\w+`, template: "token", err: "template 'token' must contain exactly one integer verb, for example 'token-%06d'"},
+		{name: "template with string verb", pattern: `\w+`, template: "token-%s", err: "template 'token-%s' must contain exactly one integer verb, for example 'token-%06d'"},
+		{name: "template with two verbs", pattern: `\w+`, template: "%d-%d", err: "template '%d-%d' must contain exactly one integer verb, for example 'token-%06d'"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := NewRegexObfuscator(tc.pattern, tc.captureGroups, schema.ObfuscateReplacementTypeConsistent, ReplacementFormat{Template: tc.template}, NewSimpleTracker())
+			assert.EqualError(t, err, tc.err)
+		})
+	}
+}
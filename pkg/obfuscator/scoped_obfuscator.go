@@ -0,0 +1,145 @@
+package obfuscator
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/openshift/must-gather-clean/pkg/kube"
+	"github.com/openshift/must-gather-clean/pkg/omitter"
+	"github.com/openshift/must-gather-clean/pkg/schema"
+)
+
+// scoped is implemented by obfuscators that only apply to some files, see MultiObfuscator.ForFile.
+type scoped interface {
+	// appliesTo returns true if the obfuscator should run on the file at the given path, resources are nil if the file
+	// does not contain Kubernetes resources
+	appliesTo(path string, resources *kube.ResourceListWithPath) bool
+	// unscoped returns the wrapped obfuscator
+	unscoped() ReportingObfuscator
+}
+
+// scopedObfuscator restricts the wrapped obfuscator to the files matching its globs and resource selectors. The scope is
+// only evaluated by MultiObfuscator.ForFile, used on its own the obfuscator applies to any input.
+type scopedObfuscator struct {
+	ReportingObfuscator
+	include          []omitter.FileOmitter
+	exclude          []omitter.FileOmitter
+	includeResources []omitter.KubernetesResourceOmitter
+	excludeResources []omitter.KubernetesResourceOmitter
+}
+
+func (s *scopedObfuscator) appliesTo(path string, resources *kube.ResourceListWithPath) bool {
+	if matchesAny(s.exclude, path) || matchesAnyResource(s.excludeResources, resources) {
+		return false
+	}
+	if len(s.include) > 0 && !matchesAny(s.include, path) {
+		return false
+	}
+	return len(s.includeResources) == 0 || matchesAnyResource(s.includeResources, resources)
+}
+
+func (s *scopedObfuscator) unscoped() ReportingObfuscator {
+	return s.ReportingObfuscator
+}
+
+// scopedSpanObfuscator wraps obfuscators that report their matches.
+type scopedSpanObfuscator struct {
+	*scopedObfuscator
+	spanObfuscator SpanObfuscator
+}
+
+func (s *scopedSpanObfuscator) PathSpans(input string) []Span {
+	return s.spanObfuscator.PathSpans(input)
+}
+
+func (s *scopedSpanObfuscator) ContentsSpans(input string) []Span {
+	return s.spanObfuscator.ContentsSpans(input)
+}
+
+func matchesAny(globs []omitter.FileOmitter, path string) bool {
+	for _, g := range globs {
+		// the globs were validated upfront, so there are no errors to expect
+		if match, _ := g.OmitPath(path); match {
+			return true
+		}
+	}
+	return false
+}
+
+func matchesAnyResource(selectors []omitter.KubernetesResourceOmitter, resources *kube.ResourceListWithPath) bool {
+	if resources == nil {
+		return false
+	}
+	for _, s := range selectors {
+		// matching resources never fails
+		if match, _ := s.OmitKubeResource(resources); match {
+			return true
+		}
+	}
+	return false
+}
+
+func newGlobs(patterns []string) ([]omitter.FileOmitter, error) {
+	var globs []omitter.FileOmitter
+	for _, p := range patterns {
+		glob, err := omitter.NewFilenamePatternOmitter(p)
+		if err != nil {
+			return nil, err
+		}
+		if _, err := glob.OmitPath(""); err != nil {
+			return nil, fmt.Errorf("invalid path glob '%s': %w", p, err)
+		}
+		globs = append(globs, glob)
+	}
+	return globs, nil
+}
+
+func newResourceSelectors(selectors []schema.KubernetesResourceSelector) ([]omitter.KubernetesResourceOmitter, error) {
+	var matchers []omitter.KubernetesResourceOmitter
+	for _, s := range selectors {
+		if s.Kind == "" {
+			return nil, errors.New("no kind specified in kubernetes resource selector")
+		}
+		kind := s.Kind
+		matcher, err := omitter.NewKubernetesResourceOmitter(s.ApiVersion, &kind, s.Namespaces)
+		if err != nil {
+			return nil, err
+		}
+		matchers = append(matchers, matcher)
+	}
+	return matchers, nil
+}
+
+// NewScopedObfuscator wraps the given obfuscator, so that it only applies to the files matching the given paths and
+// containing the given Kubernetes resources. If there is no scope, the obfuscator is returned as-is.
+func NewScopedObfuscator(paths *schema.ObfuscatePaths, resources *schema.ObfuscateKubernetesResources, obfuscator ReportingObfuscator) (ReportingObfuscator, error) {
+	if paths == nil {
+		paths = &schema.ObfuscatePaths{}
+	}
+	if resources == nil {
+		resources = &schema.ObfuscateKubernetesResources{}
+	}
+	if len(paths.Include) == 0 && len(paths.Exclude) == 0 && len(resources.Include) == 0 && len(resources.Exclude) == 0 {
+		return obfuscator, nil
+	}
+
+	s := &scopedObfuscator{ReportingObfuscator: obfuscator}
+	var err error
+	if s.include, err = newGlobs(paths.Include); err != nil {
+		return nil, err
+	}
+	if s.exclude, err = newGlobs(paths.Exclude); err != nil {
+		return nil, err
+	}
+	if s.includeResources, err = newResourceSelectors(resources.Include); err != nil {
+		return nil, err
+	}
+	if s.excludeResources, err = newResourceSelectors(resources.Exclude); err != nil {
+		return nil, err
+	}
+
+	if so, ok := obfuscator.(SpanObfuscator); ok {
+		return &scopedSpanObfuscator{scopedObfuscator: s, spanObfuscator: so}, nil
+	}
+	return s, nil
+}
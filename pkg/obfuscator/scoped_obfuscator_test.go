@@ -0,0 +1,186 @@
+package obfuscator
+
+import (
+	"testing"
+
+	"github.com/openshift/must-gather-clean/pkg/kube"
+	"github.com/openshift/must-gather-clean/pkg/schema"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestScopedObfuscationByPath(t *testing.T) {
+	for _, tc := range []struct {
+		name   string
+		paths  schema.ObfuscatePaths
+		path   string
+		output string
+	}{
+		{
+			name:   "included",
+			paths:  schema.ObfuscatePaths{Include: []string{"pods/*.log"}},
+			path:   "pods/api.log",
+			output: "x-regex-0000000001-x",
+		},
+		{
+			name:   "not included",
+			paths:  schema.ObfuscatePaths{Include: []string{"pods/*.log"}},
+			path:   "pods/api.yaml",
+			output: "secret-word",
+		},
+		{
+			name:   "excluded",
+			paths:  schema.ObfuscatePaths{Exclude: []string{"pods/*.log"}},
+			path:   "pods/api.log",
+			output: "secret-word",
+		},
+		{
+			name:   "not excluded",
+			paths:  schema.ObfuscatePaths{Exclude: []string{"pods/*.log"}},
+			path:   "pods/api.yaml",
+			output: "x-regex-0000000001-x",
+		},
+		{
+			name:   "exclude wins over include",
+			paths:  schema.ObfuscatePaths{Include: []string{"pods/*"}, Exclude: []string{"pods/*.log"}},
+			path:   "pods/api.log",
+			output: "secret-word",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			regex, err := NewRegexObfuscator("secret-word", nil, schema.ObfuscateReplacementTypeConsistent, ReplacementFormat{}, NewSimpleTracker())
+			require.NoError(t, err)
+			o, err := NewScopedObfuscator(&tc.paths, nil, regex)
+			require.NoError(t, err)
+			require.Implements(t, (*SpanObfuscator)(nil), o)
+
+			mo := NewMultiObfuscator([]ReportingObfuscator{o}).ForFile(tc.path, nil)
+			assert.Equal(t, tc.output, mo.Contents("secret-word"))
+			assert.Equal(t, tc.output, mo.Path("secret-word"))
+		})
+	}
+}
+
+func TestScopedObfuscationByResource(t *testing.T) {
+	route := &kube.ResourceListWithPath{ResourceList: kube.ResourceList{Items: []kube.Resource{
+		{ApiVersion: "route.openshift.io/v1", Kind: "Route", Metadata: kube.Metadata{Namespace: "openshift-console"}},
+	}}}
+	routeList := &kube.ResourceListWithPath{ResourceList: kube.ResourceList{Items: []kube.Resource{
+		{ApiVersion: "v1", Kind: "Service", Metadata: kube.Metadata{Namespace: "openshift-console"}},
+		{ApiVersion: "route.openshift.io/v1", Kind: "Route", Metadata: kube.Metadata{Namespace: "openshift-console"}},
+	}}}
+	clusterOperator := &kube.ResourceListWithPath{ResourceList: kube.ResourceList{Items: []kube.Resource{
+		{ApiVersion: "config.openshift.io/v1", Kind: "ClusterOperator"},
+	}}}
+	routeApiVersion := "route.openshift.io/v1"
+
+	for _, tc := range []struct {
+		name      string
+		resources schema.ObfuscateKubernetesResources
+		paths     schema.ObfuscatePaths
+		file      *kube.ResourceListWithPath
+		applies   bool
+	}{
+		{
+			name:      "included kind",
+			resources: schema.ObfuscateKubernetesResources{Include: []schema.KubernetesResourceSelector{{Kind: "Route"}, {Kind: "Node"}}},
+			file:      route,
+			applies:   true,
+		},
+		{
+			name:      "included kind within a list",
+			resources: schema.ObfuscateKubernetesResources{Include: []schema.KubernetesResourceSelector{{Kind: "Route"}}},
+			file:      routeList,
+			applies:   true,
+		},
+		{
+			name:      "not included kind",
+			resources: schema.ObfuscateKubernetesResources{Include: []schema.KubernetesResourceSelector{{Kind: "Route"}}},
+			file:      clusterOperator,
+		},
+		{
+			name:      "no resource with included kinds",
+			resources: schema.ObfuscateKubernetesResources{Include: []schema.KubernetesResourceSelector{{Kind: "Route"}}},
+		},
+		{
+			name:      "included kind with api version and namespace",
+			resources: schema.ObfuscateKubernetesResources{Include: []schema.KubernetesResourceSelector{{Kind: "Route", ApiVersion: &routeApiVersion, Namespaces: []string{"openshift-console"}}}},
+			file:      route,
+			applies:   true,
+		},
+		{
+			name:      "included kind in another namespace",
+			resources: schema.ObfuscateKubernetesResources{Include: []schema.KubernetesResourceSelector{{Kind: "Route", Namespaces: []string{"default"}}}},
+			file:      route,
+		},
+		{
+			name:      "excluded kind",
+			resources: schema.ObfuscateKubernetesResources{Exclude: []schema.KubernetesResourceSelector{{Kind: "ClusterOperator"}}},
+			file:      clusterOperator,
+		},
+		{
+			name:      "no resource with excluded kinds",
+			resources: schema.ObfuscateKubernetesResources{Exclude: []schema.KubernetesResourceSelector{{Kind: "ClusterOperator"}}},
+			applies:   true,
+		},
+		{
+			name:      "included kind in an excluded path",
+			resources: schema.ObfuscateKubernetesResources{Include: []schema.KubernetesResourceSelector{{Kind: "Route"}}},
+			paths:     schema.ObfuscatePaths{Exclude: []string{"routes/*"}},
+			file:      route,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			regex, err := NewRegexObfuscator("secret-word", nil, schema.ObfuscateReplacementTypeStatic, ReplacementFormat{}, NewSimpleTracker())
+			require.NoError(t, err)
+			o, err := NewScopedObfuscator(&tc.paths, &tc.resources, regex)
+			require.NoError(t, err)
+
+			output := NewMultiObfuscator([]ReportingObfuscator{o}).ForFile("routes/console.yaml", tc.file).Contents("secret-word")
+			if tc.applies {
+				assert.Equal(t, "xxxxxxxxxxx", output)
+			} else {
+				assert.Equal(t, "secret-word", output)
+			}
+		})
+	}
+}
+
+func TestScopedObfuscatorWithoutScope(t *testing.T) {
+	regex, err := NewRegexObfuscator("secret-word", nil, schema.ObfuscateReplacementTypeStatic, ReplacementFormat{}, NewSimpleTracker())
+	require.NoError(t, err)
+
+	o, err := NewScopedObfuscator(nil, nil, regex)
+	require.NoError(t, err)
+	assert.Same(t, regex, o)
+
+	o, err = NewScopedObfuscator(&schema.ObfuscatePaths{}, nil, regex)
+	require.NoError(t, err)
+	assert.Same(t, regex, o)
+
+	mo := NewMultiObfuscator([]ReportingObfuscator{o})
+	assert.Same(t, mo, mo.ForFile("any/path", nil))
+}
+
+func TestScopedObfuscatorInvalidGlob(t *testing.T) {
+	_, err := NewScopedObfuscator(&schema.ObfuscatePaths{Include: []string{"pods/[a-"}}, nil, NoopObfuscator{})
+	assert.EqualError(t, err, "invalid path glob 'pods/[a-': syntax error in pattern")
+	_, err = NewScopedObfuscator(&schema.ObfuscatePaths{Exclude: []string{""}}, nil, NoopObfuscator{})
+	assert.EqualError(t, err, "pattern for file omitter cannot be empty")
+	_, err = NewScopedObfuscator(nil, &schema.ObfuscateKubernetesResources{Include: []schema.KubernetesResourceSelector{{}}}, NoopObfuscator{})
+	assert.EqualError(t, err, "no kind specified in kubernetes resource selector")
+}
+
+func TestScopedObfuscatorKeepsSharedReport(t *testing.T) {
+	regex, err := NewRegexObfuscator("secret-word", nil, schema.ObfuscateReplacementTypeConsistent, ReplacementFormat{}, NewSimpleTracker())
+	require.NoError(t, err)
+	scoped, err := NewScopedObfuscator(&schema.ObfuscatePaths{Include: []string{"*.log"}}, nil, regex)
+	require.NoError(t, err)
+	ip, err := NewIPObfuscator(nil, nil, schema.ObfuscateReplacementTypeStatic, ReplacementFormat{}, NewSimpleTracker())
+	require.NoError(t, err)
+
+	mo := NewPrioritizedMultiObfuscator([]ReportingObfuscator{scoped, ip}, []int{0, 1})
+	assert.Equal(t, "secret-word xxx.xxx.xxx.xxx", mo.ForFile("a.yaml", nil).Contents("secret-word 10.0.0.1"))
+	assert.Equal(t, "x-regex-0000000001-x xxx.xxx.xxx.xxx", mo.ForFile("a.log", nil).Contents("secret-word 10.0.0.1"))
+	assert.Equal(t, map[string]string{"secret-word": "x-regex-0000000001-x", "10.0.0.1": "xxx.xxx.xxx.xxx"}, mo.Report().AsMap())
+}
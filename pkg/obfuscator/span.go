@@ -0,0 +1,69 @@
+package obfuscator
+
+import (
+	"sort"
+	"strings"
+)
+
+// Span is a match of an obfuscator within its input. The replacement is only generated when the span is applied, so
+// that matches which lose against another obfuscator are neither replaced nor reported.
+type Span struct {
+	Start int
+	End   int
+	// Replace returns the replacement of the match and records it in the tracker of the obfuscator
+	Replace func() string
+}
+
+// SpanObfuscator is implemented by obfuscators that can report their matches on the original input instead of
+// replacing them. This allows the MultiObfuscator to resolve the conflicts between obfuscators and to replace every
+// part of the input at most once.
+type SpanObfuscator interface {
+	ReportingObfuscator
+	// PathSpans returns the matches within a path, ordered by their position and without any overlaps
+	PathSpans(string) []Span
+	// ContentsSpans returns the matches within a line of content, ordered by their position and without any overlaps
+	ContentsSpans(string) []Span
+}
+
+// applySpans replaces the given ordered and non-overlapping spans within the input.
+func applySpans(input string, spans []Span) string {
+	if len(spans) == 0 {
+		return input
+	}
+
+	var sb strings.Builder
+	last := 0
+	for _, s := range spans {
+		sb.WriteString(input[last:s.Start])
+		sb.WriteString(s.Replace())
+		last = s.End
+	}
+	sb.WriteString(input[last:])
+	return sb.String()
+}
+
+// mergeSpans combines the spans of multiple detections on the same input, ordered by their precedence. A span that
+// overlaps with a span of a preceding detection is dropped, the result is ordered by position.
+func mergeSpans(spans ...[]Span) []Span {
+	var merged []Span
+	for _, candidates := range spans {
+		for _, c := range candidates {
+			if !overlapsAny(merged, c) {
+				merged = append(merged, c)
+			}
+		}
+	}
+	sort.Slice(merged, func(i, j int) bool {
+		return merged[i].Start < merged[j].Start
+	})
+	return merged
+}
+
+func overlapsAny(spans []Span, s Span) bool {
+	for _, other := range spans {
+		if s.Start < other.End && other.Start < s.End {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,143 @@
+package obfuscator
+
+import (
+	"testing"
+
+	"github.com/openshift/must-gather-clean/pkg/schema"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMergeSpans(t *testing.T) {
+	replaceWith := func(s string) func() string {
+		return func() string { return s }
+	}
+	first := []Span{{Start: 4, End: 8, Replace: replaceWith("A")}}
+	second := []Span{
+		{Start: 0, End: 2, Replace: replaceWith("B")},
+		{Start: 6, End: 10, Replace: replaceWith("C")},
+		{Start: 10, End: 12, Replace: replaceWith("D")},
+	}
+
+	merged := mergeSpans(first, second)
+	require.Len(t, merged, 3)
+	assert.Equal(t, []int{0, 4, 10}, []int{merged[0].Start, merged[1].Start, merged[2].Start})
+	assert.Equal(t, "B23A89D", applySpans("0123456789ab", merged))
+}
+
+func TestReplaceByMatchIndex(t *testing.T) {
+	for _, tc := range []struct {
+		name   string
+		create func() (ReportingObfuscator, error)
+		input  string
+		output string
+		report map[string]string
+	}{
+		{
+			name: "ip prefix of a longer ip",
+			create: func() (ReportingObfuscator, error) {
+				return NewIPObfuscator(nil, nil, schema.ObfuscateReplacementTypeConsistent, ReplacementFormat{}, NewSimpleTracker())
+			},
+			input:  "10.0.0.1 and 10.0.0.15",
+			output: "x-ipv4-0000000001-x and x-ipv4-0000000002-x",
+			report: map[string]string{"10.0.0.1": "x-ipv4-0000000001-x", "10.0.0.15": "x-ipv4-0000000002-x"},
+		},
+		{
+			name: "longer ip first",
+			create: func() (ReportingObfuscator, error) {
+				return NewIPObfuscator(nil, nil, schema.ObfuscateReplacementTypeConsistent, ReplacementFormat{}, NewSimpleTracker())
+			},
+			input:  "10.0.0.15 and 10.0.0.1 and 10.0.0.15",
+			output: "x-ipv4-0000000001-x and x-ipv4-0000000002-x and x-ipv4-0000000001-x",
+			report: map[string]string{"10.0.0.15": "x-ipv4-0000000001-x", "10.0.0.1": "x-ipv4-0000000002-x"},
+		},
+		{
+			name: "ipv4 within an excluded ipv6 address",
+			create: func() (ReportingObfuscator, error) {
+				return NewIPObfuscator([]string{"64:ff9b::/96"}, nil, schema.ObfuscateReplacementTypeConsistent, ReplacementFormat{}, NewSimpleTracker())
+			},
+			input:  "nat64 64:ff9b::10.0.0.1 for 10.0.0.1",
+			output: "nat64 64:ff9b::x-ipv4-0000000001-x for x-ipv4-0000000001-x",
+			report: map[string]string{"10.0.0.1": "x-ipv4-0000000001-x"},
+		},
+		{
+			name: "ipv4 embedded in an ipv6 address",
+			create: func() (ReportingObfuscator, error) {
+				return NewIPObfuscator(nil, nil, schema.ObfuscateReplacementTypeConsistent, ReplacementFormat{}, NewSimpleTracker())
+			},
+			input:  "nat64 64:ff9b::10.0.0.1 for 10.0.0.1",
+			output: "nat64 x-ipv6-0000000001-x for x-ipv4-0000000001-x",
+			report: map[string]string{"64:ff9b::10.0.0.1": "x-ipv6-0000000001-x", "10.0.0.1": "x-ipv4-0000000001-x"},
+		},
+		{
+			name: "domain within longer names",
+			create: func() (ReportingObfuscator, error) {
+				return NewDomainObfuscator([]string{"example.com"}, schema.ObfuscateReplacementTypeConsistent, ReplacementFormat{}, NewSimpleTracker())
+			},
+			input:  "myexample.com example.community api.example.com example.com.",
+			output: "myexample.com example.community api.domain0000000001 domain0000000001.",
+			report: map[string]string{"api.example.com": "domain0000000001", "example.com": "domain0000000001"},
+		},
+		{
+			name: "domain with a dashed subdomain",
+			create: func() (ReportingObfuscator, error) {
+				return NewDomainObfuscator([]string{"example.com"}, schema.ObfuscateReplacementTypeStatic, ReplacementFormat{}, NewSimpleTracker())
+			},
+			input:  "https://my-cluster.example.com-ca.crt and sub-example.com",
+			output: "https://my-cluster.obfuscated.com-ca.crt and sub-example.com",
+			report: map[string]string{"my-cluster.example.com": "obfuscated.com"},
+		},
+		{
+			name: "more specific domain",
+			create: func() (ReportingObfuscator, error) {
+				return NewDomainObfuscator([]string{"example.com", "dev.example.com"}, schema.ObfuscateReplacementTypeConsistent, ReplacementFormat{}, NewSimpleTracker())
+			},
+			input:  "a.dev.example.com and b.example.com",
+			output: "a.domain0000000001 and b.domain0000000002",
+			report: map[string]string{"a.dev.example.com": "domain0000000001", "b.example.com": "domain0000000002"},
+		},
+		{
+			name: "mac in different notations",
+			create: func() (ReportingObfuscator, error) {
+				return NewMacAddressObfuscator(schema.ObfuscateReplacementTypeConsistent, ReplacementFormat{}, NewSimpleTracker())
+			},
+			input:  "29-7E-8C-8C-60-C9 297e.8c8c.60c9 29-7E-8C-8C-60-C8",
+			output: "x-mac-0000000001-x x-mac-0000000001-x x-mac-0000000002-x",
+			report: map[string]string{"29-7E-8C-8C-60-C9": "x-mac-0000000001-x", "297e.8c8c.60c9": "x-mac-0000000001-x", "29-7E-8C-8C-60-C8": "x-mac-0000000002-x"},
+		},
+		{
+			name: "regex group equal to other text",
+			create: func() (ReportingObfuscator, error) {
+				return NewRegexObfuscator(`id=(\d+)`, []string{"1"}, schema.ObfuscateReplacementTypeConsistent, ReplacementFormat{}, NewSimpleTracker())
+			},
+			input:  "id=12 port=12 id=123",
+			output: "id=x-regex-0000000001-x port=12 id=x-regex-0000000002-x",
+			report: map[string]string{"12": "x-regex-0000000001-x", "123": "x-regex-0000000002-x"},
+		},
+		{
+			name: "keyword within a longer word",
+			create: func() (ReportingObfuscator, error) {
+				return NewKeywordsObfuscator(map[string]string{"node1": "worker"}, nil, false, true, schema.ObfuscateReplacementTypeStatic, ReplacementFormat{})
+			},
+			input:  "node1 node10 node1",
+			output: "worker node10 worker",
+			report: map[string]string{"node1": "worker"},
+		},
+		{
+			name: "identity within a longer name",
+			create: func() (ReportingObfuscator, error) {
+				return NewIdentityObfuscator([]string{"bob"}, nil, schema.ObfuscateReplacementTypeConsistent, ReplacementFormat{}, NewSimpleTracker())
+			},
+			input:  "bob bobcat bob",
+			output: "user-00000001 bobcat user-00000001",
+			report: map[string]string{"bob": "user-00000001"},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			o, err := tc.create()
+			require.NoError(t, err)
+			assert.Equal(t, tc.output, o.Contents(tc.input))
+			assert.Equal(t, tc.report, o.Report().AsMap())
+		})
+	}
+}
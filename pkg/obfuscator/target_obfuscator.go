@@ -25,9 +25,33 @@ func (t *targetObfuscator) Report() ReplacementReport {
 	return t.obfuscator.Report()
 }
 
+// targetSpanObfuscator wraps obfuscators that report their matches, only the matches of the target are reported.
+type targetSpanObfuscator struct {
+	*targetObfuscator
+	spanObfuscator SpanObfuscator
+}
+
+func (t *targetSpanObfuscator) PathSpans(s string) []Span {
+	if t.target == schema.ObfuscateTargetAll || t.target == schema.ObfuscateTargetFilePath {
+		return t.spanObfuscator.PathSpans(s)
+	}
+	return nil
+}
+
+func (t *targetSpanObfuscator) ContentsSpans(s string) []Span {
+	if t.target == schema.ObfuscateTargetAll || t.target == schema.ObfuscateTargetFileContents {
+		return t.spanObfuscator.ContentsSpans(s)
+	}
+	return nil
+}
+
 func NewTargetObfuscator(target schema.ObfuscateTarget, obfuscator ReportingObfuscator) ReportingObfuscator {
-	return &targetObfuscator{
+	t := &targetObfuscator{
 		target:     target,
 		obfuscator: obfuscator,
 	}
+	if so, ok := obfuscator.(SpanObfuscator); ok {
+		return &targetSpanObfuscator{targetObfuscator: t, spanObfuscator: so}
+	}
+	return t
 }
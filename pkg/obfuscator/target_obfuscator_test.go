@@ -33,7 +33,7 @@ func TestRegexObfuscationTarget(t *testing.T) {
 		},
 	} {
 		t.Run(tc.name, func(t *testing.T) {
-			obfuscator, err := NewRegexObfuscator("secret-word", NewSimpleTracker())
+			obfuscator, err := NewRegexObfuscator("secret-word", nil, schema.ObfuscateReplacementTypeStatic, ReplacementFormat{}, NewSimpleTracker())
 			require.NoError(t, err)
 			o := NewTargetObfuscator(tc.target, obfuscator)
 
@@ -0,0 +1,84 @@
+package obfuscator
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+// Timing accumulates the calls and the time spent by an obfuscator. It is safe for concurrent use.
+type Timing struct {
+	// the 64-bit counters are accessed atomically, so they need to come first for alignment on 32-bit platforms
+	calls uint64
+	nanos int64
+}
+
+// Calls returns how often the obfuscator was called.
+func (t *Timing) Calls() uint64 {
+	return atomic.LoadUint64(&t.calls)
+}
+
+// Duration returns the time the obfuscator spent in total.
+func (t *Timing) Duration() time.Duration {
+	return time.Duration(atomic.LoadInt64(&t.nanos))
+}
+
+func (t *Timing) track(start time.Time) {
+	atomic.AddUint64(&t.calls, 1)
+	atomic.AddInt64(&t.nanos, int64(time.Since(start)))
+}
+
+// timingObfuscator measures the time spent by the wrapped obfuscator.
+type timingObfuscator struct {
+	ReportingObfuscator
+	timing *Timing
+}
+
+func (t *timingObfuscator) Path(s string) string {
+	defer t.timing.track(time.Now())
+	return t.ReportingObfuscator.Path(s)
+}
+
+func (t *timingObfuscator) Contents(s string) string {
+	defer t.timing.track(time.Now())
+	return t.ReportingObfuscator.Contents(s)
+}
+
+// timingSpanObfuscator wraps obfuscators that report their matches, the time spent to generate the replacements of the
+// matches is measured as well.
+type timingSpanObfuscator struct {
+	*timingObfuscator
+	spanObfuscator SpanObfuscator
+}
+
+func (t *timingSpanObfuscator) PathSpans(s string) []Span {
+	defer t.timing.track(time.Now())
+	return t.timedSpans(t.spanObfuscator.PathSpans(s))
+}
+
+func (t *timingSpanObfuscator) ContentsSpans(s string) []Span {
+	defer t.timing.track(time.Now())
+	return t.timedSpans(t.spanObfuscator.ContentsSpans(s))
+}
+
+func (t *timingSpanObfuscator) timedSpans(spans []Span) []Span {
+	for i := range spans {
+		replace := spans[i].Replace
+		spans[i].Replace = func() string {
+			start := time.Now()
+			defer func() {
+				atomic.AddInt64(&t.timing.nanos, int64(time.Since(start)))
+			}()
+			return replace()
+		}
+	}
+	return spans
+}
+
+// NewTimingObfuscator wraps the given obfuscator, so that the time it spends is accumulated in the given timing.
+func NewTimingObfuscator(timing *Timing, obfuscator ReportingObfuscator) ReportingObfuscator {
+	t := &timingObfuscator{ReportingObfuscator: obfuscator, timing: timing}
+	if so, ok := obfuscator.(SpanObfuscator); ok {
+		return &timingSpanObfuscator{timingObfuscator: t, spanObfuscator: so}
+	}
+	return t
+}
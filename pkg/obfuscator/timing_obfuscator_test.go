@@ -0,0 +1,38 @@
+package obfuscator
+
+import (
+	"testing"
+
+	"github.com/openshift/must-gather-clean/pkg/schema"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTimingObfuscator(t *testing.T) {
+	regex, err := NewRegexObfuscator("secret-word", nil, schema.ObfuscateReplacementTypeStatic, ReplacementFormat{}, NewSimpleTracker())
+	require.NoError(t, err)
+	timing := &Timing{}
+	o := NewTimingObfuscator(timing, regex)
+	_, isSpan := o.(SpanObfuscator)
+	assert.True(t, isSpan, "span obfuscators should stay span obfuscators")
+
+	assert.Equal(t, "xxxxxxxxxxx", o.Contents("secret-word"))
+	assert.Equal(t, "xxxxxxxxxxx", o.Path("secret-word"))
+	assert.Equal(t, uint64(2), timing.Calls())
+	assert.Greater(t, int64(timing.Duration()), int64(0))
+
+	multi := NewMultiObfuscator([]ReportingObfuscator{o})
+	assert.Equal(t, "a xxxxxxxxxxx", multi.Contents("a secret-word"))
+	assert.Equal(t, uint64(3), timing.Calls())
+	assert.Equal(t, map[string]uint{"secret-word": 3}, o.Report().Replacements[0].Counter)
+}
+
+func TestTimingObfuscatorWithoutSpans(t *testing.T) {
+	timing := &Timing{}
+	o := NewTimingObfuscator(timing, NoopObfuscator{Replacements: map[string]string{}})
+	_, isSpan := o.(SpanObfuscator)
+	assert.False(t, isSpan)
+
+	assert.Equal(t, "input", o.Contents("input"))
+	assert.Equal(t, uint64(1), timing.Calls())
+}
@@ -10,6 +10,8 @@ type GenerateReplacement func() string
 
 type ReplacementReport struct {
 	Replacements []Replacement
+	// Skipped counts the strings that were not obfuscated, because they matched one of the exceptions
+	Skipped map[string]uint
 }
 
 func (s ReplacementReport) AsMap() (m map[string]string) {
@@ -60,6 +62,8 @@ type ReplacementTracker interface {
 type SimpleTracker struct {
 	lock    sync.RWMutex
 	mapping map[string]*Replacement
+	// existing are the replacements the tracker was created with, generated replacements must not collide with them
+	existing map[string]bool
 }
 
 func (s *SimpleTracker) Report() ReplacementReport {
@@ -83,6 +87,14 @@ func (s *SimpleTracker) GenerateIfAbsent(canonical string, original string, coun
 	}
 
 	g := generator()
+	// skip the replacements of a previous run, unless the generator always returns the same static replacement
+	for s.existing[g] {
+		next := generator()
+		if next == g {
+			break
+		}
+		g = next
+	}
 	s.mapping[canonical] = NewReplacement(canonical, original, g, count)
 	return g
 }
@@ -114,9 +126,11 @@ func NewSimpleTracker() ReplacementTracker {
 func NewSimpleTrackerMap(existingReplacements map[string]string) ReplacementTracker {
 
 	var m = map[string]*Replacement{}
+	var existing = map[string]bool{}
 	// injecting the already-existing replacement report
 	for key, value := range existingReplacements {
 		m[key] = NewReplacement(key, key, value, 0)
+		existing[value] = true
 	}
-	return &SimpleTracker{mapping: m}
+	return &SimpleTracker{mapping: m, existing: existing}
 }
@@ -1,6 +1,7 @@
 package obfuscator
 
 import (
+	"fmt"
 	"sort"
 	"testing"
 
@@ -63,10 +64,26 @@ func TestMultiGeneratedReplacementDifferentOriginals(t *testing.T) {
 	}}, st.Report())
 }
 
+func TestGeneratedReplacementsSkipExisting(t *testing.T) {
+	st := NewSimpleTrackerMap(map[string]string{"a": "x-1", "b": "x-3"})
+	count := 0
+	generator := func() string {
+		count++
+		return fmt.Sprintf("x-%d", count)
+	}
+	assert.Equal(t, "x-2", st.GenerateIfAbsent("c", "c", 1, generator))
+	assert.Equal(t, "x-4", st.GenerateIfAbsent("d", "d", 1, generator))
+	assert.Equal(t, "x-1", st.GenerateIfAbsent("a", "a", 1, generator))
+
+	// static replacements are the same for all values
+	static := NewSimpleTrackerMap(map[string]string{"a": "x"})
+	assert.Equal(t, "x", static.GenerateIfAbsent("b", "b", 1, func() string { return "x" }))
+}
+
 func TestHappyPathInit(t *testing.T) {
 	st := NewSimpleTracker()
 	r := ReplacementReport{
-		[]Replacement{
+		Replacements: []Replacement{
 			{
 				Canonical:    "a",
 				ReplacedWith: "b",
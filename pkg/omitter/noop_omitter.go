@@ -21,3 +21,7 @@ func (n *NoopOmitter) OmitKubeResource(resourceList *kube.ResourceListWithPath)
 func (n *NoopOmitter) Report() []string {
 	return n.Paths
 }
+
+func (n *NoopOmitter) Omissions() []Omission {
+	return nil
+}
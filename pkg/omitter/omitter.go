@@ -26,4 +26,18 @@ type ReportingOmitter interface {
 
 	// Report should return all paths that were omitted
 	Report() []string
+
+	// Omissions returns all omitted files along with the rule that omitted them
+	Omissions() []Omission
+}
+
+// Omission is a file that was omitted by a rule.
+type Omission struct {
+	Path string
+	// Rule is the index of the omitter in the configuration
+	Rule int
+	// Resource is the first resource of the file that matched a Kubernetes omitter
+	Resource *kube.Resource
+	// Size of the file in bytes, 0 if it can't be determined
+	Size int64
 }
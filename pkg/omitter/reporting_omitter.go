@@ -1,6 +1,8 @@
 package omitter
 
 import (
+	"os"
+	"path/filepath"
 	"sync"
 
 	"github.com/openshift/must-gather-clean/pkg/kube"
@@ -9,20 +11,25 @@ import (
 type MultiReportingOmitter struct {
 	fileOmitters []FileOmitter
 	k8sOmitters  []KubernetesResourceOmitter
+	// fileRules and k8sRules are the indices of the omitters in the configuration
+	fileRules []int
+	k8sRules  []int
+	// inputFolder is used to determine the size of the omitted files
+	inputFolder string
 
 	omittedPathsLock sync.Mutex
-	omittedPaths     []string
+	omissions        []Omission
 }
 
 func (m *MultiReportingOmitter) OmitPath(path string) (bool, error) {
-	for _, o := range m.fileOmitters {
+	for i, o := range m.fileOmitters {
 		omit, err := o.OmitPath(path)
 		if err != nil {
 			return false, err
 		}
 
 		if omit {
-			m.appendUnderLock(path)
+			m.appendUnderLock(Omission{Path: path, Rule: m.fileRules[i], Size: fileSize(filepath.Join(m.inputFolder, path))})
 			return true, nil
 		}
 	}
@@ -30,14 +37,19 @@ func (m *MultiReportingOmitter) OmitPath(path string) (bool, error) {
 }
 
 func (m *MultiReportingOmitter) OmitKubeResource(resourceList *kube.ResourceListWithPath) (bool, error) {
-	for _, o := range m.k8sOmitters {
+	for i, o := range m.k8sOmitters {
 		omit, err := o.OmitKubeResource(resourceList)
 		if err != nil {
 			return false, err
 		}
 
 		if omit {
-			m.appendUnderLock(resourceList.Path)
+			resource, err := matchingResource(o, resourceList)
+			if err != nil {
+				return false, err
+			}
+			// the path of a resource already includes the input folder
+			m.appendUnderLock(Omission{Path: resourceList.Path, Rule: m.k8sRules[i], Resource: resource, Size: fileSize(resourceList.Path)})
 			return true, nil
 		}
 	}
@@ -49,25 +61,75 @@ func (m *MultiReportingOmitter) Report() []string {
 	defer m.omittedPathsLock.Unlock()
 
 	var copySlice []string
-	for i := 0; i < len(m.omittedPaths); i++ {
-		copySlice = append(copySlice, m.omittedPaths[i])
+	for i := 0; i < len(m.omissions); i++ {
+		copySlice = append(copySlice, m.omissions[i].Path)
 	}
 
 	return copySlice
 }
 
-func (m *MultiReportingOmitter) appendUnderLock(path string) {
+func (m *MultiReportingOmitter) Omissions() []Omission {
 	m.omittedPathsLock.Lock()
 	defer m.omittedPathsLock.Unlock()
 
-	m.omittedPaths = append(m.omittedPaths, path)
+	return append([]Omission{}, m.omissions...)
 }
 
+func (m *MultiReportingOmitter) appendUnderLock(omission Omission) {
+	m.omittedPathsLock.Lock()
+	defer m.omittedPathsLock.Unlock()
+
+	m.omissions = append(m.omissions, omission)
+}
+
+// matchingResource returns the first resource of the list that is omitted on its own.
+func matchingResource(o KubernetesResourceOmitter, resourceList *kube.ResourceListWithPath) (*kube.Resource, error) {
+	for _, r := range resourceList.Items {
+		omit, err := o.OmitKubeResource(&kube.ResourceListWithPath{ResourceList: kube.ResourceList{Items: []kube.Resource{r}}, Path: resourceList.Path})
+		if err != nil {
+			return nil, err
+		}
+		if omit {
+			resource := r
+			return &resource, nil
+		}
+	}
+	return nil, nil
+}
+
+// fileSize returns the size of the file or symbolic link, or 0 if it can't be determined.
+func fileSize(path string) int64 {
+	stat, err := os.Lstat(path)
+	if err != nil {
+		return 0
+	}
+	return stat.Size()
+}
+
+// NewMultiReportingOmitter returns a ReportingOmitter where the rule of an omission is the index of its omitter, counting
+// the file omitters first.
 func NewMultiReportingOmitter(fileOmitters []FileOmitter, k8sOmitters []KubernetesResourceOmitter) ReportingOmitter {
+	fileRules := make([]int, len(fileOmitters))
+	for i := range fileRules {
+		fileRules[i] = i
+	}
+	k8sRules := make([]int, len(k8sOmitters))
+	for i := range k8sRules {
+		k8sRules[i] = len(fileOmitters) + i
+	}
+	return NewMultiReportingOmitterWithRules("", fileOmitters, fileRules, k8sOmitters, k8sRules)
+}
+
+// NewMultiReportingOmitterWithRules returns a ReportingOmitter that reports the rule at the same index as the omitter
+// for each omission, the files are relative to the input folder.
+func NewMultiReportingOmitterWithRules(inputFolder string, fileOmitters []FileOmitter, fileRules []int, k8sOmitters []KubernetesResourceOmitter, k8sRules []int) ReportingOmitter {
 	return &MultiReportingOmitter{
 		fileOmitters:     fileOmitters,
 		k8sOmitters:      k8sOmitters,
+		fileRules:        fileRules,
+		k8sRules:         k8sRules,
+		inputFolder:      inputFolder,
 		omittedPathsLock: sync.Mutex{},
-		omittedPaths:     []string{},
+		omissions:        []Omission{},
 	}
 }
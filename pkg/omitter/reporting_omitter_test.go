@@ -1,6 +1,8 @@
 package omitter
 
 import (
+	"os"
+	"path/filepath"
 	"testing"
 
 	"github.com/openshift/must-gather-clean/pkg/kube"
@@ -82,6 +84,39 @@ func TestOmitK8s(t *testing.T) {
 	assert.Equal(t, []string{"some.path"}, omitter.Report())
 }
 
+func TestOmissionsWithRules(t *testing.T) {
+	inputDir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(inputDir, "some.log"), []byte("0123456789"), 0600))
+	secrets := filepath.Join(inputDir, "secrets.yaml")
+	require.NoError(t, os.WriteFile(secrets, []byte("abc"), 0600))
+
+	omitter := NewMultiReportingOmitterWithRules(inputDir,
+		[]FileOmitter{testingFileOmitterWithPattern(t, "*.log")}, []int{2},
+		[]KubernetesResourceOmitter{testingK8sResourceOmitter(t)}, []int{0})
+
+	omit, err := omitter.OmitPath("some.log")
+	require.NoError(t, err)
+	assert.True(t, omit)
+
+	omit, err = omitter.OmitKubeResource(&kube.ResourceListWithPath{
+		ResourceList: kube.ResourceList{
+			Items: []kube.Resource{
+				{ApiVersion: "v2", Kind: "kind", Metadata: kube.Metadata{Name: "kept"}},
+				{ApiVersion: "v1", Kind: "kind", Metadata: kube.Metadata{Namespace: "default", Name: "omitted"}},
+			},
+		},
+		Path: secrets,
+	})
+	require.NoError(t, err)
+	assert.True(t, omit)
+
+	assert.Equal(t, []Omission{
+		{Path: "some.log", Rule: 2, Size: 10},
+		{Path: secrets, Rule: 0, Size: 3, Resource: &kube.Resource{ApiVersion: "v1", Kind: "kind", Metadata: kube.Metadata{Namespace: "default", Name: "omitted"}}},
+	}, omitter.Omissions())
+	assert.Equal(t, []string{"some.log", secrets}, omitter.Report())
+}
+
 func testingFileOmitterWithPattern(t *testing.T, pattern string) FileOmitter {
 	omitter, err := NewFilenamePatternOmitter(pattern)
 	require.NoError(t, err)
@@ -0,0 +1,234 @@
+package reporting
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"sort"
+
+	"github.com/openshift/must-gather-clean/pkg/schema"
+	"gopkg.in/yaml.v3"
+)
+
+// ReportDiff compares the replacements and omissions of two reports, usually of two runs with a different configuration
+// on the same input.
+type ReportDiff struct {
+	// Obfuscators are the obfuscators with added or removed values, by their index in the configuration
+	Obfuscators []ObfuscatorDiff `yaml:"obfuscators,omitempty" json:"obfuscators,omitempty"`
+	// AddedOmissions are the files that are only omitted in the new report
+	AddedOmissions []Omission `yaml:"addedOmissions,omitempty" json:"addedOmissions,omitempty"`
+	// RemovedOmissions are the files that are only omitted in the old report
+	RemovedOmissions []Omission `yaml:"removedOmissions,omitempty" json:"removedOmissions,omitempty"`
+}
+
+// ObfuscatorDiff are the values that only a single one of the reports replaced with the obfuscator at the same index.
+type ObfuscatorDiff struct {
+	Obfuscator int                  `yaml:"obfuscator" json:"obfuscator"`
+	Type       schema.ObfuscateType `yaml:"type,omitempty" json:"type,omitempty"`
+	// Added are the values that are only replaced in the new report
+	Added []ValueDiff `yaml:"added,omitempty" json:"added,omitempty"`
+	// Removed are the values that are only replaced in the old report
+	Removed []ValueDiff `yaml:"removed,omitempty" json:"removed,omitempty"`
+}
+
+type ValueDiff struct {
+	Original     string `yaml:"original" json:"original"`
+	ReplacedWith string `yaml:"replacedWith" json:"replacedWith"`
+	// OtherObfuscator is the obfuscator that replaces the value in the other report, a removed value with none is not
+	// caught anymore at all
+	OtherObfuscator *int `yaml:"otherObfuscator,omitempty" json:"otherObfuscator,omitempty"`
+}
+
+// Empty returns true if the reports replaced the same values and omitted the same files.
+func (d ReportDiff) Empty() bool {
+	return len(d.Obfuscators) == 0 && len(d.AddedOmissions) == 0 && len(d.RemovedOmissions) == 0
+}
+
+// ReadReport reads a report in the YAML or JSON format. Summaries of a split report are refused, since they do not
+// contain the replaced values.
+func ReadReport(path string) (*Report, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read report %s: %w", path, err)
+	}
+	if IsEncryptedMapping(data) {
+		return nil, fmt.Errorf("report %s is an encrypted mapping, decrypt it with the reveal command first", path)
+	}
+	return ParseReport(data, path)
+}
+
+// ParseReport parses the data of the report at the given path in the YAML or JSON format, summaries of a split report
+// are refused like with ReadReport.
+func ParseReport(data []byte, path string) (*Report, error) {
+	var report Report
+	// JSON is a subset of YAML, so both formats are read the same way
+	if err := yaml.Unmarshal(data, &report); err != nil {
+		return nil, fmt.Errorf("failed to parse report %s: %w", path, err)
+	}
+	if len(report.Replacements) == 0 {
+		for _, s := range report.Summary {
+			if s.Values > 0 {
+				return nil, fmt.Errorf("report %s is a summary without the replaced values, use the mapping of the split report instead", path)
+			}
+		}
+	}
+	return &report, nil
+}
+
+// replacedValue is a value of a report along with the obfuscator that replaced it.
+type replacedValue struct {
+	obfuscator   int
+	replacedWith string
+}
+
+// replacedValues returns the values of the report by their original, if several obfuscators replaced a value the first
+// one is kept. Replacements without occurrences, for example those passed in the configuration but not found, count by
+// their canonical value.
+func replacedValues(report *Report) map[string]replacedValue {
+	values := map[string]replacedValue{}
+	for i, replacements := range report.Replacements {
+		for _, r := range replacements {
+			originals := []string{r.Canonical}
+			if len(r.Occurrences) > 0 {
+				originals = nil
+				for _, o := range r.Occurrences {
+					originals = append(originals, o.Original)
+				}
+			}
+			for _, original := range originals {
+				if _, ok := values[original]; !ok && original != "" {
+					values[original] = replacedValue{obfuscator: i, replacedWith: r.ReplacedWith}
+				}
+			}
+		}
+	}
+	return values
+}
+
+// DiffReports compares the old with the new report. The obfuscators are compared by their index, a value that moved to
+// another obfuscator is removed from one and added to the other, along with the index of the other one.
+func DiffReports(oldReport *Report, newReport *Report) ReportDiff {
+	oldValues := replacedValues(oldReport)
+	newValues := replacedValues(newReport)
+
+	obfuscators := len(oldReport.Replacements)
+	if len(newReport.Replacements) > obfuscators {
+		obfuscators = len(newReport.Replacements)
+	}
+	diffs := make([]ObfuscatorDiff, obfuscators)
+	for i := range diffs {
+		diffs[i] = ObfuscatorDiff{Obfuscator: i, Type: obfuscatorType(*newReport, i)}
+		if diffs[i].Type == "" {
+			diffs[i].Type = obfuscatorType(*oldReport, i)
+		}
+	}
+	for original, n := range newValues {
+		o, ok := oldValues[original]
+		if ok && o.obfuscator == n.obfuscator {
+			continue
+		}
+		value := ValueDiff{Original: original, ReplacedWith: n.replacedWith}
+		if ok {
+			value.OtherObfuscator = intPointer(o.obfuscator)
+		}
+		diffs[n.obfuscator].Added = append(diffs[n.obfuscator].Added, value)
+	}
+	for original, o := range oldValues {
+		n, ok := newValues[original]
+		if ok && o.obfuscator == n.obfuscator {
+			continue
+		}
+		value := ValueDiff{Original: original, ReplacedWith: o.replacedWith}
+		if ok {
+			value.OtherObfuscator = intPointer(n.obfuscator)
+		}
+		diffs[o.obfuscator].Removed = append(diffs[o.obfuscator].Removed, value)
+	}
+
+	var result ReportDiff
+	for _, d := range diffs {
+		if len(d.Added) == 0 && len(d.Removed) == 0 {
+			continue
+		}
+		sortValues(d.Added)
+		sortValues(d.Removed)
+		result.Obfuscators = append(result.Obfuscators, d)
+	}
+	result.AddedOmissions = omissionsOnlyIn(newReport.Omissions, oldReport.Omissions)
+	result.RemovedOmissions = omissionsOnlyIn(oldReport.Omissions, newReport.Omissions)
+	return result
+}
+
+func intPointer(i int) *int {
+	return &i
+}
+
+func sortValues(values []ValueDiff) {
+	sort.Slice(values, func(i, j int) bool {
+		return values[i].Original < values[j].Original
+	})
+}
+
+// omissionsOnlyIn returns the omissions whose path is not omitted by the others, ordered by path.
+func omissionsOnlyIn(omissions []Omission, others []Omission) []Omission {
+	otherPaths := map[string]bool{}
+	for _, o := range others {
+		otherPaths[o.Path] = true
+	}
+	var result []Omission
+	for _, o := range omissions {
+		if !otherPaths[o.Path] {
+			result = append(result, o)
+		}
+	}
+	sort.SliceStable(result, func(i, j int) bool {
+		return result[i].Path < result[j].Path
+	})
+	return result
+}
+
+// WriteDiff writes the diff in a human readable form, a line prefixed with + for every added and with - for every
+// removed value and omission.
+func WriteDiff(writer io.Writer, diff ReportDiff) error {
+	for _, d := range diff.Obfuscators {
+		if _, err := fmt.Fprintf(writer, "obfuscator %d (%s): %d added, %d removed\n", d.Obfuscator, d.Type, len(d.Added), len(d.Removed)); err != nil {
+			return err
+		}
+		for _, v := range d.Added {
+			if err := writeValueDiff(writer, "+", v, "previously"); err != nil {
+				return err
+			}
+		}
+		for _, v := range d.Removed {
+			if err := writeValueDiff(writer, "-", v, "now"); err != nil {
+				return err
+			}
+		}
+	}
+	if len(diff.AddedOmissions) == 0 && len(diff.RemovedOmissions) == 0 {
+		return nil
+	}
+	if _, err := fmt.Fprintf(writer, "omissions: %d added, %d removed\n", len(diff.AddedOmissions), len(diff.RemovedOmissions)); err != nil {
+		return err
+	}
+	for _, o := range diff.AddedOmissions {
+		if _, err := fmt.Fprintf(writer, "  + %s (rule %d)\n", o.Path, o.Rule); err != nil {
+			return err
+		}
+	}
+	for _, o := range diff.RemovedOmissions {
+		if _, err := fmt.Fprintf(writer, "  - %s (rule %d)\n", o.Path, o.Rule); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func writeValueDiff(writer io.Writer, prefix string, v ValueDiff, other string) error {
+	line := fmt.Sprintf("  %s %s -> %s", prefix, v.Original, v.ReplacedWith)
+	if v.OtherObfuscator != nil {
+		line += fmt.Sprintf(" (%s obfuscator %d)", other, *v.OtherObfuscator)
+	}
+	_, err := fmt.Fprintln(writer, line)
+	return err
+}
@@ -0,0 +1,126 @@
+package reporting
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/openshift/must-gather-clean/pkg/schema"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func replacement(original string, replacedWith string) Replacement {
+	return Replacement{Canonical: original, ReplacedWith: replacedWith, Occurrences: []Occurrence{{Original: original, Count: 1}}}
+}
+
+func TestDiffReports(t *testing.T) {
+	config := schema.SchemaJsonConfig{Obfuscate: []schema.Obfuscate{{Type: schema.ObfuscateTypeIP}, {Type: schema.ObfuscateTypeDomain}}}
+	oldReport := &Report{
+		Config: config,
+		Replacements: [][]Replacement{
+			{replacement("10.0.0.1", "ip-1"), replacement("10.0.0.2", "ip-2")},
+			{replacement("example.com", "domain-1")},
+		},
+		Omissions: []Omission{{Path: "secret.yaml", Rule: 0}, {Path: "old.log", Rule: 1}},
+	}
+	newReport := &Report{
+		Config: config,
+		Replacements: [][]Replacement{
+			{replacement("10.0.0.1", "ip-1"), replacement("10.0.0.3", "ip-3")},
+			{replacement("example.com", "domain-1"), replacement("10.0.0.2", "domain-2")},
+		},
+		Omissions: []Omission{{Path: "secret.yaml", Rule: 0}, {Path: "new.log", Rule: 2}},
+	}
+
+	diff := DiffReports(oldReport, newReport)
+	assert.Equal(t, ReportDiff{
+		Obfuscators: []ObfuscatorDiff{
+			{
+				Obfuscator: 0,
+				Type:       schema.ObfuscateTypeIP,
+				Added:      []ValueDiff{{Original: "10.0.0.3", ReplacedWith: "ip-3"}},
+				Removed:    []ValueDiff{{Original: "10.0.0.2", ReplacedWith: "ip-2", OtherObfuscator: intPointer(1)}},
+			},
+			{
+				Obfuscator: 1,
+				Type:       schema.ObfuscateTypeDomain,
+				Added:      []ValueDiff{{Original: "10.0.0.2", ReplacedWith: "domain-2", OtherObfuscator: intPointer(0)}},
+			},
+		},
+		AddedOmissions:   []Omission{{Path: "new.log", Rule: 2}},
+		RemovedOmissions: []Omission{{Path: "old.log", Rule: 1}},
+	}, diff)
+	assert.False(t, diff.Empty())
+
+	buffer := &bytes.Buffer{}
+	require.NoError(t, WriteDiff(buffer, diff))
+	assert.Equal(t, `obfuscator 0 (IP): 1 added, 1 removed
+  + 10.0.0.3 -> ip-3
+  - 10.0.0.2 -> ip-2 (now obfuscator 1)
+obfuscator 1 (Domain): 1 added, 0 removed
+  + 10.0.0.2 -> domain-2 (previously obfuscator 0)
+omissions: 1 added, 1 removed
+  + new.log (rule 2)
+  - old.log (rule 1)
+`, buffer.String())
+}
+
+func TestDiffReportsWithoutDifferences(t *testing.T) {
+	report := &Report{Replacements: [][]Replacement{{replacement("10.0.0.1", "ip-1")}}, Omissions: []Omission{{Path: "a.log"}}}
+	diff := DiffReports(report, report)
+	assert.True(t, diff.Empty())
+}
+
+func TestDiffReportsWithAddedObfuscator(t *testing.T) {
+	oldReport := &Report{}
+	newReport := &Report{Replacements: [][]Replacement{{{Canonical: "mykey", ReplacedWith: "key-1"}}}}
+
+	diff := DiffReports(oldReport, newReport)
+	assert.Equal(t, []ObfuscatorDiff{{Obfuscator: 0, Added: []ValueDiff{{Original: "mykey", ReplacedWith: "key-1"}}}}, diff.Obfuscators)
+}
+
+func TestReadReport(t *testing.T) {
+	dir := t.TempDir()
+	for _, tc := range []struct {
+		name     string
+		content  string
+		expected *Report
+		err      string
+	}{
+		{
+			name:     "report.yaml",
+			content:  "replacements:\n- - canonical: 10.0.0.1\n    replacedWith: ip-1\n",
+			expected: &Report{Replacements: [][]Replacement{{{Canonical: "10.0.0.1", ReplacedWith: "ip-1"}}}},
+		},
+		{
+			name:     "report.json",
+			content:  `{"omissions":[{"path":"a.log","rule":1}]}`,
+			expected: &Report{Omissions: []Omission{{Path: "a.log", Rule: 1}}},
+		},
+		{
+			name:    "summary.yaml",
+			content: "summary:\n- obfuscator: 0\n  values: 2\n  occurrences: 3\n",
+			err:     "is a summary without the replaced values, use the mapping of the split report instead",
+		},
+		{
+			name:    "mapping.yaml.age",
+			content: "-----BEGIN AGE ENCRYPTED FILE-----\n-----END AGE ENCRYPTED FILE-----\n",
+			err:     "is an encrypted mapping, decrypt it with the reveal command first",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			path := filepath.Join(dir, tc.name)
+			require.NoError(t, os.WriteFile(path, []byte(tc.content), 0600))
+			report, err := ReadReport(path)
+			if tc.err != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tc.err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tc.expected, report)
+		})
+	}
+}
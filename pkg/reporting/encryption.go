@@ -0,0 +1,104 @@
+package reporting
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"strings"
+
+	"filippo.io/age"
+	"filippo.io/age/armor"
+)
+
+// ageHeader starts every binary age file, the mappings are written ASCII armored with armor.Header instead.
+const ageHeader = "age-encryption.org/"
+
+// EncryptMapping encrypts the mapping with age to the given recipients. The result is ASCII armored, so it can be
+// handled like the other reporting files.
+func EncryptMapping(mapping []byte, recipients []age.Recipient) ([]byte, error) {
+	encrypted := &bytes.Buffer{}
+	armored := armor.NewWriter(encrypted)
+	writer, err := age.Encrypt(armored, recipients...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encrypt mapping: %w", err)
+	}
+	if _, err := writer.Write(mapping); err != nil {
+		return nil, fmt.Errorf("failed to encrypt mapping: %w", err)
+	}
+	if err := writer.Close(); err != nil {
+		return nil, fmt.Errorf("failed to encrypt mapping: %w", err)
+	}
+	if err := armored.Close(); err != nil {
+		return nil, fmt.Errorf("failed to encrypt mapping: %w", err)
+	}
+	return encrypted.Bytes(), nil
+}
+
+// DecryptMapping decrypts a mapping that was encrypted with EncryptMapping, or any other age file in the binary or the
+// armored format.
+func DecryptMapping(data []byte, identities []age.Identity) ([]byte, error) {
+	if !IsEncryptedMapping(data) {
+		return nil, errors.New("not an encrypted mapping")
+	}
+	var input io.Reader = bytes.NewReader(data)
+	if !bytes.HasPrefix(data, []byte(ageHeader)) {
+		input = armor.NewReader(bytes.NewReader(bytes.TrimSpace(data)))
+	}
+	reader, err := age.Decrypt(input, identities...)
+	if err != nil {
+		var noMatch *age.NoIdentityMatchError
+		if errors.As(err, &noMatch) {
+			return nil, errors.New("failed to decrypt mapping, it is encrypted for another key")
+		}
+		return nil, fmt.Errorf("failed to decrypt mapping: %w", err)
+	}
+	mapping, err := ioutil.ReadAll(reader)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt mapping: %w", err)
+	}
+	return mapping, nil
+}
+
+// IsEncryptedMapping returns true if the data is an age encrypted file, binary or ASCII armored.
+func IsEncryptedMapping(data []byte) bool {
+	return bytes.HasPrefix(data, []byte(ageHeader)) || bytes.HasPrefix(bytes.TrimSpace(data), []byte(armor.Header))
+}
+
+// ReadRecipients parses the given age X25519 recipient, for example as printed by age-keygen -y, or reads the
+// recipients from the file at the given path, one per line.
+func ReadRecipients(recipient string) ([]age.Recipient, error) {
+	if strings.HasPrefix(recipient, "age1") {
+		r, err := age.ParseX25519Recipient(recipient)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse recipient %s: %w", recipient, err)
+		}
+		return []age.Recipient{r}, nil
+	}
+	f, err := os.Open(recipient)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read recipients %s: %w", recipient, err)
+	}
+	defer f.Close()
+	recipients, err := age.ParseRecipients(f)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse recipients %s: %w", recipient, err)
+	}
+	return recipients, nil
+}
+
+// ReadIdentities reads the age X25519 identities from the file at the given path, as written by age-keygen.
+func ReadIdentities(path string) ([]age.Identity, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read identities %s: %w", path, err)
+	}
+	defer f.Close()
+	identities, err := age.ParseIdentities(f)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse identities %s: %w", path, err)
+	}
+	return identities, nil
+}
@@ -0,0 +1,105 @@
+package reporting
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"filippo.io/age"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testingIdentity(t *testing.T) *age.X25519Identity {
+	identity, err := age.GenerateX25519Identity()
+	require.NoError(t, err)
+	return identity
+}
+
+func TestEncryptMapping(t *testing.T) {
+	identity := testingIdentity(t)
+	mapping := []byte("replacements:\n  - - canonical: 10.0.0.1\n")
+
+	encrypted, err := EncryptMapping(mapping, []age.Recipient{identity.Recipient()})
+	require.NoError(t, err)
+	assert.True(t, IsEncryptedMapping(encrypted))
+	assert.True(t, bytes.HasPrefix(encrypted, []byte("-----BEGIN AGE ENCRYPTED FILE-----\n")))
+	assert.NotContains(t, string(encrypted), "10.0.0.1")
+
+	decrypted, err := DecryptMapping(encrypted, []age.Identity{identity})
+	require.NoError(t, err)
+	assert.Equal(t, mapping, decrypted)
+}
+
+func TestDecryptBinaryMapping(t *testing.T) {
+	identity := testingIdentity(t)
+	encrypted := &bytes.Buffer{}
+	w, err := age.Encrypt(encrypted, identity.Recipient())
+	require.NoError(t, err)
+	_, err = w.Write([]byte("secret"))
+	require.NoError(t, err)
+	require.NoError(t, w.Close())
+	assert.True(t, IsEncryptedMapping(encrypted.Bytes()))
+
+	decrypted, err := DecryptMapping(encrypted.Bytes(), []age.Identity{identity})
+	require.NoError(t, err)
+	assert.Equal(t, "secret", string(decrypted))
+}
+
+func TestDecryptMappingWithOtherKey(t *testing.T) {
+	encrypted, err := EncryptMapping([]byte("secret"), []age.Recipient{testingIdentity(t).Recipient()})
+	require.NoError(t, err)
+
+	_, err = DecryptMapping(encrypted, []age.Identity{testingIdentity(t)})
+	assert.EqualError(t, err, "failed to decrypt mapping, it is encrypted for another key")
+}
+
+func TestDecryptMappingNotEncrypted(t *testing.T) {
+	assert.False(t, IsEncryptedMapping([]byte("replacements: []\n")))
+	_, err := DecryptMapping([]byte("replacements: []\n"), []age.Identity{testingIdentity(t)})
+	assert.EqualError(t, err, "not an encrypted mapping")
+}
+
+func TestReadRecipientsAndIdentities(t *testing.T) {
+	first := testingIdentity(t)
+	second := testingIdentity(t)
+	dir := t.TempDir()
+	recipientsPath := filepath.Join(dir, "recipients.txt")
+	require.NoError(t, os.WriteFile(recipientsPath, []byte("# team\n"+first.Recipient().String()+"\n\n"+second.Recipient().String()+"\n"), 0600))
+	identityPath := filepath.Join(dir, "key.txt")
+	require.NoError(t, os.WriteFile(identityPath, []byte("# public key: "+second.Recipient().String()+"\n"+second.String()+"\n"), 0600))
+
+	recipients, err := ReadRecipients(first.Recipient().String())
+	require.NoError(t, err)
+	assert.Equal(t, []age.Recipient{first.Recipient()}, recipients)
+
+	recipients, err = ReadRecipients(recipientsPath)
+	require.NoError(t, err)
+	assert.Equal(t, []age.Recipient{first.Recipient(), second.Recipient()}, recipients)
+
+	identities, err := ReadIdentities(identityPath)
+	require.NoError(t, err)
+	encrypted, err := EncryptMapping([]byte("secret"), recipients)
+	require.NoError(t, err)
+	decrypted, err := DecryptMapping(encrypted, identities)
+	require.NoError(t, err)
+	assert.Equal(t, "secret", string(decrypted))
+
+	_, err = ReadRecipients("age1invalid")
+	assert.Error(t, err)
+	_, err = ReadRecipients(filepath.Join(dir, "missing.txt"))
+	assert.Error(t, err)
+	_, err = ReadIdentities(recipientsPath)
+	assert.Error(t, err)
+}
+
+func TestDecryptMappingWithSurroundingWhitespace(t *testing.T) {
+	identity := testingIdentity(t)
+	encrypted, err := EncryptMapping([]byte("replacements: []\n"), []age.Recipient{identity.Recipient()})
+	require.NoError(t, err)
+
+	decrypted, err := DecryptMapping(append(append([]byte("\n"), encrypted...), '\n'), []age.Identity{identity})
+	require.NoError(t, err)
+	assert.Equal(t, "replacements: []\n", string(decrypted))
+}
@@ -0,0 +1,139 @@
+package reporting
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+
+	"github.com/openshift/must-gather-clean/pkg/schema"
+	"gopkg.in/yaml.v3"
+	"k8s.io/klog/v2"
+)
+
+// Location is a place in the must-gather where a value was replaced.
+type Location struct {
+	// Path is relative to the must-gather root
+	Path string `yaml:"path" json:"path"`
+	// Line starts at 1, it is omitted for file paths
+	Line int `yaml:"line,omitempty" json:"line,omitempty"`
+	// Obfuscator is the index of the obfuscator in the configuration
+	Obfuscator int                    `yaml:"obfuscator" json:"obfuscator"`
+	Target     schema.ObfuscateTarget `yaml:"target" json:"target"`
+}
+
+// ValueLocations are the locations of a replaced value, up to the maximum number of locations per value.
+type ValueLocations struct {
+	Original     string `yaml:"original" json:"original"`
+	ReplacedWith string `yaml:"replacedWith" json:"replacedWith"`
+	// Omitted is the number of locations that exceeded the maximum
+	Omitted   uint       `yaml:"omitted,omitempty" json:"omitted,omitempty"`
+	Locations []Location `yaml:"locations" json:"locations"`
+}
+
+type LocationReport struct {
+	Values []ValueLocations `yaml:"values"`
+}
+
+type LocationTracker interface {
+	// TrackLocation records the location where the original value was replaced.
+	TrackLocation(original, replacedWith string, location Location)
+}
+
+type LocationReporter interface {
+	LocationTracker
+
+	// Report returns the recorded locations.
+	Report() LocationReport
+
+	// WriteLocations writes the recorded locations into the given path, will create folders if necessary.
+	WriteLocations(path string) error
+}
+
+type locationKey struct {
+	original     string
+	replacedWith string
+}
+
+// SimpleLocationReporter records the locations of all replaced values in memory. It is safe for concurrent use.
+type SimpleLocationReporter struct {
+	maxPerValue int
+	lock        sync.Mutex
+	values      map[locationKey]*ValueLocations
+}
+
+var _ LocationReporter = (*SimpleLocationReporter)(nil)
+
+func (s *SimpleLocationReporter) TrackLocation(original, replacedWith string, location Location) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	key := locationKey{original: original, replacedWith: replacedWith}
+	value, ok := s.values[key]
+	if !ok {
+		value = &ValueLocations{Original: original, ReplacedWith: replacedWith}
+		s.values[key] = value
+	}
+	if len(value.Locations) >= s.maxPerValue {
+		value.Omitted++
+		return
+	}
+	value.Locations = append(value.Locations, location)
+}
+
+// Report returns the recorded locations ordered by value, path and line.
+func (s *SimpleLocationReporter) Report() LocationReport {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	report := LocationReport{Values: []ValueLocations{}}
+	for _, value := range s.values {
+		v := *value
+		v.Locations = append([]Location{}, value.Locations...)
+		sort.SliceStable(v.Locations, func(i, j int) bool {
+			if v.Locations[i].Path != v.Locations[j].Path {
+				return v.Locations[i].Path < v.Locations[j].Path
+			}
+			return v.Locations[i].Line < v.Locations[j].Line
+		})
+		report.Values = append(report.Values, v)
+	}
+	sort.Slice(report.Values, func(i, j int) bool {
+		if report.Values[i].Original != report.Values[j].Original {
+			return report.Values[i].Original < report.Values[j].Original
+		}
+		return report.Values[i].ReplacedWith < report.Values[j].ReplacedWith
+	})
+	return report
+}
+
+func (s *SimpleLocationReporter) WriteLocations(path string) error {
+	err := os.MkdirAll(filepath.Dir(path), 0700)
+	if err != nil {
+		return fmt.Errorf("failed to create reporting output folder: %w", err)
+	}
+
+	locationsFile, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("failed to open locations file %s: %w", path, err)
+	}
+	defer locationsFile.Close()
+
+	err = yaml.NewEncoder(locationsFile).Encode(s.Report())
+	if err != nil {
+		return fmt.Errorf("failed to write locations at %s: %w", path, err)
+	}
+
+	klog.V(3).Infof("successfully saved obfuscation locations in %s", path)
+
+	return nil
+}
+
+// NewSimpleLocationReporter returns a LocationReporter that records up to maxPerValue locations for each value.
+func NewSimpleLocationReporter(maxPerValue int) *SimpleLocationReporter {
+	return &SimpleLocationReporter{
+		maxPerValue: maxPerValue,
+		values:      map[locationKey]*ValueLocations{},
+	}
+}
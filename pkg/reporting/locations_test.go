@@ -0,0 +1,61 @@
+package reporting
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	"github.com/openshift/must-gather-clean/pkg/schema"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+)
+
+func TestLocationReporter(t *testing.T) {
+	r := NewSimpleLocationReporter(2)
+	r.TrackLocation("10.0.0.1", "x-ipv4-0000000001-x", Location{Path: "b.log", Line: 3, Obfuscator: 0, Target: schema.ObfuscateTargetFileContents})
+	r.TrackLocation("10.0.0.1", "x-ipv4-0000000001-x", Location{Path: "a.log", Line: 7, Obfuscator: 0, Target: schema.ObfuscateTargetFileContents})
+	r.TrackLocation("10.0.0.1", "x-ipv4-0000000001-x", Location{Path: "c.log", Line: 1, Obfuscator: 0, Target: schema.ObfuscateTargetFileContents})
+	r.TrackLocation("10.0.0.1", "x-ipv4-0000000001-x", Location{Path: "d.log", Line: 1, Obfuscator: 0, Target: schema.ObfuscateTargetFileContents})
+	r.TrackLocation("10.0.0.1", "xxx.xxx.xxx.xxx", Location{Path: "e.log", Line: 2, Obfuscator: 1, Target: schema.ObfuscateTargetFileContents})
+	r.TrackLocation("00:50:56:ac:8e:92", "x-mac-0000000001-x", Location{Path: "nodes/00:50:56:ac:8e:92", Obfuscator: 2, Target: schema.ObfuscateTargetFilePath})
+
+	expected := LocationReport{Values: []ValueLocations{
+		{
+			Original:     "00:50:56:ac:8e:92",
+			ReplacedWith: "x-mac-0000000001-x",
+			Locations:    []Location{{Path: "nodes/00:50:56:ac:8e:92", Obfuscator: 2, Target: schema.ObfuscateTargetFilePath}},
+		},
+		{
+			Original:     "10.0.0.1",
+			ReplacedWith: "x-ipv4-0000000001-x",
+			Omitted:      2,
+			Locations: []Location{
+				{Path: "a.log", Line: 7, Obfuscator: 0, Target: schema.ObfuscateTargetFileContents},
+				{Path: "b.log", Line: 3, Obfuscator: 0, Target: schema.ObfuscateTargetFileContents},
+			},
+		},
+		{
+			Original:     "10.0.0.1",
+			ReplacedWith: "xxx.xxx.xxx.xxx",
+			Locations:    []Location{{Path: "e.log", Line: 2, Obfuscator: 1, Target: schema.ObfuscateTargetFileContents}},
+		},
+	}}
+	assert.Equal(t, expected, r.Report())
+
+	locationsFile := filepath.Join(t.TempDir(), "reports", "locations.yaml")
+	require.NoError(t, r.WriteLocations(locationsFile))
+	bytes, err := ioutil.ReadFile(locationsFile)
+	require.NoError(t, err)
+	var written LocationReport
+	require.NoError(t, yaml.Unmarshal(bytes, &written))
+	assert.Equal(t, expected, written)
+}
+
+func TestLocationReporterEmpty(t *testing.T) {
+	locationsFile := filepath.Join(t.TempDir(), "locations.yaml")
+	require.NoError(t, NewSimpleLocationReporter(1).WriteLocations(locationsFile))
+	bytes, err := ioutil.ReadFile(locationsFile)
+	require.NoError(t, err)
+	assert.Equal(t, "values: []\n", string(bytes))
+}
@@ -0,0 +1,127 @@
+package reporting
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/openshift/must-gather-clean/pkg/omitter"
+	"github.com/openshift/must-gather-clean/pkg/schema"
+	"gopkg.in/yaml.v3"
+)
+
+// Omission is a file that was omitted along with the rule that omitted it.
+type Omission struct {
+	Path string `yaml:"path" json:"path"`
+	// Rule is the index of the omit rule in the configuration
+	Rule     int             `yaml:"rule" json:"rule"`
+	Type     schema.OmitType `yaml:"type,omitempty" json:"type,omitempty"`
+	Criteria string          `yaml:"criteria,omitempty" json:"criteria,omitempty"`
+	// Resource is the first resource in the file that matched a Kubernetes rule
+	Resource *OmittedResource `yaml:"resource,omitempty" json:"resource,omitempty"`
+	Bytes    int64            `yaml:"bytes,omitempty" json:"bytes,omitempty"`
+}
+
+// UnmarshalYAML also accepts plain paths, as written by previous versions of the report.
+func (o *Omission) UnmarshalYAML(value *yaml.Node) error {
+	if value.Kind == yaml.ScalarNode {
+		*o = Omission{Path: value.Value}
+		return nil
+	}
+	type plain Omission
+	return value.Decode((*plain)(o))
+}
+
+type OmittedResource struct {
+	ApiVersion string `yaml:"apiVersion,omitempty" json:"apiVersion,omitempty"`
+	Kind       string `yaml:"kind,omitempty" json:"kind,omitempty"`
+	Namespace  string `yaml:"namespace,omitempty" json:"namespace,omitempty"`
+	Name       string `yaml:"name,omitempty" json:"name,omitempty"`
+}
+
+// String returns the kind, namespace and name of the resource, or an empty string if there is no resource.
+func (r *OmittedResource) String() string {
+	if r == nil {
+		return ""
+	}
+	if r.Namespace == "" {
+		return fmt.Sprintf("%s %s", r.Kind, r.Name)
+	}
+	return fmt.Sprintf("%s %s/%s", r.Kind, r.Namespace, r.Name)
+}
+
+// OmissionRule aggregates the omissions of a single omit rule of the configuration.
+type OmissionRule struct {
+	Rule     int             `yaml:"rule" json:"rule"`
+	Type     schema.OmitType `yaml:"type" json:"type"`
+	Criteria string          `yaml:"criteria,omitempty" json:"criteria,omitempty"`
+	Count    uint            `yaml:"count" json:"count"`
+	Bytes    int64           `yaml:"bytes" json:"bytes"`
+}
+
+// ruleCriteria describes what the omit rule matches on.
+func ruleCriteria(o schema.Omit) string {
+	switch o.Type {
+	case schema.OmitTypeFile:
+		if o.Pattern != nil {
+			return *o.Pattern
+		}
+	case schema.OmitTypeKubernetes:
+		if o.KubernetesResource == nil {
+			return ""
+		}
+		var criteria []string
+		if o.KubernetesResource.Kind != nil {
+			criteria = append(criteria, fmt.Sprintf("kind=%s", *o.KubernetesResource.Kind))
+		}
+		if o.KubernetesResource.ApiVersion != nil {
+			criteria = append(criteria, fmt.Sprintf("apiVersion=%s", *o.KubernetesResource.ApiVersion))
+		}
+		if len(o.KubernetesResource.Namespaces) > 0 {
+			criteria = append(criteria, fmt.Sprintf("namespaces=%s", strings.Join(o.KubernetesResource.Namespaces, ",")))
+		}
+		return strings.Join(criteria, " ")
+	}
+	return ""
+}
+
+// toOmissions describes the omissions with the rules of the configuration, the omissions are ordered by path.
+func toOmissions(omissions []omitter.Omission, rules []schema.Omit) []Omission {
+	var result []Omission
+	for _, o := range omissions {
+		omission := Omission{Path: o.Path, Rule: o.Rule, Bytes: o.Size}
+		if o.Rule >= 0 && o.Rule < len(rules) {
+			omission.Type = rules[o.Rule].Type
+			omission.Criteria = ruleCriteria(rules[o.Rule])
+		}
+		if o.Resource != nil {
+			omission.Resource = &OmittedResource{
+				ApiVersion: o.Resource.ApiVersion,
+				Kind:       o.Resource.Kind,
+				Namespace:  o.Resource.Metadata.Namespace,
+				Name:       o.Resource.Metadata.Name,
+			}
+		}
+		result = append(result, omission)
+	}
+	sort.SliceStable(result, func(i, j int) bool {
+		return result[i].Path < result[j].Path
+	})
+	return result
+}
+
+// toOmissionRules aggregates the omissions for every rule of the configuration, including the rules that did not omit
+// anything.
+func toOmissionRules(omissions []Omission, rules []schema.Omit) []OmissionRule {
+	var result []OmissionRule
+	for i, r := range rules {
+		result = append(result, OmissionRule{Rule: i, Type: r.Type, Criteria: ruleCriteria(r)})
+	}
+	for _, o := range omissions {
+		if o.Rule >= 0 && o.Rule < len(result) {
+			result[o.Rule].Count++
+			result[o.Rule].Bytes += o.Bytes
+		}
+	}
+	return result
+}
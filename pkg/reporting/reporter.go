@@ -1,39 +1,71 @@
 package reporting
 
 import (
+	"bytes"
+	"errors"
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
 
+	"filippo.io/age"
+	"github.com/openshift/must-gather-clean/pkg/discovery"
 	"github.com/openshift/must-gather-clean/pkg/obfuscator"
+	"github.com/openshift/must-gather-clean/pkg/omitter"
 	"github.com/openshift/must-gather-clean/pkg/schema"
-	"gopkg.in/yaml.v3"
 	"k8s.io/klog/v2"
 )
 
 type Replacement struct {
-	Canonical    string       `yaml:"canonical,omitempty"`
-	ReplacedWith string       `yaml:"replacedWith,omitempty"`
-	Occurrences  []Occurrence `yaml:"occurrences,omitempty"`
+	Canonical    string       `yaml:"canonical,omitempty" json:"canonical,omitempty"`
+	ReplacedWith string       `yaml:"replacedWith,omitempty" json:"replacedWith,omitempty"`
+	Occurrences  []Occurrence `yaml:"occurrences,omitempty" json:"occurrences,omitempty"`
 }
 
 type Occurrence struct {
-	Original string `yaml:"original,omitempty"`
-	Count    uint   `yaml:"count,omitempty"`
+	Original string `yaml:"original,omitempty" json:"original,omitempty"`
+	Count    uint   `yaml:"count,omitempty" json:"count,omitempty"`
 }
 
 type Report struct {
-	Replacements [][]Replacement         `yaml:"replacements,omitempty"`
-	Omissions    []string                `yaml:"omissions,omitempty"`
-	Config       schema.SchemaJsonConfig `yaml:"config,omitempty"`
+	Summary      []ObfuscatorSummary `yaml:"summary,omitempty" json:"summary,omitempty"`
+	Replacements [][]Replacement     `yaml:"replacements,omitempty" json:"replacements,omitempty"`
+	Skipped      [][]Occurrence      `yaml:"skipped,omitempty" json:"skipped,omitempty"`
+	Omissions    []Omission          `yaml:"omissions,omitempty" json:"omissions,omitempty"`
+	// OmissionRules aggregates the omissions per omit rule of the configuration
+	OmissionRules []OmissionRule          `yaml:"omissionRules,omitempty" json:"omissionRules,omitempty"`
+	Discovered    discovery.Report        `yaml:"discovered,omitempty" json:"discovered,omitempty"`
+	Config        schema.SchemaJsonConfig `yaml:"config,omitempty" json:"config,omitempty"`
+	Statistics    *Statistics             `yaml:"statistics,omitempty" json:"statistics,omitempty"`
+	// Locations are only part of the mapping of a split report, otherwise they are written into a file of their own
+	Locations []ValueLocations `yaml:"locations,omitempty" json:"locations,omitempty"`
 }
 
 type Reporter interface {
 	// WriteReport writes the final report into the given path, will create folders if necessary.
 	WriteReport(path string) error
 
+	// WriteSplitReport writes a summary without any original values into the summary path and the full report into the
+	// mapping path. The paths of the summary must have been collected with CollectSummaryPaths. If recipients are given,
+	// the mapping is encrypted to them with age.
+	WriteSplitReport(summaryPath string, mappingPath string, recipients []age.Recipient) error
+
+	// CollectDiscoveryReport collects the values that were discovered in the must-gather.
+	CollectDiscoveryReport(report discovery.Report)
+
 	// CollectOmitterReport collects the omitter's omission results.
-	CollectOmitterReport(omitter []string)
+	CollectOmitterReport(omissions []omitter.Omission)
+
+	// CollectStatistics collects the metrics of the run.
+	CollectStatistics(statistics Statistics)
+
+	// CollectLocations collects the locations of the replaced values.
+	CollectLocations(locations LocationReport)
+
+	// CollectSummaryPaths obfuscates the paths and resource names of the omitted and the slowest files for the summary of
+	// a split report. It must be called after the omissions and statistics and before the obfuscator report are
+	// collected, so that the values that are only replaced in these paths are part of the mapping as well.
+	CollectSummaryPaths(obfuscate func(string) string)
 
 	// CollectObfuscatorReport will call the Report method on the obfuscator and collect the individual obfuscation results.
 	CollectObfuscatorReport(obfuscatorReport []obfuscator.ReplacementReport)
@@ -41,14 +73,120 @@ type Reporter interface {
 
 type SimpleReporter struct {
 	replacements [][]Replacement
-	omissions    []string
+	skipped      [][]Occurrence
+	omissions    []omitter.Omission
+	discovered   discovery.Report
 	config       *schema.SchemaJson
+	writer       ReportWriter
+	statistics   *Statistics
+	locations    []ValueLocations
+	// summaryPaths holds the obfuscated omissions and statistics of a split report
+	summaryPaths *Report
 }
 
 var _ Reporter = (*SimpleReporter)(nil)
 
 func (s *SimpleReporter) WriteReport(path string) error {
+	err := writeReportFile(path, func(w io.Writer) error {
+		return s.writer.Write(w, s.report())
+	})
+	if err != nil {
+		return err
+	}
+
+	klog.V(3).Infof("successfully saved obfuscation report in %s", path)
+
+	return nil
+}
+
+func (s *SimpleReporter) CollectSummaryPaths(obfuscate func(string) string) {
+	report := s.report()
+	summaryPaths := &Report{}
+	if report.Statistics != nil {
+		statistics := *report.Statistics
+		statistics.SlowestFiles = nil
+		for _, f := range report.Statistics.SlowestFiles {
+			f.Path = obfuscate(f.Path)
+			statistics.SlowestFiles = append(statistics.SlowestFiles, f)
+		}
+		summaryPaths.Statistics = &statistics
+	}
+	for _, o := range report.Omissions {
+		o.Path = obfuscate(o.Path)
+		if o.Resource != nil {
+			o.Resource = &OmittedResource{
+				ApiVersion: o.Resource.ApiVersion,
+				Kind:       o.Resource.Kind,
+				Namespace:  obfuscate(o.Resource.Namespace),
+				Name:       obfuscate(o.Resource.Name),
+			}
+		}
+		summaryPaths.Omissions = append(summaryPaths.Omissions, o)
+	}
+	s.summaryPaths = summaryPaths
+}
+
+func (s *SimpleReporter) WriteSplitReport(summaryPath string, mappingPath string, recipients []age.Recipient) error {
+	if s.summaryPaths == nil {
+		return errors.New("the paths of the summary were not collected")
+	}
+	report := s.report()
+
+	summary := Report{
+		Summary:       report.Summary,
+		Omissions:     s.summaryPaths.Omissions,
+		OmissionRules: report.OmissionRules,
+		Statistics:    s.summaryPaths.Statistics,
+	}
+	err := writeReportFile(summaryPath, func(w io.Writer) error {
+		return s.writer.Write(w, summary)
+	})
+	if err != nil {
+		return err
+	}
+
+	// the mapping is always written as YAML, so it can be used as a configuration to reproduce the run
+	err = writeReportFile(mappingPath, func(w io.Writer) error {
+		if len(recipients) == 0 {
+			return yamlReportWriter{}.Write(w, report)
+		}
+		mapping := &bytes.Buffer{}
+		if err := (yamlReportWriter{}).Write(mapping, report); err != nil {
+			return err
+		}
+		encrypted, err := EncryptMapping(mapping.Bytes(), recipients)
+		if err != nil {
+			return err
+		}
+		_, err = w.Write(encrypted)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	klog.V(3).Infof("successfully saved obfuscation summary in %s and mapping in %s", summaryPath, mappingPath)
 
+	return nil
+}
+
+func (s *SimpleReporter) report() Report {
+	omissions := toOmissions(s.omissions, s.config.Config.Omit)
+	report := Report{
+		Replacements:  s.replacements,
+		Skipped:       s.skipped,
+		Omissions:     omissions,
+		OmissionRules: toOmissionRules(omissions, s.config.Config.Omit),
+		Discovered:    s.discovered,
+		Config:        s.config.Config,
+		Statistics:    s.statistics,
+		Locations:     s.locations,
+	}
+	report.Summary = summarize(report)
+	return report
+}
+
+func writeReportFile(path string, write func(w io.Writer) error) error {
 	reportingFolder := filepath.Dir(path)
 	err := os.MkdirAll(reportingFolder, 0700)
 	if err != nil {
@@ -59,44 +197,50 @@ func (s *SimpleReporter) WriteReport(path string) error {
 	if err != nil {
 		return fmt.Errorf("failed to open report file %s: %w", path, err)
 	}
+	defer reportFile.Close()
 
-	rEncoder := yaml.NewEncoder(reportFile)
-	err = rEncoder.Encode(Report{
-		Replacements: s.replacements,
-		Omissions:    s.omissions,
-		Config:       s.config.Config,
-	})
+	err = write(reportFile)
 	if err != nil {
 		return fmt.Errorf("failed to write report at %s: %w", path, err)
 	}
+	return nil
+}
 
-	klog.V(3).Infof("successfully saved obfuscation report in %s", path)
+func (s *SimpleReporter) CollectDiscoveryReport(report discovery.Report) {
+	s.discovered = report
+}
 
-	return nil
+func (s *SimpleReporter) CollectStatistics(statistics Statistics) {
+	s.statistics = &statistics
+}
+
+func (s *SimpleReporter) CollectLocations(locations LocationReport) {
+	s.locations = locations.Values
 }
 
-func (s *SimpleReporter) CollectOmitterReport(report []string) {
+func (s *SimpleReporter) CollectOmitterReport(report []omitter.Omission) {
 	s.omissions = append(s.omissions, report...)
 }
 
 func (s *SimpleReporter) CollectObfuscatorReport(obfuscatorReport []obfuscator.ReplacementReport) {
+	hasSkipped := false
+	var skipped [][]Occurrence
 	for _, report := range obfuscatorReport {
 		var replacements []Replacement
 		for _, r := range report.Replacements {
-			var occurrences []Occurrence
-			for original, cnt := range r.Counter {
-				occurrences = append(occurrences, Occurrence{
-					Original: original,
-					Count:    cnt,
-				})
-			}
 			replacements = append(replacements, Replacement{
 				Canonical:    r.Canonical,
 				ReplacedWith: r.ReplacedWith,
-				Occurrences:  occurrences,
+				Occurrences:  toOccurrences(r.Counter),
 			})
 		}
 		s.replacements = append(s.replacements, replacements)
+		skipped = append(skipped, toOccurrences(report.Skipped))
+		hasSkipped = hasSkipped || len(report.Skipped) > 0
+	}
+	// the skipped strings are listed at the same index as the replacements of their obfuscator, only if there are any
+	if hasSkipped {
+		s.skipped = append(s.skipped, skipped...)
 	}
 
 	for i := range s.config.Config.Obfuscate {
@@ -112,10 +256,28 @@ func (s *SimpleReporter) CollectObfuscatorReport(obfuscatorReport []obfuscator.R
 	}
 }
 
+func toOccurrences(counter map[string]uint) []Occurrence {
+	var occurrences []Occurrence
+	for original, cnt := range counter {
+		occurrences = append(occurrences, Occurrence{
+			Original: original,
+			Count:    cnt,
+		})
+	}
+	return occurrences
+}
+
 func NewSimpleReporter(config *schema.SchemaJson) Reporter {
+	return NewSimpleReporterWithWriter(config, yamlReportWriter{})
+}
+
+// NewSimpleReporterWithWriter returns a Reporter that writes the report with the given writer, for example in another
+// format than YAML.
+func NewSimpleReporterWithWriter(config *schema.SchemaJson, writer ReportWriter) Reporter {
 	return &SimpleReporter{
 		replacements: [][]Replacement{},
-		omissions:    []string{},
+		omissions:    []omitter.Omission{},
 		config:       config,
+		writer:       writer,
 	}
 }
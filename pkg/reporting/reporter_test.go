@@ -4,9 +4,13 @@ import (
 	"io/ioutil"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 
+	"filippo.io/age"
+	"github.com/openshift/must-gather-clean/pkg/kube"
 	"github.com/openshift/must-gather-clean/pkg/obfuscator"
+	"github.com/openshift/must-gather-clean/pkg/omitter"
 	"github.com/openshift/must-gather-clean/pkg/schema"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -33,7 +37,7 @@ func TestReportingHappyPath(t *testing.T) {
 		},
 	}
 	r := NewSimpleReporter(config)
-	r.CollectOmitterReport([]string{"some path"})
+	r.CollectOmitterReport([]omitter.Omission{{Path: "some path", Rule: 0, Size: 12}})
 	multiObfuscator := obfuscator.NewMultiObfuscator([]obfuscator.ReportingObfuscator{
 		obfuscator.NoopObfuscator{Replacements: map[string]string{
 			"this": "that",
@@ -59,8 +63,158 @@ func TestReportingHappyPath(t *testing.T) {
 			{Replacement{Canonical: "this", ReplacedWith: "that", Occurrences: []Occurrence{{Original: "this", Count: 1}}}},
 			{Replacement{Canonical: "another", ReplacedWith: "something", Occurrences: []Occurrence{{Original: "another", Count: 1}}}},
 		},
-		Omissions: []string{"some path"},
-		Config:    config.Config,
+		Omissions:     []Omission{{Path: "some path", Rule: 0, Type: schema.OmitTypeFile, Criteria: "random-pattern", Bytes: 12}},
+		OmissionRules: []OmissionRule{{Rule: 0, Type: schema.OmitTypeFile, Criteria: "random-pattern", Count: 1, Bytes: 12}},
+		Config:        config.Config,
+	})
+}
+
+func TestReportingSkipped(t *testing.T) {
+	config := &schema.SchemaJson{
+		Config: schema.SchemaJsonConfig{
+			Obfuscate: []schema.Obfuscate{
+				{Type: schema.ObfuscateTypeIP},
+				{Type: schema.ObfuscateTypeMAC},
+			},
+		},
+	}
+	r := NewSimpleReporter(config)
+	r.CollectObfuscatorReport([]obfuscator.ReplacementReport{
+		{
+			Replacements: []obfuscator.Replacement{{Canonical: "10.0.0.1", ReplacedWith: "x-ipv4-0000000001-x", Counter: map[string]uint{"10.0.0.1": 1}}},
+			Skipped:      map[string]uint{"8.8.8.8": 3},
+		},
+		{
+			Replacements: []obfuscator.Replacement{{Canonical: "00:50:56:AC:8E:92", ReplacedWith: "x-mac-0000000001-x", Counter: map[string]uint{"00:50:56:ac:8e:92": 1}}},
+		},
+	})
+
+	tmpInputDir, err := os.MkdirTemp("", "reporter-*")
+	require.NoError(t, err)
+	defer func() {
+		_ = os.RemoveAll(tmpInputDir)
+	}()
+
+	reportFile := filepath.Join(tmpInputDir, "report.yaml")
+	require.NoError(t, r.WriteReport(reportFile))
+
+	assertReportMatches(t, reportFile, Report{
+		Replacements: [][]Replacement{
+			{Replacement{Canonical: "10.0.0.1", ReplacedWith: "x-ipv4-0000000001-x", Occurrences: []Occurrence{{Original: "10.0.0.1", Count: 1}}}},
+			{Replacement{Canonical: "00:50:56:AC:8E:92", ReplacedWith: "x-mac-0000000001-x", Occurrences: []Occurrence{{Original: "00:50:56:ac:8e:92", Count: 1}}}},
+		},
+		Skipped: [][]Occurrence{{{Original: "8.8.8.8", Count: 3}}, {}},
+		Config:  config.Config,
+	})
+}
+
+func TestReportingOmissionRules(t *testing.T) {
+	kind := "Secret"
+	pattern := "*.log"
+	config := &schema.SchemaJson{
+		Config: schema.SchemaJsonConfig{
+			Omit: []schema.Omit{
+				{Type: schema.OmitTypeSymbolicLink},
+				{Type: schema.OmitTypeFile, Pattern: &pattern},
+				{Type: schema.OmitTypeKubernetes, KubernetesResource: &schema.OmitKubernetesResource{Kind: &kind, Namespaces: []string{"kube-system", "default"}}},
+			},
+		},
+	}
+	r := NewSimpleReporter(config)
+	r.CollectOmitterReport([]omitter.Omission{
+		{Path: "secrets.yaml", Rule: 2, Size: 100, Resource: &kube.Resource{ApiVersion: "v1", Kind: "Secret", Metadata: kube.Metadata{Namespace: "default", Name: "token"}}},
+		{Path: "b.log", Rule: 1, Size: 20},
+		{Path: "a.log", Rule: 1, Size: 10},
+	})
+	r.CollectObfuscatorReport(nil)
+
+	tmpInputDir, err := os.MkdirTemp("", "reporter-*")
+	require.NoError(t, err)
+	defer func() {
+		_ = os.RemoveAll(tmpInputDir)
+	}()
+
+	reportFile := filepath.Join(tmpInputDir, "report.yaml")
+	require.NoError(t, r.WriteReport(reportFile))
+
+	assertReportMatches(t, reportFile, Report{
+		Omissions: []Omission{
+			{Path: "a.log", Rule: 1, Type: schema.OmitTypeFile, Criteria: "*.log", Bytes: 10},
+			{Path: "b.log", Rule: 1, Type: schema.OmitTypeFile, Criteria: "*.log", Bytes: 20},
+			{Path: "secrets.yaml", Rule: 2, Type: schema.OmitTypeKubernetes, Criteria: "kind=Secret namespaces=kube-system,default", Bytes: 100,
+				Resource: &OmittedResource{ApiVersion: "v1", Kind: "Secret", Namespace: "default", Name: "token"}},
+		},
+		OmissionRules: []OmissionRule{
+			{Rule: 0, Type: schema.OmitTypeSymbolicLink},
+			{Rule: 1, Type: schema.OmitTypeFile, Criteria: "*.log", Count: 2, Bytes: 30},
+			{Rule: 2, Type: schema.OmitTypeKubernetes, Criteria: "kind=Secret namespaces=kube-system,default", Count: 1, Bytes: 100},
+		},
+		Config: config.Config,
+	})
+}
+
+func TestReportingLegacyOmissions(t *testing.T) {
+	var report Report
+	require.NoError(t, yaml.Unmarshal([]byte("omissions:\n  - some/path\n  - path: other/path\n    rule: 1\n"), &report))
+	assert.Equal(t, []Omission{{Path: "some/path"}, {Path: "other/path", Rule: 1}}, report.Omissions)
+}
+
+func TestReportingSplit(t *testing.T) {
+	kind := "Secret"
+	config := &schema.SchemaJson{
+		Config: schema.SchemaJsonConfig{
+			Obfuscate: []schema.Obfuscate{{Type: schema.ObfuscateTypeKeywords, Replacement: map[string]string{"secret-name": "keyword"}}},
+			Omit:      []schema.Omit{{Type: schema.OmitTypeKubernetes, KubernetesResource: &schema.OmitKubernetesResource{Kind: &kind}}},
+		},
+	}
+	r := NewSimpleReporter(config)
+	r.CollectOmitterReport([]omitter.Omission{
+		{Path: "secret-name.yaml", Rule: 0, Size: 5, Resource: &kube.Resource{ApiVersion: "v1", Kind: "Secret", Metadata: kube.Metadata{Name: "secret-name"}}},
+	})
+	r.CollectStatistics(Statistics{DurationSeconds: 2, SlowestFiles: []FileDuration{{Path: "secret-name.log", Seconds: 1, Bytes: 3}}})
+
+	dir := t.TempDir()
+	identity := testingIdentity(t)
+	recipients := []age.Recipient{identity.Recipient()}
+	assert.EqualError(t, r.WriteSplitReport(filepath.Join(dir, "report.yaml"), filepath.Join(dir, "mapping.yaml.age"), recipients),
+		"the paths of the summary were not collected")
+
+	r.CollectSummaryPaths(func(path string) string {
+		return strings.ReplaceAll(path, "secret-name", "keyword")
+	})
+	r.CollectObfuscatorReport([]obfuscator.ReplacementReport{{
+		Replacements: []obfuscator.Replacement{{Canonical: "secret-name", ReplacedWith: "keyword", Counter: map[string]uint{"secret-name": 3}}},
+	}})
+	require.NoError(t, r.WriteSplitReport(filepath.Join(dir, "report.yaml"), filepath.Join(dir, "mapping.yaml.age"), recipients))
+
+	summary, err := ioutil.ReadFile(filepath.Join(dir, "report.yaml"))
+	require.NoError(t, err)
+	assert.NotContains(t, string(summary), "secret-name")
+	actualSummary := &Report{}
+	require.NoError(t, yaml.Unmarshal(summary, actualSummary))
+	assert.Equal(t, &Report{
+		Summary: []ObfuscatorSummary{{Obfuscator: 0, Type: schema.ObfuscateTypeKeywords, Values: 1, Occurrences: 3}},
+		Omissions: []Omission{{Path: "keyword.yaml", Rule: 0, Type: schema.OmitTypeKubernetes, Criteria: "kind=Secret", Bytes: 5,
+			Resource: &OmittedResource{ApiVersion: "v1", Kind: "Secret", Name: "keyword"}}},
+		OmissionRules: []OmissionRule{{Rule: 0, Type: schema.OmitTypeKubernetes, Criteria: "kind=Secret", Count: 1, Bytes: 5}},
+		Statistics:    &Statistics{DurationSeconds: 2, SlowestFiles: []FileDuration{{Path: "keyword.log", Seconds: 1, Bytes: 3}}},
+	}, actualSummary)
+
+	encrypted, err := ioutil.ReadFile(filepath.Join(dir, "mapping.yaml.age"))
+	require.NoError(t, err)
+	assert.NotContains(t, string(encrypted), "secret-name")
+	mapping, err := DecryptMapping(encrypted, []age.Identity{identity})
+	require.NoError(t, err)
+	mappingFile := filepath.Join(dir, "mapping.yaml")
+	require.NoError(t, os.WriteFile(mappingFile, mapping, 0600))
+	assertReportMatches(t, mappingFile, Report{
+		Replacements: [][]Replacement{
+			{Replacement{Canonical: "secret-name", ReplacedWith: "keyword", Occurrences: []Occurrence{{Original: "secret-name", Count: 3}}}},
+		},
+		Omissions: []Omission{{Path: "secret-name.yaml", Rule: 0, Type: schema.OmitTypeKubernetes, Criteria: "kind=Secret", Bytes: 5,
+			Resource: &OmittedResource{ApiVersion: "v1", Kind: "Secret", Name: "secret-name"}}},
+		OmissionRules: []OmissionRule{{Rule: 0, Type: schema.OmitTypeKubernetes, Criteria: "kind=Secret", Count: 1, Bytes: 5}},
+		Config:        config.Config,
 	})
 }
 
@@ -73,6 +227,8 @@ func assertReportMatches(t *testing.T, file string, expectedReport Report) {
 	require.NoError(t, err)
 
 	assert.Equal(t, expectedReport.Omissions, actualReport.Omissions)
+	assert.Equal(t, expectedReport.OmissionRules, actualReport.OmissionRules)
 	assert.Equal(t, expectedReport.Replacements, actualReport.Replacements)
+	assert.Equal(t, expectedReport.Skipped, actualReport.Skipped)
 	assert.Equal(t, expectedReport.Config, actualReport.Config)
 }
@@ -0,0 +1,226 @@
+package reporting
+
+import (
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/openshift/must-gather-clean/pkg/schema"
+	"k8s.io/klog/v2"
+)
+
+// Statistics are the metrics of a cleaning run.
+type Statistics struct {
+	DurationSeconds float64        `yaml:"durationSeconds" json:"durationSeconds"`
+	Files           FileStatistics `yaml:"files" json:"files"`
+	// BytesPerSecond is the throughput of the whole run over all input files
+	BytesPerSecond float64                `yaml:"bytesPerSecond" json:"bytesPerSecond"`
+	Obfuscators    []ObfuscatorStatistics `yaml:"obfuscators,omitempty" json:"obfuscators,omitempty"`
+	// SlowestFiles are the files that took the longest to process, the slowest first
+	SlowestFiles []FileDuration `yaml:"slowestFiles,omitempty" json:"slowestFiles,omitempty"`
+}
+
+// FileStatistics count the files of the input by how they were processed.
+type FileStatistics struct {
+	Processed       uint  `yaml:"processed" json:"processed"`
+	ProcessedBytes  int64 `yaml:"processedBytes" json:"processedBytes"`
+	Obfuscated      uint  `yaml:"obfuscated" json:"obfuscated"`
+	ObfuscatedBytes int64 `yaml:"obfuscatedBytes" json:"obfuscatedBytes"`
+	Omitted         uint  `yaml:"omitted" json:"omitted"`
+	OmittedBytes    int64 `yaml:"omittedBytes" json:"omittedBytes"`
+	// Copied are the symbolic links, which are relinked instead of obfuscated
+	Copied uint `yaml:"copied" json:"copied"`
+}
+
+// ObfuscatorStatistics is the time spent by a single obfuscator, summed up over all workers.
+type ObfuscatorStatistics struct {
+	// Obfuscator is the index of the obfuscator in the configuration
+	Obfuscator int                  `yaml:"obfuscator" json:"obfuscator"`
+	Type       schema.ObfuscateType `yaml:"type,omitempty" json:"type,omitempty"`
+	Calls      uint64               `yaml:"calls" json:"calls"`
+	Seconds    float64              `yaml:"seconds" json:"seconds"`
+}
+
+type FileDuration struct {
+	Path    string  `yaml:"path" json:"path"`
+	Seconds float64 `yaml:"seconds" json:"seconds"`
+	Bytes   int64   `yaml:"bytes" json:"bytes"`
+}
+
+type inputFile struct {
+	size     int64
+	symlink  bool
+	duration time.Duration
+}
+
+// StatisticsCollector records the files of a run and how long they took to process. It is safe for concurrent use.
+type StatisticsCollector struct {
+	start        time.Time
+	slowestFiles int
+	lock         sync.Mutex
+	files        map[string]*inputFile
+}
+
+func (s *StatisticsCollector) TrackInput(path string, info fs.FileInfo) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	s.file(path).size = info.Size()
+	s.file(path).symlink = info.Mode()&fs.ModeSymlink != 0
+}
+
+func (s *StatisticsCollector) TrackFile(path string, duration time.Duration, _ error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	s.file(path).duration = duration
+}
+
+func (s *StatisticsCollector) file(path string) *inputFile {
+	f, ok := s.files[path]
+	if !ok {
+		f = &inputFile{}
+		s.files[path] = f
+	}
+	return f
+}
+
+// Statistics returns the metrics of the run up to now. The omitted paths are relative to the input folder, the
+// obfuscators are in the order of the configuration.
+func (s *StatisticsCollector) Statistics(omitted []string, obfuscators []ObfuscatorStatistics) Statistics {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	duration := time.Since(s.start)
+	stats := Statistics{DurationSeconds: duration.Seconds(), Obfuscators: obfuscators}
+	isOmitted := map[string]bool{}
+	for _, path := range omitted {
+		isOmitted[path] = true
+	}
+	for path, f := range s.files {
+		stats.Files.Processed++
+		stats.Files.ProcessedBytes += f.size
+		switch {
+		case isOmitted[path]:
+			stats.Files.Omitted++
+			stats.Files.OmittedBytes += f.size
+		case f.symlink:
+			stats.Files.Copied++
+		default:
+			stats.Files.Obfuscated++
+			stats.Files.ObfuscatedBytes += f.size
+		}
+		stats.SlowestFiles = append(stats.SlowestFiles, FileDuration{Path: path, Seconds: f.duration.Seconds(), Bytes: f.size})
+	}
+	if duration > 0 {
+		stats.BytesPerSecond = float64(stats.Files.ProcessedBytes) / duration.Seconds()
+	}
+
+	sort.Slice(stats.SlowestFiles, func(i, j int) bool {
+		if stats.SlowestFiles[i].Seconds != stats.SlowestFiles[j].Seconds {
+			return stats.SlowestFiles[i].Seconds > stats.SlowestFiles[j].Seconds
+		}
+		return stats.SlowestFiles[i].Path < stats.SlowestFiles[j].Path
+	})
+	if len(stats.SlowestFiles) > s.slowestFiles {
+		stats.SlowestFiles = stats.SlowestFiles[:s.slowestFiles]
+	}
+	return stats
+}
+
+// NewStatisticsCollector returns a collector that measures the duration of the run from now on and reports up to the
+// given number of slowest files.
+func NewStatisticsCollector(slowestFiles int) *StatisticsCollector {
+	return &StatisticsCollector{
+		start:        time.Now(),
+		slowestFiles: slowestFiles,
+		files:        map[string]*inputFile{},
+	}
+}
+
+type prometheusSample struct {
+	labels string
+	value  string
+}
+
+type prometheusMetric struct {
+	name    string
+	help    string
+	samples []prometheusSample
+}
+
+// WritePrometheus writes the statistics in the Prometheus text format, for example to be collected by the textfile
+// collector of the node exporter.
+func WritePrometheus(writer io.Writer, stats Statistics) error {
+	metrics := []prometheusMetric{
+		{name: "must_gather_clean_duration_seconds", help: "Duration of the cleaning run.", samples: []prometheusSample{{value: formatFloat(stats.DurationSeconds)}}},
+		{name: "must_gather_clean_throughput_bytes_per_second", help: "Processed input bytes per second.", samples: []prometheusSample{{value: formatFloat(stats.BytesPerSecond)}}},
+		{name: "must_gather_clean_files", help: "Number of input files by how they were processed.", samples: []prometheusSample{
+			{labels: `{outcome="processed"}`, value: strconv.FormatUint(uint64(stats.Files.Processed), 10)},
+			{labels: `{outcome="obfuscated"}`, value: strconv.FormatUint(uint64(stats.Files.Obfuscated), 10)},
+			{labels: `{outcome="omitted"}`, value: strconv.FormatUint(uint64(stats.Files.Omitted), 10)},
+			{labels: `{outcome="copied"}`, value: strconv.FormatUint(uint64(stats.Files.Copied), 10)},
+		}},
+		{name: "must_gather_clean_bytes", help: "Size of the input files by how they were processed.", samples: []prometheusSample{
+			{labels: `{outcome="processed"}`, value: strconv.FormatInt(stats.Files.ProcessedBytes, 10)},
+			{labels: `{outcome="obfuscated"}`, value: strconv.FormatInt(stats.Files.ObfuscatedBytes, 10)},
+			{labels: `{outcome="omitted"}`, value: strconv.FormatInt(stats.Files.OmittedBytes, 10)},
+		}},
+	}
+	if len(stats.Obfuscators) > 0 {
+		seconds := prometheusMetric{name: "must_gather_clean_obfuscator_seconds", help: "Time spent by each obfuscator, summed up over all workers."}
+		calls := prometheusMetric{name: "must_gather_clean_obfuscator_calls", help: "Number of calls of each obfuscator."}
+		for _, o := range stats.Obfuscators {
+			labels := fmt.Sprintf(`{obfuscator="%d",type="%s"}`, o.Obfuscator, o.Type)
+			seconds.samples = append(seconds.samples, prometheusSample{labels: labels, value: formatFloat(o.Seconds)})
+			calls.samples = append(calls.samples, prometheusSample{labels: labels, value: strconv.FormatUint(o.Calls, 10)})
+		}
+		metrics = append(metrics, seconds, calls)
+	}
+
+	for _, m := range metrics {
+		if _, err := fmt.Fprintf(writer, "# HELP %s %s\n# TYPE %s gauge\n", m.name, m.help, m.name); err != nil {
+			return err
+		}
+		for _, s := range m.samples {
+			if _, err := fmt.Fprintf(writer, "%s%s %s\n", m.name, s.labels, s.value); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// WritePrometheusFile writes the statistics in the Prometheus text format into the given path, will create folders if
+// necessary.
+func WritePrometheusFile(path string, stats Statistics) error {
+	err := os.MkdirAll(filepath.Dir(path), 0700)
+	if err != nil {
+		return fmt.Errorf("failed to create statistics output folder: %w", err)
+	}
+
+	file, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("failed to open statistics file %s: %w", path, err)
+	}
+	defer file.Close()
+
+	err = WritePrometheus(file, stats)
+	if err != nil {
+		return fmt.Errorf("failed to write statistics at %s: %w", path, err)
+	}
+
+	klog.V(3).Infof("successfully saved statistics in %s", path)
+
+	return nil
+}
+
+func formatFloat(f float64) string {
+	return strconv.FormatFloat(f, 'g', -1, 64)
+}
@@ -0,0 +1,85 @@
+package reporting
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/openshift/must-gather-clean/pkg/schema"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStatisticsCollector(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{"a.log": "0123456789", "b.yaml": "01234", "omitted.log": "012"}
+	for name, content := range files {
+		require.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte(content), 0600))
+	}
+	require.NoError(t, os.Symlink("a.log", filepath.Join(dir, "link")))
+
+	collector := NewStatisticsCollector(2)
+	for _, name := range []string{"a.log", "b.yaml", "omitted.log", "link"} {
+		info, err := os.Lstat(filepath.Join(dir, name))
+		require.NoError(t, err)
+		collector.TrackInput(name, info)
+	}
+	collector.TrackFile("a.log", 3*time.Second, nil)
+	collector.TrackFile("b.yaml", time.Second, nil)
+	collector.TrackFile("omitted.log", 2*time.Second, nil)
+	collector.TrackFile("link", time.Millisecond, nil)
+
+	obfuscators := []ObfuscatorStatistics{{Obfuscator: 0, Type: schema.ObfuscateTypeIP, Calls: 4, Seconds: 0.5}}
+	stats := collector.Statistics([]string{"omitted.log"}, obfuscators)
+	linkInfo, err := os.Lstat(filepath.Join(dir, "link"))
+	require.NoError(t, err)
+	assert.Equal(t, FileStatistics{
+		Processed:       4,
+		ProcessedBytes:  18 + linkInfo.Size(),
+		Obfuscated:      2,
+		ObfuscatedBytes: 15,
+		Omitted:         1,
+		OmittedBytes:    3,
+		Copied:          1,
+	}, stats.Files)
+	assert.Equal(t, obfuscators, stats.Obfuscators)
+	assert.Equal(t, []FileDuration{{Path: "a.log", Seconds: 3, Bytes: 10}, {Path: "omitted.log", Seconds: 2, Bytes: 3}}, stats.SlowestFiles)
+	assert.Greater(t, stats.DurationSeconds, 0.0)
+	assert.Greater(t, stats.BytesPerSecond, 0.0)
+}
+
+func TestWritePrometheus(t *testing.T) {
+	buffer := &bytes.Buffer{}
+	require.NoError(t, WritePrometheus(buffer, Statistics{
+		DurationSeconds: 1.5,
+		BytesPerSecond:  200,
+		Files:           FileStatistics{Processed: 3, ProcessedBytes: 300, Obfuscated: 1, ObfuscatedBytes: 100, Omitted: 1, OmittedBytes: 200, Copied: 1},
+		Obfuscators:     []ObfuscatorStatistics{{Obfuscator: 0, Type: schema.ObfuscateTypeIP, Calls: 12, Seconds: 0.25}},
+	}))
+	assert.Equal(t, `# HELP must_gather_clean_duration_seconds Duration of the cleaning run.
+# TYPE must_gather_clean_duration_seconds gauge
+must_gather_clean_duration_seconds 1.5
+# HELP must_gather_clean_throughput_bytes_per_second Processed input bytes per second.
+# TYPE must_gather_clean_throughput_bytes_per_second gauge
+must_gather_clean_throughput_bytes_per_second 200
+# HELP must_gather_clean_files Number of input files by how they were processed.
+# TYPE must_gather_clean_files gauge
+must_gather_clean_files{outcome="processed"} 3
+must_gather_clean_files{outcome="obfuscated"} 1
+must_gather_clean_files{outcome="omitted"} 1
+must_gather_clean_files{outcome="copied"} 1
+# HELP must_gather_clean_bytes Size of the input files by how they were processed.
+# TYPE must_gather_clean_bytes gauge
+must_gather_clean_bytes{outcome="processed"} 300
+must_gather_clean_bytes{outcome="obfuscated"} 100
+must_gather_clean_bytes{outcome="omitted"} 200
+# HELP must_gather_clean_obfuscator_seconds Time spent by each obfuscator, summed up over all workers.
+# TYPE must_gather_clean_obfuscator_seconds gauge
+must_gather_clean_obfuscator_seconds{obfuscator="0",type="IP"} 0.25
+# HELP must_gather_clean_obfuscator_calls Number of calls of each obfuscator.
+# TYPE must_gather_clean_obfuscator_calls gauge
+must_gather_clean_obfuscator_calls{obfuscator="0",type="IP"} 12
+`, buffer.String())
+}
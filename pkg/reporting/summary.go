@@ -0,0 +1,34 @@
+package reporting
+
+import "github.com/openshift/must-gather-clean/pkg/schema"
+
+// ObfuscatorSummary counts the replacements of a single obfuscator without revealing any original value, so it can be
+// shared along with the obfuscated output.
+type ObfuscatorSummary struct {
+	// Obfuscator is the index of the obfuscator in the configuration
+	Obfuscator  int                  `yaml:"obfuscator" json:"obfuscator"`
+	Type        schema.ObfuscateType `yaml:"type,omitempty" json:"type,omitempty"`
+	Values      int                  `yaml:"values" json:"values"`
+	Occurrences uint                 `yaml:"occurrences" json:"occurrences"`
+	Skipped     uint                 `yaml:"skipped,omitempty" json:"skipped,omitempty"`
+}
+
+// summarize counts the replaced values and occurrences of every obfuscator in the report.
+func summarize(report Report) []ObfuscatorSummary {
+	var summary []ObfuscatorSummary
+	for i, replacements := range report.Replacements {
+		s := ObfuscatorSummary{Obfuscator: i, Type: obfuscatorType(report, i), Values: len(replacements)}
+		for _, r := range replacements {
+			for _, o := range r.Occurrences {
+				s.Occurrences += o.Count
+			}
+		}
+		if i < len(report.Skipped) {
+			for _, o := range report.Skipped[i] {
+				s.Skipped += o.Count
+			}
+		}
+		summary = append(summary, s)
+	}
+	return summary
+}
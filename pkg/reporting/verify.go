@@ -0,0 +1,94 @@
+package reporting
+
+import (
+	"bytes"
+	"fmt"
+	"io/fs"
+	"io/ioutil"
+	"path/filepath"
+
+	"gopkg.in/yaml.v3"
+)
+
+// reportFileNames are the names of the files written by the reporting, in any of the report formats.
+var reportFileNames = map[string]bool{
+	"report.yaml":      true,
+	"report.json":      true,
+	"report.csv":       true,
+	"report.html":      true,
+	"mapping.yaml":     true,
+	"mapping.yaml.age": true,
+	"locations.yaml":   true,
+}
+
+// reportContentLimit is the size up to which YAML and JSON files are parsed, to check whether they contain a report.
+const reportContentLimit = 64 * 1024 * 1024
+
+// FindReports walks the folder and returns the paths of all files that look like reports, relative to the folder. Files
+// are matched by the names of the reporting files, the encrypted mapping header and by YAML or JSON content that relates
+// originals with their replacements. Symbolic links are not followed.
+func FindReports(folder string) ([]string, error) {
+	var reports []string
+	err := filepath.WalkDir(folder, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.Type().IsRegular() && !(d.Type()&fs.ModeSymlink != 0 && reportFileNames[d.Name()]) {
+			return nil
+		}
+		isReport, err := looksLikeReport(path, d)
+		if err != nil {
+			return err
+		}
+		if isReport {
+			rel, err := filepath.Rel(folder, path)
+			if err != nil {
+				return err
+			}
+			reports = append(reports, rel)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to search for reports in %s: %w", folder, err)
+	}
+	return reports, nil
+}
+
+func looksLikeReport(path string, d fs.DirEntry) (bool, error) {
+	if reportFileNames[d.Name()] {
+		return true, nil
+	}
+	extension := filepath.Ext(d.Name())
+	if extension != ".yaml" && extension != ".yml" && extension != ".json" && extension != ".age" {
+		return false, nil
+	}
+	info, err := d.Info()
+	if err != nil {
+		return false, err
+	}
+	if info.Size() > reportContentLimit {
+		return false, nil
+	}
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return false, err
+	}
+	if IsEncryptedMapping(data) {
+		return true, nil
+	}
+	// cheap check before parsing, every report with replacements contains their replacedWith
+	if !bytes.Contains(data, []byte("replacedWith")) {
+		return false, nil
+	}
+
+	var report map[string]interface{}
+	if err := yaml.Unmarshal(data, &report); err != nil {
+		// not every file in a must-gather is valid, those can't be reports either
+		return false, nil
+	}
+	_, hasReplacements := report["replacements"]
+	_, hasConfig := report["config"]
+	_, hasValues := report["values"]
+	return (hasReplacements && hasConfig) || hasValues, nil
+}
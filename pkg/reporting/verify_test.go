@@ -0,0 +1,52 @@
+package reporting
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"filippo.io/age"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFindReports(t *testing.T) {
+	dir := t.TempDir()
+	encrypted, err := EncryptMapping([]byte("replacements: []"), []age.Recipient{testingIdentity(t).Recipient()})
+	require.NoError(t, err)
+	files := map[string]string{
+		"report.html":                  "<html></html>",
+		"nested/mapping.yaml":          "",
+		"nested/renamed.yaml":          "replacements:\n  - - canonical: a\n      replacedWith: b\nconfig: {}\n",
+		"nested/renamed.json":          `{"replacements": [[{"canonical": "a", "replacedWith": "b"}]], "config": {}}`,
+		"nested/renamed-locations.yml": "values:\n  - original: a\n    replacedWith: b\n",
+		"nested/secret.age":            string(encrypted),
+		"pods.yaml":                    "kind: PodList\nitems: []\n",
+		"invalid.yaml":                 "replacedWith: [",
+		"replacements.yaml":            "replacements:\n  - replacedWith: b\n",
+		"report.log":                   "replacements:\n  - - canonical: a\n    replacedWith: b\nconfig: {}\n",
+	}
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0700))
+		require.NoError(t, os.WriteFile(path, []byte(content), 0600))
+	}
+	require.NoError(t, os.Symlink(filepath.Join(dir, "pods.yaml"), filepath.Join(dir, "report.yaml")))
+
+	reports, err := FindReports(dir)
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []string{
+		"report.html",
+		"report.yaml",
+		"nested/mapping.yaml",
+		"nested/renamed.yaml",
+		"nested/renamed.json",
+		"nested/renamed-locations.yml",
+		"nested/secret.age",
+	}, reports)
+}
+
+func TestFindReportsMissingFolder(t *testing.T) {
+	_, err := FindReports(filepath.Join(t.TempDir(), "missing"))
+	assert.Error(t, err)
+}
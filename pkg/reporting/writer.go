@@ -0,0 +1,348 @@
+package reporting
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"io"
+	"strconv"
+
+	"github.com/openshift/must-gather-clean/pkg/schema"
+	"gopkg.in/yaml.v3"
+)
+
+// ReportFormat is the file format of the report, it is also used as the file extension.
+type ReportFormat string
+
+const (
+	ReportFormatYAML ReportFormat = "yaml"
+	ReportFormatJSON ReportFormat = "json"
+	ReportFormatCSV  ReportFormat = "csv"
+	ReportFormatHTML ReportFormat = "html"
+)
+
+// ReportFormats are all supported report formats.
+var ReportFormats = []ReportFormat{ReportFormatYAML, ReportFormatJSON, ReportFormatCSV, ReportFormatHTML}
+
+// ReportWriter encodes a report in a file format.
+type ReportWriter interface {
+	// Format returns the format the report is written in.
+	Format() ReportFormat
+
+	// Write encodes the report into the writer.
+	Write(writer io.Writer, report Report) error
+}
+
+type yamlReportWriter struct{}
+
+func (yamlReportWriter) Format() ReportFormat {
+	return ReportFormatYAML
+}
+
+func (yamlReportWriter) Write(writer io.Writer, report Report) error {
+	return yaml.NewEncoder(writer).Encode(report)
+}
+
+type jsonReportWriter struct{}
+
+func (jsonReportWriter) Format() ReportFormat {
+	return ReportFormatJSON
+}
+
+func (jsonReportWriter) Write(writer io.Writer, report Report) error {
+	encoder := json.NewEncoder(writer)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(report)
+}
+
+// csvReportWriter writes one row for every replaced or skipped original, every omitted file and every omit rule. The
+// discovered values and the configuration are not included, since they don't fit into a table.
+type csvReportWriter struct{}
+
+func (csvReportWriter) Format() ReportFormat {
+	return ReportFormatCSV
+}
+
+func (csvReportWriter) Write(writer io.Writer, report Report) error {
+	w := csv.NewWriter(writer)
+	rows := [][]string{{"section", "obfuscator", "type", "canonical", "replacedWith", "original", "count", "rule", "criteria", "resource", "bytes"}}
+	for i, replacements := range report.Replacements {
+		for _, r := range replacements {
+			for _, o := range r.Occurrences {
+				rows = append(rows, []string{"replacement", strconv.Itoa(i), string(obfuscatorType(report, i)), r.Canonical, r.ReplacedWith, o.Original, strconv.FormatUint(uint64(o.Count), 10), "", "", "", ""})
+			}
+		}
+	}
+	for i, skipped := range report.Skipped {
+		for _, o := range skipped {
+			rows = append(rows, []string{"skipped", strconv.Itoa(i), string(obfuscatorType(report, i)), "", "", o.Original, strconv.FormatUint(uint64(o.Count), 10), "", "", "", ""})
+		}
+	}
+	for _, o := range report.Summary {
+		rows = append(rows, []string{"summary", strconv.Itoa(o.Obfuscator), string(o.Type), "", "", "", strconv.FormatUint(uint64(o.Occurrences), 10), "", "", "", ""})
+	}
+	for _, o := range report.Omissions {
+		rows = append(rows, []string{"omission", "", string(o.Type), "", "", o.Path, "", strconv.Itoa(o.Rule), o.Criteria, o.Resource.String(), strconv.FormatInt(o.Bytes, 10)})
+	}
+	for _, r := range report.OmissionRules {
+		rows = append(rows, []string{"omissionRule", "", string(r.Type), "", "", "", strconv.FormatUint(uint64(r.Count), 10), strconv.Itoa(r.Rule), r.Criteria, "", strconv.FormatInt(r.Bytes, 10)})
+	}
+
+	if err := w.WriteAll(rows); err != nil {
+		return fmt.Errorf("failed to write csv report: %w", err)
+	}
+	return nil
+}
+
+// htmlReportWriter writes a self-contained page with sortable tables, that can be reviewed in any browser.
+type htmlReportWriter struct{}
+
+func (htmlReportWriter) Format() ReportFormat {
+	return ReportFormatHTML
+}
+
+// htmlObfuscator is the section of a single obfuscator in the HTML report.
+type htmlObfuscator struct {
+	Index        int
+	Type         schema.ObfuscateType
+	Replacements []Replacement
+	Skipped      []Occurrence
+}
+
+type htmlStatistic struct {
+	Name  string
+	Value int
+}
+
+type htmlReport struct {
+	// Shareable is set for summaries, that don't contain original values
+	Shareable     bool
+	Statistics    []htmlStatistic
+	Summary       []ObfuscatorSummary
+	Obfuscators   []htmlObfuscator
+	Omissions     []Omission
+	OmissionRules []OmissionRule
+	RunStatistics *Statistics
+}
+
+func (htmlReportWriter) Write(writer io.Writer, report Report) error {
+	page := htmlReport{
+		Shareable:     len(report.Replacements) == 0 && len(report.Config.Obfuscate) == 0,
+		Summary:       report.Summary,
+		Omissions:     report.Omissions,
+		OmissionRules: report.OmissionRules,
+		RunStatistics: report.Statistics,
+	}
+	if len(page.Summary) == 0 {
+		page.Summary = summarize(report)
+	}
+	for i, replacements := range report.Replacements {
+		o := htmlObfuscator{Index: i, Type: obfuscatorType(report, i), Replacements: replacements}
+		if i < len(report.Skipped) {
+			o.Skipped = report.Skipped[i]
+		}
+		page.Obfuscators = append(page.Obfuscators, o)
+	}
+	values, occurrences, skipped, omittedBytes := 0, 0, 0, 0
+	for _, o := range page.Summary {
+		values += o.Values
+		occurrences += int(o.Occurrences)
+		skipped += int(o.Skipped)
+	}
+	for _, o := range report.Omissions {
+		omittedBytes += int(o.Bytes)
+	}
+	page.Statistics = []htmlStatistic{
+		{Name: "Obfuscators", Value: len(page.Summary)},
+		{Name: "Replaced values", Value: values},
+		{Name: "Replaced occurrences", Value: occurrences},
+		{Name: "Skipped occurrences", Value: skipped},
+		{Name: "Omitted files", Value: len(report.Omissions)},
+		{Name: "Omitted bytes", Value: omittedBytes},
+		{Name: "Discovered domains", Value: len(report.Discovered.Domains)},
+		{Name: "Discovered cluster identifiers", Value: len(report.Discovered.ClusterIdentifiers)},
+		{Name: "Discovered users and groups", Value: len(report.Discovered.Identities.Users) + len(report.Discovered.Identities.Groups)},
+	}
+
+	if err := htmlReportTemplate.Execute(writer, page); err != nil {
+		return fmt.Errorf("failed to write html report: %w", err)
+	}
+	return nil
+}
+
+func obfuscatorType(report Report, i int) schema.ObfuscateType {
+	if i < len(report.Config.Obfuscate) {
+		return report.Config.Obfuscate[i].Type
+	}
+	return ""
+}
+
+// NewReportWriter returns the writer for the given format.
+func NewReportWriter(format ReportFormat) (ReportWriter, error) {
+	switch format {
+	case ReportFormatYAML:
+		return yamlReportWriter{}, nil
+	case ReportFormatJSON:
+		return jsonReportWriter{}, nil
+	case ReportFormatCSV:
+		return csvReportWriter{}, nil
+	case ReportFormatHTML:
+		return htmlReportWriter{}, nil
+	default:
+		return nil, fmt.Errorf("unsupported report format '%s', expected one of %v", format, ReportFormats)
+	}
+}
+
+var htmlReportTemplate = template.Must(template.New("report").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>must-gather-clean report</title>
+<style>
+body { font-family: sans-serif; margin: 2em; }
+table { border-collapse: collapse; margin-bottom: 2em; }
+th, td { border: 1px solid #ccc; padding: 0.3em 0.6em; text-align: left; }
+th { background: #eee; }
+table.sortable th { cursor: pointer; }
+td.number { text-align: right; }
+</style>
+</head>
+<body>
+<h1>must-gather-clean report</h1>
+{{- if .Shareable}}
+<p>This summary does not contain any original values.</p>
+{{- else}}
+<p>This report relates the original confidential data with their replacements, do not share it.</p>
+{{- end}}
+
+<h2>Summary</h2>
+<table>
+{{- range .Statistics}}
+<tr><th>{{.Name}}</th><td class="number">{{.Value}}</td></tr>
+{{- end}}
+</table>
+{{- if .Summary}}
+<table class="sortable">
+<thead><tr><th data-type="number">Obfuscator</th><th>Type</th><th data-type="number">Values</th><th data-type="number">Occurrences</th><th data-type="number">Skipped</th></tr></thead>
+<tbody>
+{{- range .Summary}}
+<tr><td class="number">{{.Obfuscator}}</td><td>{{.Type}}</td><td class="number">{{.Values}}</td><td class="number">{{.Occurrences}}</td><td class="number">{{.Skipped}}</td></tr>
+{{- end}}
+</tbody>
+</table>
+{{- end}}
+{{- if .Obfuscators}}
+
+<h2>Replacements</h2>
+{{- end}}
+{{- range .Obfuscators}}
+<h3>Obfuscator {{.Index}}{{with .Type}}: {{.}}{{end}}</h3>
+{{- if .Replacements}}
+<table class="sortable">
+<thead><tr><th>Canonical</th><th>Replaced with</th><th>Original</th><th data-type="number">Count</th></tr></thead>
+<tbody>
+{{- range $r := .Replacements}}{{range .Occurrences}}
+<tr><td>{{$r.Canonical}}</td><td>{{$r.ReplacedWith}}</td><td>{{.Original}}</td><td class="number">{{.Count}}</td></tr>
+{{- end}}{{end}}
+</tbody>
+</table>
+{{- else}}
+<p>Nothing was replaced.</p>
+{{- end}}
+{{- if .Skipped}}
+<table class="sortable">
+<thead><tr><th>Skipped</th><th data-type="number">Count</th></tr></thead>
+<tbody>
+{{- range .Skipped}}
+<tr><td>{{.Original}}</td><td class="number">{{.Count}}</td></tr>
+{{- end}}
+</tbody>
+</table>
+{{- end}}
+{{- end}}
+
+<h2>Omitted files</h2>
+{{- if .Omissions}}
+<table class="sortable">
+<thead><tr><th>Path</th><th data-type="number">Rule</th><th>Type</th><th>Criteria</th><th>Resource</th><th data-type="number">Bytes</th></tr></thead>
+<tbody>
+{{- range .Omissions}}
+<tr><td>{{.Path}}</td><td class="number">{{.Rule}}</td><td>{{.Type}}</td><td>{{.Criteria}}</td><td>{{with .Resource}}{{.}}{{end}}</td><td class="number">{{.Bytes}}</td></tr>
+{{- end}}
+</tbody>
+</table>
+{{- else}}
+<p>No files were omitted.</p>
+{{- end}}
+{{- if .OmissionRules}}
+
+<h2>Omit rules</h2>
+<table class="sortable">
+<thead><tr><th data-type="number">Rule</th><th>Type</th><th>Criteria</th><th data-type="number">Files</th><th data-type="number">Bytes</th></tr></thead>
+<tbody>
+{{- range .OmissionRules}}
+<tr><td class="number">{{.Rule}}</td><td>{{.Type}}</td><td>{{.Criteria}}</td><td class="number">{{.Count}}</td><td class="number">{{.Bytes}}</td></tr>
+{{- end}}
+</tbody>
+</table>
+{{- end}}
+
+{{- with .RunStatistics}}
+
+<h2>Statistics</h2>
+<table>
+<tr><th>Duration (seconds)</th><td class="number">{{printf "%.3f" .DurationSeconds}}</td></tr>
+<tr><th>Throughput (bytes per second)</th><td class="number">{{printf "%.0f" .BytesPerSecond}}</td></tr>
+<tr><th>Processed files</th><td class="number">{{.Files.Processed}}</td></tr>
+<tr><th>Processed bytes</th><td class="number">{{.Files.ProcessedBytes}}</td></tr>
+<tr><th>Obfuscated files</th><td class="number">{{.Files.Obfuscated}}</td></tr>
+<tr><th>Obfuscated bytes</th><td class="number">{{.Files.ObfuscatedBytes}}</td></tr>
+<tr><th>Omitted files</th><td class="number">{{.Files.Omitted}}</td></tr>
+<tr><th>Omitted bytes</th><td class="number">{{.Files.OmittedBytes}}</td></tr>
+<tr><th>Copied symbolic links</th><td class="number">{{.Files.Copied}}</td></tr>
+</table>
+{{- if .Obfuscators}}
+<table class="sortable">
+<thead><tr><th data-type="number">Obfuscator</th><th>Type</th><th data-type="number">Calls</th><th data-type="number">Seconds</th></tr></thead>
+<tbody>
+{{- range .Obfuscators}}
+<tr><td class="number">{{.Obfuscator}}</td><td>{{.Type}}</td><td class="number">{{.Calls}}</td><td class="number">{{printf "%.3f" .Seconds}}</td></tr>
+{{- end}}
+</tbody>
+</table>
+{{- end}}
+{{- if .SlowestFiles}}
+<table class="sortable">
+<thead><tr><th>Slowest files</th><th data-type="number">Seconds</th><th data-type="number">Bytes</th></tr></thead>
+<tbody>
+{{- range .SlowestFiles}}
+<tr><td>{{.Path}}</td><td class="number">{{printf "%.3f" .Seconds}}</td><td class="number">{{.Bytes}}</td></tr>
+{{- end}}
+</tbody>
+</table>
+{{- end}}
+{{- end}}
+
+<script>
+document.querySelectorAll("table.sortable th").forEach(function (th) {
+  th.addEventListener("click", function () {
+    var table = th.closest("table");
+    var body = table.tBodies[0];
+    var column = Array.prototype.indexOf.call(th.parentNode.children, th);
+    var numeric = th.dataset.type === "number";
+    var ascending = th.dataset.order !== "asc";
+    th.dataset.order = ascending ? "asc" : "desc";
+    var rows = Array.prototype.slice.call(body.rows);
+    rows.sort(function (a, b) {
+      var x = a.cells[column].textContent, y = b.cells[column].textContent;
+      var result = numeric ? Number(x) - Number(y) : x.localeCompare(y);
+      return ascending ? result : -result;
+    });
+    rows.forEach(function (row) { body.appendChild(row); });
+  });
+});
+</script>
+</body>
+</html>
+`))
@@ -0,0 +1,94 @@
+package reporting
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/openshift/must-gather-clean/pkg/discovery"
+	"github.com/openshift/must-gather-clean/pkg/schema"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+)
+
+func testReport() Report {
+	return Report{
+		Replacements: [][]Replacement{
+			{{Canonical: "10.0.0.1", ReplacedWith: "x-ipv4-0000000001-x", Occurrences: []Occurrence{{Original: "10.0.0.1", Count: 3}, {Original: "10-0-0-1", Count: 1}}}},
+			{{Canonical: "<b>secret</b>", ReplacedWith: "keyword", Occurrences: []Occurrence{{Original: "<b>secret</b>", Count: 2}}}},
+		},
+		Skipped: [][]Occurrence{{{Original: "8.8.8.8", Count: 5}}, {}},
+		Omissions: []Omission{{Path: "secrets/a,b.yaml", Rule: 0, Type: schema.OmitTypeKubernetes, Criteria: "kind=Secret", Bytes: 42,
+			Resource: &OmittedResource{ApiVersion: "v1", Kind: "Secret", Namespace: "default", Name: "a"}}},
+		OmissionRules: []OmissionRule{{Rule: 0, Type: schema.OmitTypeKubernetes, Criteria: "kind=Secret", Count: 1, Bytes: 42}},
+		Discovered:    discovery.Report{Domains: []string{"example.com"}},
+		Config: schema.SchemaJsonConfig{Obfuscate: []schema.Obfuscate{
+			{Type: schema.ObfuscateTypeIP, ReplacementType: schema.ObfuscateReplacementTypeConsistent, Target: schema.ObfuscateTargetAll},
+			{Type: schema.ObfuscateTypeKeywords, ReplacementType: schema.ObfuscateReplacementTypeStatic, Target: schema.ObfuscateTargetAll},
+		}},
+	}
+}
+
+func writeReport(t *testing.T, format ReportFormat) string {
+	writer, err := NewReportWriter(format)
+	require.NoError(t, err)
+	assert.Equal(t, format, writer.Format())
+	buffer := &bytes.Buffer{}
+	require.NoError(t, writer.Write(buffer, testReport()))
+	return buffer.String()
+}
+
+func TestReportWriterYAML(t *testing.T) {
+	var report Report
+	require.NoError(t, yaml.Unmarshal([]byte(writeReport(t, ReportFormatYAML)), &report))
+	assert.Equal(t, testReport(), report)
+}
+
+func TestReportWriterJSON(t *testing.T) {
+	var report Report
+	require.NoError(t, json.Unmarshal([]byte(writeReport(t, ReportFormatJSON)), &report))
+	assert.Equal(t, testReport(), report)
+}
+
+func TestReportWriterCSV(t *testing.T) {
+	assert.Equal(t, `section,obfuscator,type,canonical,replacedWith,original,count,rule,criteria,resource,bytes
+replacement,0,IP,10.0.0.1,x-ipv4-0000000001-x,10.0.0.1,3,,,,
+replacement,0,IP,10.0.0.1,x-ipv4-0000000001-x,10-0-0-1,1,,,,
+replacement,1,Keywords,<b>secret</b>,keyword,<b>secret</b>,2,,,,
+skipped,0,IP,,,8.8.8.8,5,,,,
+omission,,Kubernetes,,,"secrets/a,b.yaml",,0,kind=Secret,Secret default/a,42
+omissionRule,,Kubernetes,,,,1,0,kind=Secret,,42
+`, writeReport(t, ReportFormatCSV))
+}
+
+func TestReportWriterHTML(t *testing.T) {
+	html := writeReport(t, ReportFormatHTML)
+	assert.Contains(t, html, "<h3>Obfuscator 0: IP</h3>")
+	assert.Contains(t, html, "<h3>Obfuscator 1: Keywords</h3>")
+	assert.Contains(t, html, `<tr><td>10.0.0.1</td><td>x-ipv4-0000000001-x</td><td>10-0-0-1</td><td class="number">1</td></tr>`)
+	assert.Contains(t, html, `<tr><td>8.8.8.8</td><td class="number">5</td></tr>`)
+	assert.Contains(t, html, `<tr><th>Replaced occurrences</th><td class="number">6</td></tr>`)
+	assert.Contains(t, html, `<tr><th>Omitted files</th><td class="number">1</td></tr>`)
+	assert.Contains(t, html, `<tr><th>Omitted bytes</th><td class="number">42</td></tr>`)
+	assert.Contains(t, html, `<tr><td>secrets/a,b.yaml</td><td class="number">0</td><td>Kubernetes</td><td>kind=Secret</td><td>Secret default/a</td><td class="number">42</td></tr>`)
+	assert.Contains(t, html, `<tr><td class="number">0</td><td>Kubernetes</td><td>kind=Secret</td><td class="number">1</td><td class="number">42</td></tr>`)
+	// originals are escaped, so they can't break the page
+	assert.Contains(t, html, "&lt;b&gt;secret&lt;/b&gt;")
+	assert.NotContains(t, html, "<b>secret</b>")
+}
+
+func TestReportWriterHTMLSummary(t *testing.T) {
+	buffer := &bytes.Buffer{}
+	require.NoError(t, htmlReportWriter{}.Write(buffer, Report{Summary: []ObfuscatorSummary{{Obfuscator: 0, Type: schema.ObfuscateTypeIP, Values: 2, Occurrences: 7}}}))
+	html := buffer.String()
+	assert.Contains(t, html, "This summary does not contain any original values.")
+	assert.Contains(t, html, `<tr><td class="number">0</td><td>IP</td><td class="number">2</td><td class="number">7</td><td class="number">0</td></tr>`)
+	assert.Contains(t, html, `<tr><th>Replaced occurrences</th><td class="number">7</td></tr>`)
+	assert.NotContains(t, html, "<h2>Replacements</h2>")
+}
+
+func TestReportWriterUnsupportedFormat(t *testing.T) {
+	_, err := NewReportWriter("xml")
+	assert.EqualError(t, err, "unsupported report format 'xml', expected one of [yaml json csv html]")
+}
@@ -6,21 +6,106 @@ import "fmt"
 import "reflect"
 import "encoding/json"
 
+type KubernetesResourceSelector struct {
+	// This defines the apiVersion of the kubernetes resource. That can be used to
+	// further refine specific versions of a resource.
+	ApiVersion *string `json:"apiVersion,omitempty" yaml:"apiVersion,omitempty"`
+
+	// This defines the kind of kubernetes resource, for example 'Route'.
+	Kind string `json:"kind" yaml:"kind"`
+
+	// This defines the namespaces the resource has to belong to, by default resources
+	// of all namespaces match.
+	Namespaces []string `json:"namespaces,omitempty" yaml:"namespaces,omitempty"`
+}
+
 type Obfuscate struct {
+	// When enabled on the type Domain obfuscator, the base domains of the cluster are
+	// discovered from well-known resources in the must-gather (DNS, Infrastructure
+	// and Ingress configs, routes, node names and the install-config) and obfuscated
+	// in addition to the supplied 'domainNames'. The discovered domains are listed in
+	// the report.
+	AutoDiscovery bool `json:"autoDiscovery,omitempty" yaml:"autoDiscovery,omitempty"`
+
+	// Only used with the type Regex obfuscator. The names or numbers of the capture
+	// groups in the 'regex' that should be obfuscated, the rest of the match is kept
+	// as-is. For example the regex 'password=(?P<pw>\S+)' with the capture group 'pw'
+	// only obfuscates the value of the password. The whole match is obfuscated when
+	// no capture groups are given.
+	CaptureGroups []string `json:"captureGroups,omitempty" yaml:"captureGroups,omitempty"`
+
+	// Only used with the type Keywords obfuscator. When enabled, keywords are matched
+	// regardless of the case of their ASCII letters.
+	CaseInsensitive bool `json:"caseInsensitive,omitempty" yaml:"caseInsensitive,omitempty"`
+
+	// By default all obfuscators match on the original input and every part of it is
+	// replaced at most once. When enabled, this obfuscator runs on the output of the
+	// obfuscators defined before it instead, for example to match the replacement of
+	// a keyword with a regex.
+	Chain bool `json:"chain,omitempty" yaml:"chain,omitempty"`
+
 	// The list of domains and their subdomains which should be obfuscated in the
 	// output, only used with the type Domain obfuscator.
 	DomainNames []string `json:"domainNames,omitempty" yaml:"domainNames,omitempty"`
 
+	// A list of strings that are never obfuscated by this obfuscator, even though
+	// they match its detection. Each exception is either a 'literal' string or a
+	// Golang 'regex' (https://pkg.go.dev/regexp). How often an exception prevented an
+	// obfuscation is listed under 'skipped' in the report.
+	Exceptions []ObfuscateExceptionsElem `json:"exceptions,omitempty" yaml:"exceptions,omitempty"`
+
+	// Only used with the type IP obfuscator. A list of IP addresses (e.g.
+	// '169.254.169.254'), CIDRs (e.g. '172.30.0.0/16') or named presets that will not
+	// be obfuscated. The presets are 'loopback', 'link-local', 'private', 'public',
+	// 'multicast', 'unspecified' and 'service-network' (the default OpenShift service
+	// network). The addresses 127.0.0.1, 0.0.0.0, ::1 and :: are always excluded.
+	Exclude []string `json:"exclude,omitempty" yaml:"exclude,omitempty"`
+
+	// Only used with the type Keywords obfuscator. Path to a text file with one
+	// keyword per line, empty lines and lines starting with '#' are ignored. Those
+	// keywords are replaced according to the replacementType, while the keywords in
+	// 'replacement' use their given replacement.
+	KeywordsFile *string `json:"keywordsFile,omitempty" yaml:"keywordsFile,omitempty"`
+
+	// Restricts the obfuscator to files containing Kubernetes resources, by default
+	// it applies to all files. A file containing a list of resources matches when any
+	// of its items matches. Files that are no Kubernetes resources never match. When
+	// used together with 'paths', a file has to match both.
+	KubernetesResources *ObfuscateKubernetesResources `json:"kubernetesResources,omitempty" yaml:"kubernetesResources,omitempty"`
+
+	// When enabled, the obfuscator runs on blocks of joined lines of the file
+	// contents as defined in the 'multiline' section, so that it can match across
+	// line breaks. File paths are still a single line.
+	Multiline bool `json:"multiline,omitempty" yaml:"multiline,omitempty"`
+
+	// Only used with the type IP obfuscator. When set, only the IP addresses within
+	// the given IP addresses, CIDRs or named presets are obfuscated. It supports the
+	// same values as 'exclude', for example ['public'] to only obfuscate public
+	// addresses or ['private'] to only obfuscate private addresses. Exclusions take
+	// precedence.
+	OnlyRanges []string `json:"onlyRanges,omitempty" yaml:"onlyRanges,omitempty"`
+
+	// Restricts the obfuscator to some files, by default it applies to all files. The
+	// globs are matched against the file paths relative to the must-gather root, as
+	// described in https://pkg.go.dev/path/filepath#Match.
+	Paths *ObfuscatePaths `json:"paths,omitempty" yaml:"paths,omitempty"`
+
+	// When the matches of obfuscators overlap, only the match of the obfuscator with
+	// the higher priority is replaced. Obfuscators with the same priority are
+	// preferred in order of their definition.
+	Priority int `json:"priority,omitempty" yaml:"priority,omitempty"`
+
 	// when replacementType 'Regex' is used, the supplied Golang regexp
 	// (https://pkg.go.dev/regexp) will be used to detect the string that should be
-	// replaced. The regex is line based, spanning multi-line regex statements is not
-	// supported.
+	// replaced. The regex is line based, unless 'multiline' is enabled to match
+	// across lines.
 	Regex *string `json:"regex,omitempty" yaml:"regex,omitempty"`
 
 	// on replacement 'Keywords', this will override a given input string with another
 	// output string. On duplicate keys it will use the last defined value as
-	// replacement. The input values are matched in a case-sensitive fashion and only
-	// as a full words, substrings must be matched using a regex.
+	// replacement. The input values are matched in a case-sensitive fashion anywhere
+	// in the text by default, see 'caseInsensitive' and 'wholeWords'. When keywords
+	// overlap, the longest keyword that starts first is replaced.
 	Replacement ObfuscateReplacement `json:"replacement,omitempty" yaml:"replacement,omitempty"`
 
 	// This defines how the detected string will be replaced. Type 'Consistent' will
@@ -28,25 +113,84 @@ type Obfuscate struct {
 	// used by default and will just try to mask the matching input.
 	ReplacementType ObfuscateReplacementType `json:"replacementType,omitempty" yaml:"replacementType,omitempty"`
 
+	// Only used with the 'Static' replacementType. Every match is replaced with this
+	// string instead of the default of the type, for Regex every character of the
+	// match is replaced by 'x'. The replacement must not be detected by the
+	// obfuscator again.
+	StaticReplacement *string `json:"staticReplacement,omitempty" yaml:"staticReplacement,omitempty"`
+
 	// This determines if the obfuscation should be performed on the file path
 	// (relative path from the must-gather root folder) or on the file contents. The
 	// file contents are obfuscated by default.
 	Target ObfuscateTarget `json:"target,omitempty" yaml:"target,omitempty"`
 
+	// Only used with the 'Consistent' replacementType. The template of the
+	// replacement, it must contain exactly one integer verb (e.g. 'token-%06d') that
+	// is replaced by a running number. The width of the verb limits the number of
+	// replacements, the default depends on the type, for example 'x-regex-%010d-x'
+	// for Regex. The replacement must not be detected by the obfuscator again.
+	Template *string `json:"template,omitempty" yaml:"template,omitempty"`
+
 	// type defines the kind of detection you want to use. For example IP will find IP
 	// addresses, whereas Keywords will find keywords defined in the 'replacement'
 	// mapping. Domain must be used in conjunction with the 'domainNames' property,
 	// that defines what domains should be obfuscated. MAC currently only supports
 	// static replacement where a detected mac address will be replaced by 'x'. Regex
-	// should be used with the 'regex' property that will define the regex, here the
-	// replacement also will be static by 'x'-ing out the matched string.
+	// should be used with the 'regex' property that will define the regex, by default
+	// the matched string is 'x'-ed out. ClusterIdentifiers will discover the cluster
+	// ID, infrastructure name and cloud account identifiers (AWS account, Azure
+	// subscription and tenant, GCP project) from the must-gather and replace them
+	// everywhere. Identity will learn the user and group names from User, Identity
+	// and Group resources, RBAC subjects and audit events and replace them, system
+	// identities are left untouched.
 	Type ObfuscateType `json:"type" yaml:"type"`
+
+	// Only used with the type Keywords obfuscator. When enabled, keywords are only
+	// matched as whole words and not as part of a longer word, for example 'node1'
+	// won't match in 'node10'.
+	WholeWords bool `json:"wholeWords,omitempty" yaml:"wholeWords,omitempty"`
+}
+
+// Restricts the obfuscator to files containing Kubernetes resources, by default it
+// applies to all files. A file containing a list of resources matches when any of
+// its items matches. Files that are no Kubernetes resources never match. When used
+// together with 'paths', a file has to match both.
+type ObfuscateKubernetesResources struct {
+	// The obfuscator never applies to files with resources matching any of these
+	// selectors. Takes precedence over 'include'.
+	Exclude []KubernetesResourceSelector `json:"exclude,omitempty" yaml:"exclude,omitempty"`
+
+	// The obfuscator only applies to files with resources matching any of these
+	// selectors.
+	Include []KubernetesResourceSelector `json:"include,omitempty" yaml:"include,omitempty"`
+}
+
+// Restricts the obfuscator to some files, by default it applies to all files. The
+// globs are matched against the file paths relative to the must-gather root, as
+// described in https://pkg.go.dev/path/filepath#Match.
+type ObfuscatePaths struct {
+	// The obfuscator never applies to files matching any of these globs, for example
+	// 'etcd_info/*'. Takes precedence over 'include'.
+	Exclude []string `json:"exclude,omitempty" yaml:"exclude,omitempty"`
+
+	// The obfuscator only applies to files matching any of these globs, for example
+	// 'namespaces/*/pods/*/*/*/logs/*'.
+	Include []string `json:"include,omitempty" yaml:"include,omitempty"`
+}
+
+type ObfuscateExceptionsElem struct {
+	// The string is matched exactly (case-sensitive).
+	Literal *string `json:"literal,omitempty" yaml:"literal,omitempty"`
+
+	// The matches of the Golang regexp are kept as-is.
+	Regex *string `json:"regex,omitempty" yaml:"regex,omitempty"`
 }
 
 // on replacement 'Keywords', this will override a given input string with another
 // output string. On duplicate keys it will use the last defined value as
-// replacement. The input values are matched in a case-sensitive fashion and only
-// as a full words, substrings must be matched using a regex.
+// replacement. The input values are matched in a case-sensitive fashion anywhere
+// in the text by default, see 'caseInsensitive' and 'wholeWords'. When keywords
+// overlap, the longest keyword that starts first is replaced.
 type ObfuscateReplacement map[string]string
 
 type ObfuscateReplacementType string
@@ -60,6 +204,24 @@ const ObfuscateTargetAll ObfuscateTarget = "All"
 const ObfuscateTargetFileContents ObfuscateTarget = "FileContents"
 const ObfuscateTargetFilePath ObfuscateTarget = "FilePath"
 
+// UnmarshalJSON implements json.Unmarshaler.
+func (j *KubernetesResourceSelector) UnmarshalJSON(b []byte) error {
+	var raw map[string]interface{}
+	if err := json.Unmarshal(b, &raw); err != nil {
+		return err
+	}
+	if v, ok := raw["kind"]; !ok || v == nil {
+		return fmt.Errorf("field kind: required")
+	}
+	type Plain KubernetesResourceSelector
+	var plain Plain
+	if err := json.Unmarshal(b, &plain); err != nil {
+		return err
+	}
+	*j = KubernetesResourceSelector(plain)
+	return nil
+}
+
 // UnmarshalJSON implements json.Unmarshaler.
 func (j *ObfuscateTarget) UnmarshalJSON(b []byte) error {
 	var v string
@@ -178,7 +340,9 @@ func (j *ObfuscateType) UnmarshalJSON(b []byte) error {
 
 type ObfuscateType string
 
+const ObfuscateTypeClusterIdentifiers ObfuscateType = "ClusterIdentifiers"
 const ObfuscateTypeDomain ObfuscateType = "Domain"
+const ObfuscateTypeIdentity ObfuscateType = "Identity"
 const ObfuscateTypeIP ObfuscateType = "IP"
 
 // UnmarshalJSON implements json.Unmarshaler.
@@ -195,12 +359,30 @@ func (j *Obfuscate) UnmarshalJSON(b []byte) error {
 	if err := json.Unmarshal(b, &plain); err != nil {
 		return err
 	}
+	if v, ok := raw["autoDiscovery"]; !ok || v == nil {
+		plain.AutoDiscovery = false
+	}
+	if v, ok := raw["caseInsensitive"]; !ok || v == nil {
+		plain.CaseInsensitive = false
+	}
+	if v, ok := raw["chain"]; !ok || v == nil {
+		plain.Chain = false
+	}
+	if v, ok := raw["multiline"]; !ok || v == nil {
+		plain.Multiline = false
+	}
+	if v, ok := raw["priority"]; !ok || v == nil {
+		plain.Priority = 0
+	}
 	if v, ok := raw["replacementType"]; !ok || v == nil {
 		plain.ReplacementType = "Static"
 	}
 	if v, ok := raw["target"]; !ok || v == nil {
 		plain.Target = "FileContents"
 	}
+	if v, ok := raw["wholeWords"]; !ok || v == nil {
+		plain.WholeWords = false
+	}
 	*j = Obfuscate(plain)
 	return nil
 }
@@ -252,17 +434,98 @@ type SchemaJson struct {
 	Config SchemaJsonConfig `json:"config" yaml:"config"`
 }
 
+type SchemaJsonConfigMultilineMode string
+
+var enumValues_SchemaJsonConfigMultilineMode = []interface{}{
+	"Window",
+	"YamlBlock",
+	"File",
+}
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (j *SchemaJsonConfigMultilineMode) UnmarshalJSON(b []byte) error {
+	var v string
+	if err := json.Unmarshal(b, &v); err != nil {
+		return err
+	}
+	var ok bool
+	for _, expected := range enumValues_SchemaJsonConfigMultilineMode {
+		if reflect.DeepEqual(v, expected) {
+			ok = true
+			break
+		}
+	}
+	if !ok {
+		return fmt.Errorf("invalid value (expected one of %#v): %#v", enumValues_SchemaJsonConfigMultilineMode, v)
+	}
+	*j = SchemaJsonConfigMultilineMode(v)
+	return nil
+}
+
+const SchemaJsonConfigMultilineModeWindow SchemaJsonConfigMultilineMode = "Window"
+const SchemaJsonConfigMultilineModeYamlBlock SchemaJsonConfigMultilineMode = "YamlBlock"
+const SchemaJsonConfigMultilineModeFile SchemaJsonConfigMultilineMode = "File"
+
+// Defines how lines of file contents are joined into blocks for the obfuscators
+// that enable 'multiline'. All other obfuscators always run on single lines.
+type SchemaJsonConfigMultiline struct {
+	// The maximum size in bytes of a file that is joined as a whole in the 'File'
+	// mode.
+	MaxFileSize int `json:"maxFileSize,omitempty" yaml:"maxFileSize,omitempty"`
+
+	// 'Window' joins a fixed number of consecutive lines, 'YamlBlock' joins YAML
+	// block scalars (e.g. 'key: |') with their indented content and 'File' joins the
+	// whole file if it is not larger than 'maxFileSize', larger files fall back to
+	// 'Window'.
+	Mode SchemaJsonConfigMultilineMode `json:"mode,omitempty" yaml:"mode,omitempty"`
+
+	// The number of lines joined into one block in the 'Window' mode.
+	WindowLines int `json:"windowLines,omitempty" yaml:"windowLines,omitempty"`
+}
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (j *SchemaJsonConfigMultiline) UnmarshalJSON(b []byte) error {
+	var raw map[string]interface{}
+	if err := json.Unmarshal(b, &raw); err != nil {
+		return err
+	}
+	type Plain SchemaJsonConfigMultiline
+	var plain Plain
+	if err := json.Unmarshal(b, &plain); err != nil {
+		return err
+	}
+	if v, ok := raw["maxFileSize"]; !ok || v == nil {
+		plain.MaxFileSize = 1.048576e+06
+	}
+	if v, ok := raw["mode"]; !ok || v == nil {
+		plain.Mode = "Window"
+	}
+	if v, ok := raw["windowLines"]; !ok || v == nil {
+		plain.WindowLines = 10
+	}
+	*j = SchemaJsonConfigMultiline(plain)
+	return nil
+}
+
 // There are two main sections, "omit" which defines the omission behaviour and
 // "obfuscate" which defines the obfuscation behaviour.
 type SchemaJsonConfig struct {
+	// Defines how lines of file contents are joined into blocks for the obfuscators
+	// that enable 'multiline'. All other obfuscators always run on single lines.
+	Multiline *SchemaJsonConfigMultiline `json:"multiline,omitempty" yaml:"multiline,omitempty"`
+
 	// The obfuscation schema determines what is being detected and how it is being
 	// replaced. We ship with several built-in replacements for common types such as
-	// IP or MAC, Keywords and Regex. The replacements are done in order of the whole
-	// list, so you can define chains of replacements that built on top of one another
-	// - for example replacing a keyword and later matching its replacement with a
-	// regex. The input to the given replacements are always a line of text (string).
-	// Since file names and directories can also have private content in them, they
-	// are also processed as a line - exactly as they would with file content.
+	// IP or MAC, Keywords and Regex. All obfuscators match on the original input and
+	// overlapping matches are resolved by their priority and then in order of the
+	// whole list, so a replacement is never obfuscated again. Obfuscators with
+	// 'chain' enabled run on the output of the obfuscators defined before them, so
+	// you can define chains of replacements that built on top of one another - for
+	// example replacing a keyword and later matching its replacement with a regex.
+	// The input to the given replacements are always a line of text (string), unless
+	// 'multiline' is enabled. Since file names and directories can also have private
+	// content in them, they are also processed as a line - exactly as they would with
+	// file content.
 	Obfuscate []Obfuscate `json:"obfuscate,omitempty" yaml:"obfuscate,omitempty"`
 
 	// The omission schema defines what kind of files shall not be included in the
@@ -283,7 +546,9 @@ var enumValues_ObfuscateTarget = []interface{}{
 	"All",
 }
 var enumValues_ObfuscateType = []interface{}{
+	"ClusterIdentifiers",
 	"Domain",
+	"Identity",
 	"IP",
 	"Keywords",
 	"MAC",
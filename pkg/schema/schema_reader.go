@@ -26,21 +26,26 @@ func (u UnsupportedFileTypeError) Error() string {
 
 func ReadConfigFromPath(path string) (*SchemaJson, error) {
 	extension := filepath.Ext(path)
-	isYaml := isYamlExtension(extension)
-	if extension != jsonExtension && !isYaml {
-		return nil, wrapError(UnsupportedFileTypeError{
-			UsedExtension:       extension,
-			SupportedExtensions: supportedExtensions,
-		})
+	if err := checkExtension(extension); err != nil {
+		return nil, err
 	}
 
-	var bytes []byte
 	bytes, err := ioutil.ReadFile(path)
 	if err != nil {
 		return nil, wrapError(err)
 	}
 
-	if isYaml {
+	return ReadConfig(bytes, extension)
+}
+
+// ReadConfig parses the config from memory, the extension determines whether it is JSON or YAML.
+func ReadConfig(bytes []byte, extension string) (*SchemaJson, error) {
+	if err := checkExtension(extension); err != nil {
+		return nil, err
+	}
+
+	if isYamlExtension(extension) {
+		var err error
 		bytes, err = yaml.YAMLToJSON(bytes)
 		if err != nil {
 			return nil, wrapError(err)
@@ -48,7 +53,7 @@ func ReadConfigFromPath(path string) (*SchemaJson, error) {
 	}
 
 	schema := &SchemaJson{}
-	err = schema.UnmarshalJSON(bytes)
+	err := schema.UnmarshalJSON(bytes)
 	if err != nil {
 		return nil, wrapError(err)
 	}
@@ -56,6 +61,16 @@ func ReadConfigFromPath(path string) (*SchemaJson, error) {
 	return schema, nil
 }
 
+func checkExtension(extension string) error {
+	if extension != jsonExtension && !isYamlExtension(extension) {
+		return wrapError(UnsupportedFileTypeError{
+			UsedExtension:       extension,
+			SupportedExtensions: supportedExtensions,
+		})
+	}
+	return nil
+}
+
 func isYamlExtension(extension string) bool {
 	return extension == yamlLongExtension || extension == yamlShortExtension
 }
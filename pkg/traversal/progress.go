@@ -0,0 +1,242 @@
+package traversal
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/fs"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// ProgressFormat is how the progress of a traversal is rendered.
+type ProgressFormat string
+
+const (
+	// ProgressNone does not render any progress
+	ProgressNone ProgressFormat = "none"
+	// ProgressLine renders a single line that is updated in place, meant for interactive terminals
+	ProgressLine ProgressFormat = "line"
+	// ProgressJSON writes one JSON event per line, meant for logs and other tools
+	ProgressJSON ProgressFormat = "json"
+)
+
+const (
+	progressLineInterval = 500 * time.Millisecond
+	progressJSONInterval = 10 * time.Second
+)
+
+// ProgressEvent is written for every update of the progress in the JSON format. The event is "progress" for the periodic
+// updates, "error" for a file that failed to process and "done" once all files are processed.
+type ProgressEvent struct {
+	Event          string  `json:"event"`
+	Files          int     `json:"files"`
+	TotalFiles     int     `json:"totalFiles"`
+	Bytes          int64   `json:"bytes"`
+	TotalBytes     int64   `json:"totalBytes"`
+	BytesPerSecond float64 `json:"bytesPerSecond"`
+	// EtaSeconds is the estimated time until all files are processed, it is missing as long as nothing was processed
+	EtaSeconds *float64 `json:"etaSeconds,omitempty"`
+	Errors     int      `json:"errors"`
+	Path       string   `json:"path,omitempty"`
+	Error      string   `json:"error,omitempty"`
+}
+
+// CountFiles walks the input folder and returns the number of files and their total size, symbolic links are counted
+// but not followed.
+func CountFiles(inputPath string) (int, int64, error) {
+	files := 0
+	var bytes int64
+	err := filepath.WalkDir(inputPath, func(path string, dirEntry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if dirEntry.IsDir() {
+			return nil
+		}
+		info, err := dirEntry.Info()
+		if err != nil {
+			return err
+		}
+		files++
+		bytes += info.Size()
+		return nil
+	})
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to count the files in %s: %w", inputPath, err)
+	}
+	return files, bytes, nil
+}
+
+// Progress is a Tracker that periodically renders how many of the counted files and bytes are processed, along with the
+// throughput, the estimated time left and the number of errors. It is safe for concurrent use.
+type Progress struct {
+	writer     io.Writer
+	format     ProgressFormat
+	interval   time.Duration
+	totalFiles int
+	totalBytes int64
+	now        func() time.Time
+	start      time.Time
+
+	lock   sync.Mutex
+	sizes  map[string]int64
+	files  int
+	bytes  int64
+	errors int
+
+	stop chan struct{}
+	done chan struct{}
+}
+
+func (p *Progress) TrackInput(path string, info fs.FileInfo) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	p.sizes[path] = info.Size()
+}
+
+func (p *Progress) TrackFile(path string, _ time.Duration, err error) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	p.files++
+	p.bytes += p.sizes[path]
+	delete(p.sizes, path)
+	if err != nil {
+		p.errors++
+		if p.format == ProgressJSON {
+			event := p.event("error")
+			event.Path = path
+			event.Error = err.Error()
+			p.writeJSON(event)
+		}
+	}
+}
+
+// Start renders the progress periodically until Stop is called.
+func (p *Progress) Start() {
+	p.start = p.now()
+	ticker := time.NewTicker(p.interval)
+	go func() {
+		defer close(p.done)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				p.render("progress")
+			case <-p.stop:
+				return
+			}
+		}
+	}()
+}
+
+// Stop ends the periodic rendering and renders the final progress.
+func (p *Progress) Stop() {
+	close(p.stop)
+	<-p.done
+	p.render("done")
+}
+
+func (p *Progress) render(event string) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	e := p.event(event)
+	if p.format == ProgressJSON {
+		p.writeJSON(e)
+		return
+	}
+	line := fmt.Sprintf("files %d/%d | %s/%s | %s/s | ETA %s | errors %d",
+		e.Files, e.TotalFiles, formatBytes(e.Bytes), formatBytes(e.TotalBytes), formatBytes(int64(e.BytesPerSecond)), formatEta(e.EtaSeconds), e.Errors)
+	// the line is rewritten in place, the escape sequence clears what is left of a longer previous line
+	end := ""
+	if event == "done" {
+		end = "\n"
+	}
+	_, _ = fmt.Fprintf(p.writer, "\r%s\x1b[K%s", line, end)
+}
+
+// event returns the current progress, it must be called with the lock held.
+func (p *Progress) event(event string) ProgressEvent {
+	e := ProgressEvent{
+		Event:      event,
+		Files:      p.files,
+		TotalFiles: p.totalFiles,
+		Bytes:      p.bytes,
+		TotalBytes: p.totalBytes,
+		Errors:     p.errors,
+	}
+	elapsed := p.now().Sub(p.start).Seconds()
+	if elapsed <= 0 {
+		return e
+	}
+	e.BytesPerSecond = float64(p.bytes) / elapsed
+	// the estimate is based on the bytes, unless there are only empty files
+	var eta float64
+	switch {
+	case p.bytes > 0:
+		eta = float64(p.totalBytes-p.bytes) / e.BytesPerSecond
+	case p.totalBytes == 0 && p.files > 0:
+		eta = float64(p.totalFiles-p.files) / (float64(p.files) / elapsed)
+	default:
+		return e
+	}
+	if eta < 0 {
+		eta = 0
+	}
+	e.EtaSeconds = &eta
+	return e
+}
+
+func (p *Progress) writeJSON(event ProgressEvent) {
+	data, err := json.Marshal(event)
+	if err != nil {
+		return
+	}
+	_, _ = fmt.Fprintf(p.writer, "%s\n", data)
+}
+
+func formatBytes(bytes int64) string {
+	const unit = 1024
+	if bytes < unit {
+		return fmt.Sprintf("%d B", bytes)
+	}
+	value := float64(bytes)
+	prefixes := "KMGTPE"
+	i := -1
+	for value >= unit && i < len(prefixes)-1 {
+		value /= unit
+		i++
+	}
+	return fmt.Sprintf("%.1f %ciB", value, prefixes[i])
+}
+
+func formatEta(seconds *float64) string {
+	if seconds == nil {
+		return "unknown"
+	}
+	return (time.Duration(*seconds) * time.Second).String()
+}
+
+// NewProgress returns a Progress that renders in the given format into the writer, the totals are usually counted with
+// CountFiles beforehand.
+func NewProgress(writer io.Writer, format ProgressFormat, totalFiles int, totalBytes int64) *Progress {
+	interval := progressLineInterval
+	if format == ProgressJSON {
+		interval = progressJSONInterval
+	}
+	return &Progress{
+		writer:     writer,
+		format:     format,
+		interval:   interval,
+		totalFiles: totalFiles,
+		totalBytes: totalBytes,
+		now:        time.Now,
+		sizes:      map[string]int64{},
+		stop:       make(chan struct{}),
+		done:       make(chan struct{}),
+	}
+}
@@ -0,0 +1,140 @@
+package traversal
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type sizeInfo struct {
+	os.FileInfo
+	size int64
+}
+
+func (s sizeInfo) Size() int64 {
+	return s.size
+}
+
+func TestCountFiles(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "pods", "pod1"), 0700))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "a.yaml"), []byte("0123456789"), 0600))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "pods", "pod1", "b.log"), []byte("01234"), 0600))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "pods", "pod1", "empty.log"), nil, 0600))
+
+	files, size, err := CountFiles(dir)
+	require.NoError(t, err)
+	assert.Equal(t, 3, files)
+	assert.Equal(t, int64(15), size)
+}
+
+func TestCountFilesFailsOnMissingInput(t *testing.T) {
+	_, _, err := CountFiles(filepath.Join(t.TempDir(), "missing"))
+	assert.Error(t, err)
+}
+
+func newTestProgress(format ProgressFormat, totalFiles int, totalBytes int64) (*Progress, *bytes.Buffer, *time.Time) {
+	buffer := &bytes.Buffer{}
+	p := NewProgress(buffer, format, totalFiles, totalBytes)
+	now := time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)
+	p.now = func() time.Time { return now }
+	p.start = now
+	return p, buffer, &now
+}
+
+func readEvents(t *testing.T, buffer *bytes.Buffer) []ProgressEvent {
+	var events []ProgressEvent
+	for _, line := range strings.Split(strings.TrimSpace(buffer.String()), "\n") {
+		var event ProgressEvent
+		require.NoError(t, json.Unmarshal([]byte(line), &event))
+		events = append(events, event)
+	}
+	return events
+}
+
+func TestProgressJSON(t *testing.T) {
+	p, buffer, now := newTestProgress(ProgressJSON, 3, 400)
+	p.TrackInput("a.yaml", sizeInfo{size: 100})
+	p.TrackInput("b.log", sizeInfo{size: 300})
+	p.render("progress")
+
+	p.TrackFile("a.yaml", time.Second, nil)
+	*now = now.Add(2 * time.Second)
+	p.render("progress")
+
+	p.TrackFile("b.log", time.Second, errors.New("broken"))
+
+	events := readEvents(t, buffer)
+	require.Len(t, events, 3)
+	assert.Equal(t, ProgressEvent{Event: "progress", TotalFiles: 3, TotalBytes: 400}, events[0])
+
+	eta := float64(6)
+	assert.Equal(t, ProgressEvent{Event: "progress", Files: 1, TotalFiles: 3, Bytes: 100, TotalBytes: 400, BytesPerSecond: 50, EtaSeconds: &eta}, events[1])
+
+	eta = 0
+	assert.Equal(t, ProgressEvent{Event: "error", Files: 2, TotalFiles: 3, Bytes: 400, TotalBytes: 400, BytesPerSecond: 200, EtaSeconds: &eta, Errors: 1, Path: "b.log", Error: "broken"}, events[2])
+}
+
+func TestProgressJSONEstimatesEmptyFilesByCount(t *testing.T) {
+	p, buffer, now := newTestProgress(ProgressJSON, 4, 0)
+	p.TrackInput("a.log", sizeInfo{})
+	p.TrackFile("a.log", time.Second, nil)
+	*now = now.Add(time.Second)
+	p.render("progress")
+
+	events := readEvents(t, buffer)
+	require.Len(t, events, 1)
+	require.NotNil(t, events[0].EtaSeconds)
+	assert.Equal(t, float64(3), *events[0].EtaSeconds)
+}
+
+func TestProgressLine(t *testing.T) {
+	p, buffer, now := newTestProgress(ProgressLine, 2, 3*1024*1024)
+	p.TrackInput("a.yaml", sizeInfo{size: 1024 * 1024})
+	p.render("progress")
+	p.TrackFile("a.yaml", time.Second, errors.New("broken"))
+	*now = now.Add(time.Second)
+	p.render("done")
+
+	assert.Equal(t, "\rfiles 0/2 | 0 B/3.0 MiB | 0 B/s | ETA unknown | errors 0\x1b[K"+
+		"\rfiles 1/2 | 1.0 MiB/3.0 MiB | 1.0 MiB/s | ETA 2s | errors 1\x1b[K\n", buffer.String())
+}
+
+func TestProgressStartStop(t *testing.T) {
+	buffer := &bytes.Buffer{}
+	p := NewProgress(buffer, ProgressJSON, 1, 10)
+	p.Start()
+	p.TrackInput("a.yaml", sizeInfo{size: 10})
+	p.TrackFile("a.yaml", time.Second, nil)
+	p.Stop()
+
+	events := readEvents(t, buffer)
+	require.Len(t, events, 1)
+	assert.Equal(t, "done", events[0].Event)
+	assert.Equal(t, 1, events[0].Files)
+	assert.Equal(t, int64(10), events[0].Bytes)
+}
+
+func TestFileWalkerWithMultiTracker(t *testing.T) {
+	first := &collectingTracker{inputs: map[string]int64{}}
+	second := &collectingTracker{inputs: map[string]int64{}}
+	tracker := NewMultiTracker(first, second)
+	walker := NewParallelFileWalkerWithTracker("testfiles/test1/mg", 2, func(id int) QueueProcessor {
+		return NewWorkerWithTracker(id, noOpCleaner{}, tracker)
+	}, tracker)
+	walker.Traverse()
+
+	assert.Len(t, first.inputs, 6)
+	assert.Len(t, first.processed, 6)
+	assert.Equal(t, first.inputs, second.inputs)
+	assert.ElementsMatch(t, first.processed, second.processed)
+}
@@ -4,6 +4,7 @@ import (
 	"io/fs"
 	"path/filepath"
 	"sync"
+	"time"
 
 	"k8s.io/klog/v2"
 )
@@ -12,11 +13,41 @@ type Traverser interface {
 	Traverse()
 }
 
+// Tracker records the files of the input and how long it took to process them. It must be safe for concurrent use, since
+// the files are processed by parallel workers.
+type Tracker interface {
+	// TrackInput records a file found while walking the input, the path is relative to the input folder
+	TrackInput(path string, info fs.FileInfo)
+	// TrackFile records how long processing the file took, err is set if processing failed
+	TrackFile(path string, duration time.Duration, err error)
+}
+
+type multiTracker []Tracker
+
+func (m multiTracker) TrackInput(path string, info fs.FileInfo) {
+	for _, t := range m {
+		t.TrackInput(path, info)
+	}
+}
+
+func (m multiTracker) TrackFile(path string, duration time.Duration, err error) {
+	for _, t := range m {
+		t.TrackFile(path, duration, err)
+	}
+}
+
+// NewMultiTracker returns a Tracker that records every file in all the given trackers, in their order.
+func NewMultiTracker(trackers ...Tracker) Tracker {
+	return multiTracker(trackers)
+}
+
 type FileWalker struct {
 	inputPath     string
 	workerCount   int
 	workers       []QueueProcessor
 	workerFactory func(int) QueueProcessor
+	// tracker is optional, it records the files found in the input
+	tracker Tracker
 }
 
 // Traverse should be called to start processing the must-gather directory. This method will exist the CLI if an error is encountered.
@@ -57,10 +88,16 @@ func (w *FileWalker) Traverse() {
 			// the rest of the logic expects the path to be relative to the input dir root, if it fails we assume it is already relative
 			relPath, err := filepath.Rel(w.inputPath, path)
 			if err != nil {
-				queue <- workerInput(path)
-			} else {
-				queue <- workerInput(relPath)
+				relPath = path
 			}
+			if w.tracker != nil {
+				info, err := dirEntry.Info()
+				if err != nil {
+					return err
+				}
+				w.tracker.TrackInput(relPath, info)
+			}
+			queue <- workerInput(relPath)
 		}
 
 		return nil
@@ -79,9 +116,16 @@ func (w *FileWalker) Traverse() {
 }
 
 func NewParallelFileWalker(inputPath string, workerCount int, workerFactory func(id int) QueueProcessor) *FileWalker {
+	return NewParallelFileWalkerWithTracker(inputPath, workerCount, workerFactory, nil)
+}
+
+// NewParallelFileWalkerWithTracker returns a FileWalker that records every file of the input in the given tracker, the
+// workers need to be created with the tracker themselves to record the processing times.
+func NewParallelFileWalkerWithTracker(inputPath string, workerCount int, workerFactory func(id int) QueueProcessor, tracker Tracker) *FileWalker {
 	return &FileWalker{
 		inputPath:     inputPath,
 		workerCount:   workerCount,
 		workerFactory: workerFactory,
+		tracker:       tracker,
 	}
 }
@@ -1,8 +1,12 @@
 package traversal
 
 import (
+	"io/fs"
+	"os"
 	"path/filepath"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -70,3 +74,45 @@ func TestFileWalkerAbsolutePathing(t *testing.T) {
 		"pods/pod2/manifests.yaml",
 	}, queueProc.paths)
 }
+
+type collectingTracker struct {
+	lock      sync.Mutex
+	inputs    map[string]int64
+	processed []string
+}
+
+func (c *collectingTracker) TrackInput(path string, info fs.FileInfo) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.inputs[path] = info.Size()
+}
+
+func (c *collectingTracker) TrackFile(path string, _ time.Duration, _ error) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.processed = append(c.processed, path)
+}
+
+func TestFileWalkerWithTracker(t *testing.T) {
+	tracker := &collectingTracker{inputs: map[string]int64{}}
+	walker := NewParallelFileWalkerWithTracker("testfiles/test1/mg", 2, func(id int) QueueProcessor {
+		return NewWorkerWithTracker(id, noOpCleaner{}, tracker)
+	}, tracker)
+	walker.Traverse()
+
+	expected := []string{
+		"nodes/another.yaml",
+		"nodes/test.yaml",
+		"pods/pod1/application.log",
+		"pods/pod1/manifests.yaml",
+		"pods/pod2/application.log",
+		"pods/pod2/manifests.yaml",
+	}
+	assert.ElementsMatch(t, expected, tracker.processed)
+	require.Len(t, tracker.inputs, len(expected))
+	for _, path := range expected {
+		info, err := os.Lstat(filepath.Join("testfiles/test1/mg", path))
+		require.NoError(t, err)
+		assert.Equal(t, info.Size(), tracker.inputs[path])
+	}
+}
@@ -2,6 +2,7 @@ package traversal
 
 import (
 	"fmt"
+	"time"
 
 	"github.com/openshift/must-gather-clean/pkg/cleaner"
 	"k8s.io/klog/v2"
@@ -30,6 +31,8 @@ type QueueProcessor interface {
 type Worker struct {
 	id      int
 	cleaner cleaner.Processor
+	// tracker is optional, it records how long processing each file took
+	tracker Tracker
 }
 
 func (w *Worker) ProcessQueue(queue chan workerInput, errorCh chan error) {
@@ -37,7 +40,11 @@ func (w *Worker) ProcessQueue(queue chan workerInput, errorCh chan error) {
 		path := string(wf)
 		klog.V(3).Infof("[Worker %02d] Processing %s\n", w.id, path)
 
+		start := time.Now()
 		err := w.cleaner.Process(path)
+		if w.tracker != nil {
+			w.tracker.TrackFile(path, time.Since(start), err)
+		}
 		if err != nil {
 			errorCh <- &fileProcessingError{
 				path:  path,
@@ -50,8 +57,14 @@ func (w *Worker) ProcessQueue(queue chan workerInput, errorCh chan error) {
 }
 
 func NewWorker(id int, cleaner cleaner.Processor) QueueProcessor {
+	return NewWorkerWithTracker(id, cleaner, nil)
+}
+
+// NewWorkerWithTracker returns a worker that records the processing time of each file in the given tracker.
+func NewWorkerWithTracker(id int, cleaner cleaner.Processor, tracker Tracker) QueueProcessor {
 	return &Worker{
 		id:      id,
 		cleaner: cleaner,
+		tracker: tracker,
 	}
 }
@@ -0,0 +1,43 @@
+package watermarking
+
+import "sort"
+
+// TreeDiff lists the files that differ between two output folders by their path relative to the folders. The manifests
+// and their signatures are not compared, since they always differ.
+type TreeDiff struct {
+	Added   []string `yaml:"added,omitempty" json:"added,omitempty"`
+	Removed []string `yaml:"removed,omitempty" json:"removed,omitempty"`
+	Changed []string `yaml:"changed,omitempty" json:"changed,omitempty"`
+}
+
+// DiffTrees hashes the files of both folders and compares them, all paths are ordered.
+func DiffTrees(oldFolder string, newFolder string) (*TreeDiff, error) {
+	oldFiles, err := listFiles(oldFolder)
+	if err != nil {
+		return nil, err
+	}
+	newFiles, err := listFiles(newFolder)
+	if err != nil {
+		return nil, err
+	}
+	old := map[string]ManifestFile{}
+	for _, f := range oldFiles {
+		old[f.Path] = f
+	}
+	diff := &TreeDiff{}
+	for _, f := range newFiles {
+		o, ok := old[f.Path]
+		delete(old, f.Path)
+		switch {
+		case !ok:
+			diff.Added = append(diff.Added, f.Path)
+		case o != f:
+			diff.Changed = append(diff.Changed, f.Path)
+		}
+	}
+	for path := range old {
+		diff.Removed = append(diff.Removed, path)
+	}
+	sort.Strings(diff.Removed)
+	return diff, nil
+}
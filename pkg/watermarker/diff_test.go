@@ -0,0 +1,25 @@
+package watermarking
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDiffTrees(t *testing.T) {
+	oldFolder := writeTree(t, map[string]string{"same.log": "same", "changed.log": "old", "removed.log": "removed"})
+	newFolder := writeTree(t, map[string]string{"same.log": "same", "changed.log": "new", "pods/added.log": "added"})
+	// the manifests always differ and are ignored
+	require.NoError(t, os.WriteFile(filepath.Join(newFolder, ManifestFileName), []byte("{}"), 0600))
+
+	diff, err := DiffTrees(oldFolder, newFolder)
+	require.NoError(t, err)
+	assert.Equal(t, &TreeDiff{
+		Added:   []string{"pods/added.log"},
+		Removed: []string{"removed.log"},
+		Changed: []string{"changed.log"},
+	}, diff)
+}
@@ -0,0 +1,251 @@
+package watermarking
+
+import (
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	version "github.com/openshift/must-gather-clean/pkg/version"
+)
+
+// ManifestFileName is the name of the manifest written into the output folder next to the watermark.
+const ManifestFileName = "manifest.json"
+
+// Manifest describes a cleaned output, so that the receiving side can check that it is complete and was produced by a
+// known configuration.
+type Manifest struct {
+	Timestamp string          `json:"timestamp"`
+	Version   version.Version `json:"version"`
+	// ConfigSha256 is the hash of the configuration file as it was passed to the run
+	ConfigSha256 string `json:"configSha256"`
+	// SeedSha256 is the hash of the report or mapping the replacements were seeded from, if any
+	SeedSha256 string `json:"seedSha256,omitempty"`
+	// InputSha256 is the digest of the input folder, see TreeDigest. It is only recorded on request, since it reads the
+	// whole input again
+	InputSha256 string `json:"inputSha256,omitempty"`
+	// Files are all files of the output except the manifest and its signature, ordered by path
+	Files []ManifestFile `json:"files"`
+}
+
+type ManifestFile struct {
+	// Path is relative to the output folder and always uses forward slashes
+	Path   string `json:"path"`
+	Sha256 string `json:"sha256"`
+	Size   int64  `json:"size"`
+	Mode   string `json:"mode"`
+	// Link is the target of a symbolic link, the hash of a link is the hash of its target path
+	Link string `json:"link,omitempty"`
+}
+
+// ManifestOptions configure what the ManifestWaterMarker records in the manifest.
+type ManifestOptions struct {
+	// Config is the configuration file as it was passed to the run, before it was parsed or seeded
+	Config []byte
+	// Seed is the report or mapping file the replacements were seeded from, it is optional
+	Seed []byte
+	// InputPath is the input folder, its digest is recorded if set
+	InputPath string
+	// Key is optional, the manifest is signed with it
+	Key ed25519.PrivateKey
+}
+
+// ManifestWaterMarker writes the watermark along with a manifest of the output.
+type ManifestWaterMarker struct {
+	SimpleWaterMarker
+
+	options ManifestOptions
+}
+
+// NewManifestWaterMarker returns a WaterMarker that records the hashes of the configuration and the seed and optionally
+// the digest of the input folder in the manifest. If a key is given, the manifest is signed and the signature is written
+// into SignatureFileName next to the manifest.
+func NewManifestWaterMarker(options ManifestOptions) *ManifestWaterMarker {
+	return &ManifestWaterMarker{options: options}
+}
+
+func (m *ManifestWaterMarker) WriteWaterMarkFile(path string) error {
+	err := m.SimpleWaterMarker.WriteWaterMarkFile(path)
+	if err != nil {
+		return err
+	}
+
+	var inputDigest string
+	if m.options.InputPath != "" {
+		inputDigest, err = TreeDigest(m.options.InputPath)
+		if err != nil {
+			return err
+		}
+	}
+	files, err := listFiles(path)
+	if err != nil {
+		return err
+	}
+	manifest := Manifest{
+		Timestamp:    time.Now().UTC().Format(time.RFC3339),
+		Version:      version.GetVersion(),
+		ConfigSha256: Sha256(m.options.Config),
+		InputSha256:  inputDigest,
+		Files:        files,
+	}
+	if m.options.Seed != nil {
+		manifest.SeedSha256 = Sha256(m.options.Seed)
+	}
+	data, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal manifest: %w", err)
+	}
+	data = append(data, '\n')
+	err = os.WriteFile(filepath.Join(path, ManifestFileName), data, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to create manifest file in output folder: %w", err)
+	}
+	if m.options.Key != nil {
+		err = os.WriteFile(filepath.Join(path, SignatureFileName), Sign(data, m.options.Key), 0644)
+		if err != nil {
+			return fmt.Errorf("failed to create manifest signature in output folder: %w", err)
+		}
+	}
+	return nil
+}
+
+// ReadManifest reads the manifest of the output folder, along with its raw contents to verify the signature.
+func ReadManifest(folder string) (*Manifest, []byte, error) {
+	data, err := os.ReadFile(filepath.Join(folder, ManifestFileName))
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read manifest: %w", err)
+	}
+	var manifest Manifest
+	if err := json.Unmarshal(data, &manifest); err != nil {
+		return nil, nil, fmt.Errorf("failed to parse manifest: %w", err)
+	}
+	return &manifest, data, nil
+}
+
+// Mismatch is a file of the output that does not match the manifest, the reason is "missing", "changed" or
+// "unexpected" for files that are not listed in the manifest.
+type Mismatch struct {
+	Path   string
+	Reason string
+}
+
+func (m Mismatch) String() string {
+	return fmt.Sprintf("%s: %s", m.Reason, m.Path)
+}
+
+// CheckManifest hashes the files of the folder and returns a Mismatch for every file that is missing, changed or not
+// listed in the manifest, ordered by path.
+func CheckManifest(folder string, manifest *Manifest) ([]Mismatch, error) {
+	files, err := listFiles(folder)
+	if err != nil {
+		return nil, err
+	}
+	actual := map[string]ManifestFile{}
+	for _, f := range files {
+		actual[f.Path] = f
+	}
+	var mismatches []Mismatch
+	for _, expected := range manifest.Files {
+		f, ok := actual[expected.Path]
+		delete(actual, expected.Path)
+		switch {
+		case !ok:
+			mismatches = append(mismatches, Mismatch{Path: expected.Path, Reason: "missing"})
+		case f != expected:
+			mismatches = append(mismatches, Mismatch{Path: expected.Path, Reason: "changed"})
+		}
+	}
+	for path := range actual {
+		mismatches = append(mismatches, Mismatch{Path: path, Reason: "unexpected"})
+	}
+	sort.Slice(mismatches, func(i, j int) bool {
+		return mismatches[i].Path < mismatches[j].Path
+	})
+	return mismatches, nil
+}
+
+// Sha256 returns the hex encoded SHA-256 of the data, as it is recorded in the manifest.
+func Sha256(data []byte) string {
+	hash := sha256.Sum256(data)
+	return hex.EncodeToString(hash[:])
+}
+
+// TreeDigest returns the SHA-256 of the folder. It hashes a line "<sha256>  <path>" for every file in lexical order of the
+// paths, which matches the output of sha256sum on the sorted files. Symbolic links are not followed, their target path is
+// hashed instead.
+func TreeDigest(folder string) (string, error) {
+	files, err := listFiles(folder)
+	if err != nil {
+		return "", err
+	}
+	digest := sha256.New()
+	for _, f := range files {
+		_, _ = fmt.Fprintf(digest, "%s  %s\n", f.Sha256, f.Path)
+	}
+	return hex.EncodeToString(digest.Sum(nil)), nil
+}
+
+// listFiles hashes every file below the folder, except for the manifest and its signature in the root of the folder.
+func listFiles(folder string) ([]ManifestFile, error) {
+	var files []ManifestFile
+	err := filepath.WalkDir(folder, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			return nil
+		}
+		rel, err := filepath.Rel(folder, path)
+		if err != nil {
+			return err
+		}
+		if rel == ManifestFileName || rel == SignatureFileName {
+			return nil
+		}
+		f, err := hashFile(path, d)
+		if err != nil {
+			return err
+		}
+		f.Path = filepath.ToSlash(rel)
+		files = append(files, f)
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to hash the files in %s: %w", folder, err)
+	}
+	return files, nil
+}
+
+func hashFile(path string, d fs.DirEntry) (ManifestFile, error) {
+	info, err := d.Info()
+	if err != nil {
+		return ManifestFile{}, err
+	}
+	f := ManifestFile{Size: info.Size(), Mode: info.Mode().String()}
+	digest := sha256.New()
+	if info.Mode()&fs.ModeSymlink != 0 {
+		f.Link, err = os.Readlink(path)
+		if err != nil {
+			return ManifestFile{}, err
+		}
+		digest.Write([]byte(f.Link))
+	} else {
+		file, err := os.Open(path)
+		if err != nil {
+			return ManifestFile{}, err
+		}
+		defer file.Close()
+		if _, err := io.Copy(digest, file); err != nil {
+			return ManifestFile{}, err
+		}
+	}
+	f.Sha256 = hex.EncodeToString(digest.Sum(nil))
+	return f, nil
+}
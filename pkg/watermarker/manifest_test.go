@@ -0,0 +1,112 @@
+package watermarking
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	version "github.com/openshift/must-gather-clean/pkg/version"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func sha256Hex(s string) string {
+	sum := sha256.Sum256([]byte(s))
+	return hex.EncodeToString(sum[:])
+}
+
+func writeTree(t *testing.T, files map[string]string) string {
+	dir := t.TempDir()
+	for path, content := range files {
+		require.NoError(t, os.MkdirAll(filepath.Join(dir, filepath.Dir(path)), 0700))
+		require.NoError(t, os.WriteFile(filepath.Join(dir, path), []byte(content), 0600))
+	}
+	return dir
+}
+
+func TestTreeDigest(t *testing.T) {
+	dir := writeTree(t, map[string]string{"b.yaml": "b", "a/a.log": "a"})
+
+	digest, err := TreeDigest(dir)
+	require.NoError(t, err)
+	expected := sha256Hex(sha256Hex("a") + "  a/a.log\n" + sha256Hex("b") + "  b.yaml\n")
+	assert.Equal(t, expected, digest)
+
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "b.yaml"), []byte("changed"), 0600))
+	changed, err := TreeDigest(dir)
+	require.NoError(t, err)
+	assert.NotEqual(t, digest, changed)
+}
+
+func TestManifestWaterMarker(t *testing.T) {
+	input := writeTree(t, map[string]string{"a.log": "10.0.0.1"})
+	output := writeTree(t, map[string]string{"a.log": "x-ipv4-0000000001-x", "pods/b.yaml": "kind: Pod"})
+	require.NoError(t, os.Symlink("a.log", filepath.Join(output, "link.log")))
+
+	w := NewManifestWaterMarker(ManifestOptions{Config: []byte("config: {}\n"), InputPath: input})
+	require.NoError(t, w.WriteWaterMarkFile(output))
+	require.FileExists(t, filepath.Join(output, "watermark.txt"))
+
+	data, err := os.ReadFile(filepath.Join(output, ManifestFileName))
+	require.NoError(t, err)
+	var manifest Manifest
+	require.NoError(t, json.Unmarshal(data, &manifest))
+
+	inputDigest, err := TreeDigest(input)
+	require.NoError(t, err)
+	assert.Equal(t, inputDigest, manifest.InputSha256)
+	assert.Equal(t, sha256Hex("config: {}\n"), manifest.ConfigSha256)
+	assert.Empty(t, manifest.SeedSha256)
+	assert.Equal(t, version.GetVersion(), manifest.Version)
+	assert.NotEmpty(t, manifest.Timestamp)
+
+	watermark, err := os.ReadFile(filepath.Join(output, "watermark.txt"))
+	require.NoError(t, err)
+	watermarkInfo, err := os.Lstat(filepath.Join(output, "watermark.txt"))
+	require.NoError(t, err)
+	assert.Equal(t, []ManifestFile{
+		{Path: "a.log", Sha256: sha256Hex("x-ipv4-0000000001-x"), Size: 19, Mode: "-rw-------"},
+		{Path: "link.log", Sha256: sha256Hex("a.log"), Size: 5, Mode: manifest.Files[1].Mode, Link: "a.log"},
+		{Path: "pods/b.yaml", Sha256: sha256Hex("kind: Pod"), Size: 9, Mode: "-rw-------"},
+		{Path: "watermark.txt", Sha256: sha256Hex(string(watermark)), Size: int64(len(watermark)), Mode: watermarkInfo.Mode().String()},
+	}, manifest.Files)
+	assert.Equal(t, "L", manifest.Files[1].Mode[:1])
+}
+
+func TestCheckManifest(t *testing.T) {
+	output := writeTree(t, map[string]string{"a.log": "a", "b.log": "b", "c.log": "c"})
+	_, key, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	require.NoError(t, NewManifestWaterMarker(ManifestOptions{Key: key}).WriteWaterMarkFile(output))
+
+	manifest, data, err := ReadManifest(output)
+	require.NoError(t, err)
+	signature, err := os.ReadFile(filepath.Join(output, SignatureFileName))
+	require.NoError(t, err)
+	signer, err := VerifySignature(data, signature)
+	require.NoError(t, err)
+	assert.Equal(t, key.Public(), signer)
+
+	assert.Empty(t, manifest.InputSha256, "the input is only digested if its path is given")
+
+	mismatches, err := CheckManifest(output, manifest)
+	require.NoError(t, err)
+	assert.Empty(t, mismatches)
+
+	require.NoError(t, os.WriteFile(filepath.Join(output, "b.log"), []byte("changed"), 0600))
+	require.NoError(t, os.Remove(filepath.Join(output, "c.log")))
+	require.NoError(t, os.WriteFile(filepath.Join(output, "added.log"), []byte("added"), 0600))
+	mismatches, err = CheckManifest(output, manifest)
+	require.NoError(t, err)
+	assert.Equal(t, []Mismatch{
+		{Path: "added.log", Reason: "unexpected"},
+		{Path: "b.log", Reason: "changed"},
+		{Path: "c.log", Reason: "missing"},
+	}, mismatches)
+	assert.Equal(t, "changed: b.log", mismatches[1].String())
+}
@@ -0,0 +1,289 @@
+package watermarking
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"crypto/sha512"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"strings"
+)
+
+// SignatureFileName is the name of the signature of the manifest, written next to it in the output folder.
+const SignatureFileName = ManifestFileName + ".sig"
+
+// SignatureNamespace is the namespace of the SSH signatures of the manifest, it has to be passed to ssh-keygen -Y verify
+// with -n to verify a signature without this tool.
+const SignatureNamespace = "must-gather-clean"
+
+const (
+	sshSignatureType     = "SSH SIGNATURE"
+	sshPrivateKeyType    = "OPENSSH PRIVATE KEY"
+	sshSignatureMagic    = "SSHSIG"
+	sshSignatureVersion  = 1
+	sshSignatureHash     = "sha512"
+	sshPrivateKeyMagic   = "openssh-key-v1\x00"
+	sshEd25519KeyType    = "ssh-ed25519"
+	sshUnencryptedCipher = "none"
+)
+
+// Sign returns the armored SSH signature of the message, in the format of ssh-keygen -Y sign with the
+// SignatureNamespace.
+func Sign(message []byte, key ed25519.PrivateKey) []byte {
+	publicKey := key.Public().(ed25519.PublicKey)
+	signature := ed25519.Sign(key, signedData(message))
+
+	blob := &bytes.Buffer{}
+	blob.WriteString(sshSignatureMagic)
+	_ = binary.Write(blob, binary.BigEndian, uint32(sshSignatureVersion))
+	writeString(blob, marshalPublicKey(publicKey))
+	writeString(blob, []byte(SignatureNamespace))
+	writeString(blob, nil)
+	writeString(blob, []byte(sshSignatureHash))
+	encodedSignature := &bytes.Buffer{}
+	writeString(encodedSignature, []byte(sshEd25519KeyType))
+	writeString(encodedSignature, signature)
+	writeString(blob, encodedSignature.Bytes())
+
+	return pem.EncodeToMemory(&pem.Block{Type: sshSignatureType, Bytes: blob.Bytes()})
+}
+
+// VerifySignature checks the armored SSH signature of the message and returns the public key that created it. Only
+// ed25519 signatures in the SignatureNamespace are accepted.
+func VerifySignature(message []byte, armored []byte) (ed25519.PublicKey, error) {
+	block, _ := pem.Decode(armored)
+	if block == nil || block.Type != sshSignatureType {
+		return nil, errors.New("not an SSH signature")
+	}
+	blob := block.Bytes
+	if !bytes.HasPrefix(blob, []byte(sshSignatureMagic)) {
+		return nil, errors.New("invalid SSH signature magic")
+	}
+	reader := &sshReader{data: blob[len(sshSignatureMagic):]}
+	version := reader.uint32()
+	publicKey := reader.string()
+	namespace := reader.string()
+	_ = reader.string()
+	hash := reader.string()
+	encodedSignature := reader.string()
+	if reader.err != nil {
+		return nil, fmt.Errorf("invalid SSH signature: %w", reader.err)
+	}
+	if version != sshSignatureVersion {
+		return nil, fmt.Errorf("unsupported SSH signature version %d", version)
+	}
+	if string(namespace) != SignatureNamespace {
+		return nil, fmt.Errorf("SSH signature is for namespace '%s', expected '%s'", namespace, SignatureNamespace)
+	}
+	if string(hash) != sshSignatureHash {
+		return nil, fmt.Errorf("unsupported SSH signature hash '%s', expected '%s'", hash, sshSignatureHash)
+	}
+	key, err := unmarshalPublicKey(publicKey)
+	if err != nil {
+		return nil, err
+	}
+	reader = &sshReader{data: encodedSignature}
+	signatureType := reader.string()
+	signature := reader.string()
+	if reader.err != nil {
+		return nil, fmt.Errorf("invalid SSH signature: %w", reader.err)
+	}
+	if string(signatureType) != sshEd25519KeyType {
+		return nil, fmt.Errorf("unsupported SSH signature type '%s', expected '%s'", signatureType, sshEd25519KeyType)
+	}
+	if !ed25519.Verify(key, signedData(message), signature) {
+		return nil, errors.New("SSH signature does not match")
+	}
+	return key, nil
+}
+
+// signedData is what is actually signed for an SSH signature, the hash of the message along with the namespace.
+func signedData(message []byte) []byte {
+	hash := sha512.Sum512(message)
+	data := &bytes.Buffer{}
+	data.WriteString(sshSignatureMagic)
+	writeString(data, []byte(SignatureNamespace))
+	writeString(data, nil)
+	writeString(data, []byte(sshSignatureHash))
+	writeString(data, hash[:])
+	return data.Bytes()
+}
+
+// Fingerprint returns the SHA-256 fingerprint of the key, as shown by ssh-keygen -l.
+func Fingerprint(key ed25519.PublicKey) string {
+	hash := sha256.Sum256(marshalPublicKey(key))
+	return "SHA256:" + base64.RawStdEncoding.EncodeToString(hash[:])
+}
+
+// ReadSigningKey reads an unencrypted ed25519 private key, either in the OpenSSH format as generated by
+// ssh-keygen -t ed25519 or PEM encoded in PKCS #8 form.
+func ReadSigningKey(path string) (ed25519.PrivateKey, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read signing key %s: %w", path, err)
+	}
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("signing key %s is not PEM encoded", path)
+	}
+	if block.Type == sshPrivateKeyType {
+		key, err := parseOpenSSHPrivateKey(block.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse signing key %s: %w", path, err)
+		}
+		return key, nil
+	}
+	key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse signing key %s: %w", path, err)
+	}
+	edKey, ok := key.(ed25519.PrivateKey)
+	if !ok {
+		return nil, fmt.Errorf("signing key %s is not an ed25519 key", path)
+	}
+	return edKey, nil
+}
+
+// ReadVerifyingKey reads an ed25519 public key, either as a line of an OpenSSH public key file like id_ed25519.pub or
+// PEM encoded in PKIX form.
+func ReadVerifyingKey(path string) (ed25519.PublicKey, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read public key %s: %w", path, err)
+	}
+	if block, _ := pem.Decode(data); block != nil {
+		key, err := x509.ParsePKIXPublicKey(block.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse public key %s: %w", path, err)
+		}
+		edKey, ok := key.(ed25519.PublicKey)
+		if !ok {
+			return nil, fmt.Errorf("public key %s is not an ed25519 key", path)
+		}
+		return edKey, nil
+	}
+	fields := strings.Fields(string(data))
+	if len(fields) < 2 || fields[0] != sshEd25519KeyType {
+		return nil, fmt.Errorf("public key %s is neither PEM encoded nor an %s key", path, sshEd25519KeyType)
+	}
+	wire, err := base64.StdEncoding.DecodeString(fields[1])
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse public key %s: %w", path, err)
+	}
+	key, err := unmarshalPublicKey(wire)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse public key %s: %w", path, err)
+	}
+	return key, nil
+}
+
+// parseOpenSSHPrivateKey parses the first key of an unencrypted openssh-key-v1 container, see PROTOCOL.key of OpenSSH.
+func parseOpenSSHPrivateKey(data []byte) (ed25519.PrivateKey, error) {
+	if !bytes.HasPrefix(data, []byte(sshPrivateKeyMagic)) {
+		return nil, errors.New("invalid OpenSSH private key magic")
+	}
+	reader := &sshReader{data: data[len(sshPrivateKeyMagic):]}
+	cipher := reader.string()
+	_ = reader.string()
+	_ = reader.string()
+	keys := reader.uint32()
+	_ = reader.string()
+	private := reader.string()
+	if reader.err != nil {
+		return nil, reader.err
+	}
+	if string(cipher) != sshUnencryptedCipher {
+		return nil, errors.New("encrypted OpenSSH private keys are not supported, remove the passphrase with ssh-keygen -p")
+	}
+	if keys != 1 {
+		return nil, fmt.Errorf("expected a single key, found %d", keys)
+	}
+
+	reader = &sshReader{data: private}
+	check1 := reader.uint32()
+	check2 := reader.uint32()
+	keyType := reader.string()
+	_ = reader.string()
+	key := reader.string()
+	if reader.err != nil {
+		return nil, reader.err
+	}
+	if check1 != check2 {
+		return nil, errors.New("invalid OpenSSH private key check")
+	}
+	if string(keyType) != sshEd25519KeyType {
+		return nil, fmt.Errorf("key type '%s' is not supported, expected '%s'", keyType, sshEd25519KeyType)
+	}
+	if len(key) != ed25519.PrivateKeySize {
+		return nil, fmt.Errorf("invalid ed25519 private key size %d", len(key))
+	}
+	return ed25519.PrivateKey(key), nil
+}
+
+func marshalPublicKey(key ed25519.PublicKey) []byte {
+	buffer := &bytes.Buffer{}
+	writeString(buffer, []byte(sshEd25519KeyType))
+	writeString(buffer, key)
+	return buffer.Bytes()
+}
+
+func unmarshalPublicKey(wire []byte) (ed25519.PublicKey, error) {
+	reader := &sshReader{data: wire}
+	keyType := reader.string()
+	key := reader.string()
+	if reader.err != nil {
+		return nil, fmt.Errorf("invalid SSH public key: %w", reader.err)
+	}
+	if string(keyType) != sshEd25519KeyType {
+		return nil, fmt.Errorf("key type '%s' is not supported, expected '%s'", keyType, sshEd25519KeyType)
+	}
+	if len(key) != ed25519.PublicKeySize {
+		return nil, fmt.Errorf("invalid ed25519 public key size %d", len(key))
+	}
+	return ed25519.PublicKey(key), nil
+}
+
+// writeString writes the data in the SSH wire format, prefixed with its length.
+func writeString(buffer *bytes.Buffer, data []byte) {
+	_ = binary.Write(buffer, binary.BigEndian, uint32(len(data)))
+	buffer.Write(data)
+}
+
+// sshReader reads the SSH wire format, the first error is kept and all further reads return zero values.
+type sshReader struct {
+	data []byte
+	err  error
+}
+
+func (r *sshReader) uint32() uint32 {
+	if r.err != nil {
+		return 0
+	}
+	if len(r.data) < 4 {
+		r.err = errors.New("unexpected end of data")
+		return 0
+	}
+	v := binary.BigEndian.Uint32(r.data)
+	r.data = r.data[4:]
+	return v
+}
+
+func (r *sshReader) string() []byte {
+	length := r.uint32()
+	if r.err != nil {
+		return nil
+	}
+	if uint32(len(r.data)) < length {
+		r.err = errors.New("unexpected end of data")
+		return nil
+	}
+	s := r.data[:length]
+	r.data = r.data[length:]
+	return s
+}
@@ -0,0 +1,116 @@
+package watermarking
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/pem"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// the testfiles were generated with ssh-keygen -t ed25519 and ssh-keygen -Y sign -n must-gather-clean
+const (
+	testPrivateKey = "testfiles/id_ed25519"
+	testPublicKey  = "testfiles/id_ed25519.pub"
+	testMessage    = "testfiles/message.json"
+	testSignature  = "testfiles/message.json.sig"
+)
+
+func TestReadOpenSSHKeys(t *testing.T) {
+	privateKey, err := ReadSigningKey(testPrivateKey)
+	require.NoError(t, err)
+	publicKey, err := ReadVerifyingKey(testPublicKey)
+	require.NoError(t, err)
+	assert.True(t, publicKey.Equal(privateKey.Public()))
+}
+
+func TestReadPEMKeys(t *testing.T) {
+	publicKey, privateKey, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	privateBytes, err := x509.MarshalPKCS8PrivateKey(privateKey)
+	require.NoError(t, err)
+	publicBytes, err := x509.MarshalPKIXPublicKey(publicKey)
+	require.NoError(t, err)
+	dir := t.TempDir()
+	privatePath := filepath.Join(dir, "private.pem")
+	publicPath := filepath.Join(dir, "public.pem")
+	require.NoError(t, os.WriteFile(privatePath, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: privateBytes}), 0600))
+	require.NoError(t, os.WriteFile(publicPath, pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: publicBytes}), 0600))
+
+	readPrivate, err := ReadSigningKey(privatePath)
+	require.NoError(t, err)
+	assert.Equal(t, privateKey, readPrivate)
+	readPublic, err := ReadVerifyingKey(publicPath)
+	require.NoError(t, err)
+	assert.Equal(t, publicKey, readPublic)
+}
+
+func TestReadKeysFailures(t *testing.T) {
+	dir := t.TempDir()
+	notPEM := filepath.Join(dir, "key")
+	require.NoError(t, os.WriteFile(notPEM, []byte("ssh-rsa AAAA comment"), 0600))
+
+	_, err := ReadSigningKey(notPEM)
+	assert.EqualError(t, err, "signing key "+notPEM+" is not PEM encoded")
+	_, err = ReadVerifyingKey(notPEM)
+	assert.EqualError(t, err, "public key "+notPEM+" is neither PEM encoded nor an ssh-ed25519 key")
+	_, err = ReadSigningKey(filepath.Join(dir, "missing"))
+	assert.Error(t, err)
+}
+
+func TestVerifySSHKeygenSignature(t *testing.T) {
+	message, err := os.ReadFile(testMessage)
+	require.NoError(t, err)
+	signature, err := os.ReadFile(testSignature)
+	require.NoError(t, err)
+	expected, err := ReadVerifyingKey(testPublicKey)
+	require.NoError(t, err)
+
+	signer, err := VerifySignature(message, signature)
+	require.NoError(t, err)
+	assert.Equal(t, expected, signer)
+
+	_, err = VerifySignature(append(message, ' '), signature)
+	assert.EqualError(t, err, "SSH signature does not match")
+}
+
+func TestSignMatchesSSHKeygen(t *testing.T) {
+	key, err := ReadSigningKey(testPrivateKey)
+	require.NoError(t, err)
+	message, err := os.ReadFile(testMessage)
+	require.NoError(t, err)
+	expected, err := os.ReadFile(testSignature)
+	require.NoError(t, err)
+
+	// ed25519 signatures are deterministic, so signing the same message yields the same blob as ssh-keygen
+	expectedBlock, _ := pem.Decode(expected)
+	signedBlock, _ := pem.Decode(Sign(message, key))
+	require.NotNil(t, signedBlock)
+	assert.Equal(t, expectedBlock.Bytes, signedBlock.Bytes)
+}
+
+func TestVerifySignatureFailures(t *testing.T) {
+	_, key, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	signature := Sign([]byte("message"), key)
+
+	_, err = VerifySignature([]byte("message"), []byte("garbage"))
+	assert.EqualError(t, err, "not an SSH signature")
+
+	block, _ := pem.Decode(signature)
+	truncated := pem.EncodeToMemory(&pem.Block{Type: block.Type, Bytes: block.Bytes[:20]})
+	_, err = VerifySignature([]byte("message"), truncated)
+	assert.EqualError(t, err, "invalid SSH signature: unexpected end of data")
+}
+
+func TestFingerprint(t *testing.T) {
+	key, err := ReadVerifyingKey(testPublicKey)
+	require.NoError(t, err)
+	// as shown by ssh-keygen -l
+	assert.Equal(t, "SHA256:R1tDjmUZUN2iTO9dpAJAQzxRWl14SpZQGRlm2zWFEG4", Fingerprint(key))
+}